@@ -0,0 +1,102 @@
+// Package apperr defines the errors handlers return and the Fiber error
+// handler that writes them as RFC 7807 problem details.
+package apperr
+
+import (
+	"blog-api/validate"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Error is an error meant for the client: an HTTP status, a stable
+// machine-readable code and a human-readable detail. The underlying cause,
+// if any, is only logged.
+type Error struct {
+	Status int
+	Code   string
+	Detail string
+	// Fields lists the invalid fields of a validation error.
+	Fields validate.Errors
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Detail, e.Err)
+	}
+	return e.Code + ": " + e.Detail
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns a copy of e caused by err, so that shared errors can carry
+// the details of one failure.
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+func BadRequest(code, detail string) *Error {
+	return New(http.StatusBadRequest, code, detail)
+}
+
+func Unauthorized(code, detail string) *Error {
+	return New(http.StatusUnauthorized, code, detail)
+}
+
+func Forbidden(code, detail string) *Error {
+	return New(http.StatusForbidden, code, detail)
+}
+
+func NotFound(code, detail string) *Error {
+	return New(http.StatusNotFound, code, detail)
+}
+
+func Conflict(code, detail string) *Error {
+	return New(http.StatusConflict, code, detail)
+}
+
+// Internal reports an unexpected failure. The client only learns that
+// something went wrong; err is logged with the request ID.
+func Internal(err error) *Error {
+	return &Error{
+		Status: http.StatusInternalServerError,
+		Code:   "internal_error",
+		Detail: "An unexpected error occurred",
+		Err:    err,
+	}
+}
+
+// From returns err as an *Error. Validation errors become a 422 listing
+// the invalid fields, Fiber errors, such as unknown routes or oversized
+// bodies, keep their status, and anything else is Internal.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var fields validate.Errors
+	if errors.As(err, &fields) {
+		return &Error{
+			Status: http.StatusUnprocessableEntity,
+			Code:   "validation_failed",
+			Detail: "The request contains invalid fields",
+			Fields: fields,
+		}
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return New(fe.Code, statusCode(fe.Code), fe.Message)
+	}
+	return Internal(err)
+}
@@ -0,0 +1,139 @@
+package apperr
+
+import (
+	"blog-api/validate"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+func TestFrom(t *testing.T) {
+	notFound := NotFound("article_not_found", "Article not found")
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"app error", notFound, http.StatusNotFound, "article_not_found"},
+		{"wrapped app error", errors.Join(errors.New("context"), notFound), http.StatusNotFound, "article_not_found"},
+		{"validation errors", validate.Errors{{Field: "email", Rule: "required"}}, http.StatusUnprocessableEntity, "validation_failed"},
+		{"fiber error", fiber.ErrRequestEntityTooLarge, http.StatusRequestEntityTooLarge, "request_entity_too_large"},
+		{"fiber error with an unknown status", fiber.NewError(599, "odd"), 599, "error"},
+		{"other error", errors.New("connection refused"), http.StatusInternalServerError, "internal_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := From(tt.err)
+			if e.Status != tt.status || e.Code != tt.code {
+				t.Fatalf("From = %d %q, want %d %q", e.Status, e.Code, tt.status, tt.code)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	base := Unauthorized("invalid_token", "Invalid token")
+	cause := errors.New("expired")
+	wrapped := base.Wrap(cause)
+	if base.Err != nil {
+		t.Fatal("Wrap changed the shared error")
+	}
+	if !errors.Is(wrapped, cause) || wrapped.Code != "invalid_token" {
+		t.Fatalf("wrapped = %v", wrapped)
+	}
+	if got := wrapped.Error(); got != "invalid_token: Invalid token: expired" {
+		t.Fatalf("Error() = %q", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: Handler})
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return NotFound("article_not_found", "Article not found")
+	})
+	app.Get("/broken", func(c *fiber.Ctx) error {
+		return errors.New("pq: password authentication failed")
+	})
+	traced := app.Group("/traced", requestid.New())
+	traced.Get("/", func(c *fiber.Ctx) error {
+		return BadRequest("invalid_limit", "Bad limit")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		code   string
+		detail string
+	}{
+		{"/missing", http.StatusNotFound, "article_not_found", "Article not found"},
+		{"/broken", http.StatusInternalServerError, "internal_error", "An unexpected error occurred"},
+		{"/nowhere", http.StatusNotFound, "not_found", "Cannot GET /nowhere"},
+		{"/traced", http.StatusBadRequest, "invalid_limit", "Bad limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			var p Problem
+			if err := json.Unmarshal(body, &p); err != nil {
+				t.Fatalf("body %s: %v", body, err)
+			}
+			if resp.StatusCode != tt.status || resp.Header.Get("Content-Type") != ContentType {
+				t.Fatalf("response = %d %q, want %d %q", resp.StatusCode, resp.Header.Get("Content-Type"), tt.status, ContentType)
+			}
+			if p.Status != tt.status || p.Code != tt.code || p.Detail != tt.detail || p.Title != http.StatusText(tt.status) || p.Type != "about:blank" || p.Instance != tt.path {
+				t.Fatalf("problem = %+v", p)
+			}
+			// The request ID is the one of the requestid middleware when
+			// installed, or a new one, and is sent in both places.
+			if p.RequestID == "" || resp.Header.Get(fiber.HeaderXRequestID) != p.RequestID {
+				t.Fatalf("request ID %q, header %q", p.RequestID, resp.Header.Get(fiber.HeaderXRequestID))
+			}
+			if strings.Contains(string(body), "pq:") {
+				t.Fatalf("body leaks the cause: %s", body)
+			}
+		})
+	}
+}
+
+func TestHandlerOmitsQuery(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	app := fiber.New(fiber.Config{ErrorHandler: Handler})
+	app.Get("/stream", func(c *fiber.Ctx) error {
+		return errors.New("broken")
+	})
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/stream?access_token=secret", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	var p Problem
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatalf("body %s: %v", body, err)
+	}
+	if p.Instance != "/stream" || !strings.Contains(logs.String(), "GET /stream: broken") {
+		t.Fatalf("instance %q, log %q, want the path alone", p.Instance, logs.String())
+	}
+	if strings.Contains(string(body)+logs.String(), "secret") {
+		t.Fatalf("token leaked: body %s, log %q", body, logs.String())
+	}
+}
@@ -0,0 +1,73 @@
+package apperr
+
+import (
+	"blog-api/validate"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// Problem is the RFC 7807 body of an error response, extended with the
+// error code, the request ID and, for validation errors, the invalid fields.
+type Problem struct {
+	Type      string          `json:"type"`
+	Title     string          `json:"title"`
+	Status    int             `json:"status"`
+	Detail    string          `json:"detail"`
+	Instance  string          `json:"instance"`
+	Code      string          `json:"code"`
+	RequestID string          `json:"request_id"`
+	Errors    validate.Errors `json:"errors,omitempty"`
+}
+
+// Handler is the Fiber ErrorHandler of the application. It answers with the
+// problem details of err and logs server errors with their cause. The
+// request ID comes from the requestid middleware, or is generated when it
+// is not installed, and is sent in the X-Request-ID header as well. Neither
+// the log nor the problem has the query string, which may hold a token.
+func Handler(c *fiber.Ctx, err error) error {
+	e := From(err)
+	requestID := RequestID(c)
+
+	if e.Status >= 500 {
+		log.Printf("[%s] %s %s: %v", requestID, c.Method(), c.Path(), err)
+	}
+
+	return c.Status(e.Status).JSON(Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(e.Status),
+		Status:    e.Status,
+		Detail:    e.Detail,
+		Instance:  c.Path(),
+		Code:      e.Code,
+		RequestID: requestID,
+		Errors:    e.Fields,
+	}, ContentType)
+}
+
+// RequestID returns the ID of the current request, assigning one if the
+// requestid middleware has not.
+func RequestID(c *fiber.Ctx) string {
+	id := c.GetRespHeader(fiber.HeaderXRequestID)
+	if id == "" {
+		id = uuid.New().String()
+		c.Set(fiber.HeaderXRequestID, id)
+	}
+	return id
+}
+
+// statusCode turns a status into a code for errors raised by Fiber itself:
+// 404 becomes "not_found".
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
@@ -0,0 +1,381 @@
+// Package config loads the settings of the server.
+//
+// Every setting has an environment variable name, such as
+// DB_MAX_OPEN_CONNS. Sources are applied in this order, each one overriding
+// the previous ones:
+//
+//  1. the defaults of Default;
+//  2. an optional YAML or TOML file, given by -config or CONFIG_FILE, whose
+//     keys are the variable names in lower case (db_max_open_conns), or
+//     nested in sections joined with underscores (db: {max_open_conns: 20});
+//  3. a .env file in the working directory, when there is one;
+//  4. environment variables;
+//  5. command-line flags, the variable names in lower case with dashes
+//     (-db-max-open-conns).
+//
+// Validate then checks the result and reports every invalid setting at
+// once; Database.Validate checks the database settings alone, for commands
+// that need nothing else.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Config holds every setting. Fields are tagged with their variable name
+// and a description shown by -help.
+type Config struct {
+	Port int `env:"PORT" usage:"HTTP port"`
+	// SiteURL is the public address of the blog, used in feed links.
+	SiteURL string `env:"SITE_URL" usage:"public address of the blog, used in feed links"`
+	// ProxyHeader carries the client address behind a reverse proxy. It is
+	// only read on requests coming from TrustedProxies, so that clients
+	// reaching the server directly cannot pick their own address.
+	ProxyHeader string `env:"PROXY_HEADER" usage:"header carrying the client address behind a reverse proxy, such as X-Forwarded-For"`
+	// TrustedProxies are the addresses or CIDR ranges of the proxies.
+	TrustedProxies []string `env:"TRUSTED_PROXIES" usage:"comma-separated addresses or CIDR ranges of the proxies trusted to set PROXY_HEADER"`
+	// CORSAllowOrigins are the origins allowed by CORS, or "*".
+	CORSAllowOrigins []string `env:"CORS_ALLOW_ORIGINS" usage:"comma-separated origins allowed by CORS, or *"`
+
+	Server   Server
+	Database Database
+	Auth     Auth
+	Media    Media
+
+	CommentMaxDepth     int           `env:"COMMENT_MAX_DEPTH" usage:"deepest reply level of comments"`
+	RealtimeBroadcaster string        `env:"REALTIME_BROADCASTER" usage:"local or postgres"`
+	RateLimitStore      string        `env:"RATE_LIMIT_STORE" usage:"memory, postgres or none"`
+	RateLimits          string        `env:"RATE_LIMITS" usage:"rate limit overrides, such as comments=10/1m,likes=60/1m"`
+	IdempotencyStore    string        `env:"IDEMPOTENCY_STORE" usage:"memory, postgres or none"`
+	IdempotencyExpiry   time.Duration `env:"IDEMPOTENCY_EXPIRY" usage:"how long idempotency keys are remembered"`
+	SchedulerInterval   time.Duration `env:"SCHEDULER_INTERVAL" usage:"how often scheduled articles are published"`
+	ScoreInterval       time.Duration `env:"SCORE_INTERVAL" usage:"how often ranking scores are refreshed"`
+	RenderInterval      time.Duration `env:"RENDER_INTERVAL" usage:"how often content rendered by an older Markdown version is rendered again"`
+
+	Features Features
+}
+
+// Server tunes the HTTP server and its shutdown.
+type Server struct {
+	ReadTimeout  time.Duration `env:"HTTP_READ_TIMEOUT" usage:"maximum time to read a request, body included, 0 for no limit"`
+	WriteTimeout time.Duration `env:"HTTP_WRITE_TIMEOUT" usage:"maximum time to write a response, 0 for no limit"`
+	IdleTimeout  time.Duration `env:"HTTP_IDLE_TIMEOUT" usage:"how long an idle keep-alive connection stays open"`
+	// ShutdownTimeout is how long requests in progress may take to finish
+	// once a shutdown signal is received.
+	ShutdownTimeout time.Duration `env:"HTTP_SHUTDOWN_TIMEOUT" usage:"how long requests in progress may take to finish on shutdown"`
+	// ShutdownDelay keeps accepting requests for a while after the shutdown
+	// signal, with /readyz failing, so that load balancers stop sending
+	// new ones first.
+	ShutdownDelay time.Duration `env:"HTTP_SHUTDOWN_DELAY" usage:"how long to keep serving, not ready, after a shutdown signal"`
+	// HealthCheckTimeout bounds each check of /readyz.
+	HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" usage:"maximum time of each readiness check"`
+	// BodyLimit applies to every request but POST /api/media, whose uploads
+	// are bounded by MEDIA_MAX_UPLOAD_SIZE.
+	BodyLimit int `env:"HTTP_BODY_LIMIT" usage:"maximum size of a request body in bytes, uploads excepted"`
+
+	// TLS is served when both files are set. They are read again when they
+	// change, so that renewed certificates apply without a restart.
+	TLSCertFile       string        `env:"TLS_CERT_FILE" usage:"PEM certificate chain, to serve HTTPS"`
+	TLSKeyFile        string        `env:"TLS_KEY_FILE" usage:"PEM private key of the certificate"`
+	TLSReloadInterval time.Duration `env:"TLS_RELOAD_INTERVAL" usage:"how often the certificate files are checked for changes"`
+}
+
+// Database locates PostgreSQL and sizes the connection pool.
+type Database struct {
+	// URL is a full connection string. When empty, one is built from the
+	// other fields.
+	URL      string `env:"DATABASE_URL" usage:"PostgreSQL connection URL, instead of the DB_HOST... settings"`
+	Host     string `env:"DB_HOST" usage:"database host"`
+	Port     int    `env:"DB_PORT" usage:"database port"`
+	User     string `env:"DB_USER" usage:"database user"`
+	Password string `env:"DB_PASSWORD" usage:"database password"`
+	Name     string `env:"DB_NAME" usage:"database name"`
+	SSLMode  string `env:"DB_SSLMODE" usage:"disable, allow, prefer, require, verify-ca or verify-full"`
+
+	MaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" usage:"maximum open connections, 0 for no limit"`
+	MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" usage:"maximum idle connections"`
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" usage:"maximum lifetime of a connection, 0 for no limit"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" usage:"maximum idle time of a connection, 0 for no limit"`
+	ConnectTimeout  time.Duration `env:"DB_CONNECT_TIMEOUT" usage:"how long to wait for the database at startup"`
+	// RequireSchema refuses to start while migrations are pending.
+	RequireSchema bool `env:"DB_REQUIRE_SCHEMA" usage:"refuse to start while migrations are pending"`
+}
+
+// DSN returns URL, or a connection string built from the other fields.
+func (d Database) DSN() string {
+	if d.URL != "" {
+		return d.URL
+	}
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quoteDSN(d.Host), d.Port, quoteDSN(d.User), quoteDSN(d.Password), quoteDSN(d.Name), d.SSLMode)
+}
+
+// quoteDSN quotes a connection string value when it needs it.
+func quoteDSN(s string) string {
+	if s != "" && !strings.ContainsAny(s, ` '\`) {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// Auth configures the verification of Supabase tokens.
+type Auth struct {
+	JWTSecret string `env:"SUPABASE_JWT_SECRET" usage:"Supabase JWT secret for HS256 tokens"`
+	JWKSFile  string `env:"JWT_JWKS_FILE" usage:"JWKS file with the RS256 public keys"`
+	Audience  string `env:"JWT_AUDIENCE" usage:"expected audience of tokens"`
+}
+
+// Media configures where uploaded images are kept.
+type Media struct {
+	Storage       string `env:"MEDIA_STORAGE" usage:"local, s3 or none"`
+	Dir           string `env:"MEDIA_DIR" usage:"directory of local media storage"`
+	URL           string `env:"MEDIA_URL" usage:"public URL of local media, served by the API when empty"`
+	MaxUploadSize int64  `env:"MEDIA_MAX_UPLOAD_SIZE" usage:"maximum size of an upload in bytes"`
+
+	S3Endpoint        string `env:"S3_ENDPOINT" usage:"S3 endpoint"`
+	S3Region          string `env:"S3_REGION" usage:"S3 region"`
+	S3Bucket          string `env:"S3_BUCKET" usage:"S3 bucket"`
+	S3AccessKeyID     string `env:"S3_ACCESS_KEY_ID" usage:"S3 access key ID"`
+	S3SecretAccessKey string `env:"S3_SECRET_ACCESS_KEY" usage:"S3 secret access key"`
+	S3PublicURL       string `env:"S3_PUBLIC_URL" usage:"public URL of the S3 bucket"`
+}
+
+// Features turns optional parts of the server on and off.
+type Features struct {
+	// Feeds serves the RSS, Atom and JSON feeds under /feeds.
+	Feeds bool `env:"FEATURE_FEEDS" usage:"serve the RSS, Atom and JSON feeds"`
+	// Workers runs the background jobs. Turn it off on all instances but
+	// one to run them in a single place.
+	Workers bool `env:"FEATURE_WORKERS" usage:"run the background jobs on this instance"`
+}
+
+// Default returns the settings used when no source sets them.
+func Default() *Config {
+	return &Config{
+		Port:             4000,
+		CORSAllowOrigins: []string{"*"},
+		Server: Server{
+			ReadTimeout:        30 * time.Second,
+			WriteTimeout:       30 * time.Second,
+			IdleTimeout:        2 * time.Minute,
+			ShutdownTimeout:    30 * time.Second,
+			HealthCheckTimeout: 2 * time.Second,
+			BodyLimit:          1 << 20,
+			TLSReloadInterval:  time.Minute,
+		},
+		Database: Database{
+			Port:            5432,
+			SSLMode:         "require",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectTimeout:  10 * time.Second,
+		},
+		Media: Media{
+			Storage:       "local",
+			Dir:           "uploads",
+			MaxUploadSize: 10 << 20,
+		},
+		CommentMaxDepth:     5,
+		RealtimeBroadcaster: "local",
+		RateLimitStore:      "memory",
+		IdempotencyStore:    "memory",
+		IdempotencyExpiry:   24 * time.Hour,
+		SchedulerInterval:   30 * time.Second,
+		ScoreInterval:       5 * time.Minute,
+		RenderInterval:      time.Hour,
+		Features: Features{
+			Feeds:   true,
+			Workers: true,
+		},
+	}
+}
+
+// Load reads the settings from every source, given the command-line
+// arguments without the program name. It returns the arguments left after
+// the flags, such as a subcommand. -help returns flag.ErrHelp once the usage
+// has been printed. The settings are not validated yet: what needs to be
+// depends on the subcommand.
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	fs := flag.NewFlagSet("blog-api", flag.ContinueOnError)
+	configFile := fs.String("config", "", "YAML or TOML configuration file (CONFIG_FILE)")
+	flags := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flags[s.env] = fs.String(flagName(s.env), "", s.usage+" ("+s.env+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	setFlags := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf(".env: %w", err)
+	}
+
+	path := *configFile
+	if path == "" {
+		path = lookup("CONFIG_FILE", dotenv)
+	}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := checkKeys(settings, path, values); err != nil {
+			return nil, nil, err
+		}
+		if err := apply(settings, path, func(env string) (string, bool) {
+			v, ok := values[strings.ToLower(env)]
+			return v, ok
+		}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sources := []struct {
+		name   string
+		lookup func(env string) (string, bool)
+	}{
+		{".env", func(env string) (string, bool) { v, ok := dotenv[env]; return v, ok }},
+		{"environment", os.LookupEnv},
+		{"flags", func(env string) (string, bool) { return *flags[env], setFlags[flagName(env)] }},
+	}
+	for _, src := range sources {
+		if err := apply(settings, src.name, src.lookup); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// checkKeys reports the keys of a configuration file that match no
+// setting, which are most likely typos.
+func checkKeys(settings []setting, path string, values map[string]string) error {
+	known := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		known[strings.ToLower(s.env)] = true
+	}
+	var unknown []string
+	for key := range values {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%s: unknown settings %s", path, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// lookup reads a variable from the environment, then from .env.
+func lookup(env string, dotenv map[string]string) string {
+	if v, ok := os.LookupEnv(env); ok {
+		return v
+	}
+	return dotenv[env]
+}
+
+func flagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
+
+// setting is a field of Config bound to its variable name.
+type setting struct {
+	env   string
+	usage string
+	value reflect.Value
+}
+
+// settings lists the tagged fields of c, including those of its sections.
+func (c *Config) settings() []setting {
+	var settings []setting
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if env := field.Tag.Get("env"); env != "" {
+				settings = append(settings, setting{env: env, usage: field.Tag.Get("usage"), value: v.Field(i)})
+			} else if field.Type.Kind() == reflect.Struct {
+				walk(v.Field(i))
+			}
+		}
+	}
+	walk(reflect.ValueOf(c).Elem())
+	return settings
+}
+
+// apply sets the settings found by lookup. source names where the values
+// come from in errors.
+func apply(settings []setting, source string, lookup func(env string) (string, bool)) error {
+	var errs []error
+	for _, s := range settings {
+		raw, ok := lookup(s.env)
+		// An empty value, as in PORT= in .env, leaves typed settings alone.
+		if !ok || (strings.TrimSpace(raw) == "" && s.value.Kind() != reflect.String) {
+			continue
+		}
+		if err := set(s.value, strings.TrimSpace(raw)); err != nil {
+			errs = append(errs, fmt.Errorf("%s (from %s): %w", s.env, source, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func set(v reflect.Value, raw string) error {
+	switch v.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	case []string:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	default:
+		panic("config: unsupported field type " + v.Type().String())
+	}
+	return nil
+}
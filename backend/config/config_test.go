@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// inDir runs the test in a temporary directory holding files, so that Load
+// finds their .env, if any.
+func inDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := inDir(t, map[string]string{
+		"config.yaml": "port: 5000\nsite_url: https://file.example.com\ndb:\n  max_open_conns: 40\n  max_idle_conns: 10\ncors_allow_origins: [https://a.example.com, https://b.example.com]\n",
+		".env":        "PORT=6000\nSITE_URL=https://dotenv.example.com\nCOMMENT_MAX_DEPTH=\nDATABASE_URL=postgres://localhost/blog\nSUPABASE_JWT_SECRET=secret\n",
+	})
+	t.Setenv("SITE_URL", "https://env.example.com")
+	t.Setenv("SCORE_INTERVAL", "1m")
+
+	cfg, args, err := Load([]string{"-config", filepath.Join(dir, "config.yaml"), "-site-url", "https://flag.example.com", "migrate", "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(args, []string{"migrate", "up"}) {
+		t.Errorf("args = %v, want the subcommand", args)
+	}
+	tests := []struct {
+		name      string
+		got, want any
+	}{
+		{"default", cfg.Media.Storage, "local"},
+		{"file", cfg.Database.MaxOpenConns, 40},
+		{"nested file key", cfg.Database.MaxIdleConns, 10},
+		{"file list", cfg.CORSAllowOrigins, []string{"https://a.example.com", "https://b.example.com"}},
+		{".env over file", cfg.Port, 6000},
+		{"empty .env value keeps the default", cfg.CommentMaxDepth, 5},
+		{"environment", cfg.ScoreInterval, time.Minute},
+		{"flag over environment", cfg.SiteURL, "https://flag.example.com"},
+	}
+	for _, tt := range tests {
+		got, want := tt.got, tt.want
+		if s, ok := got.([]string); ok {
+			if !slices.Equal(s, want.([]string)) {
+				t.Errorf("%s: got %v, want %v", tt.name, got, want)
+			}
+		} else if got != want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestLoadTOMLFromEnvironment(t *testing.T) {
+	dir := inDir(t, map[string]string{
+		"blog.toml": "database_url = \"postgres://localhost/blog\"\n[supabase]\njwt_secret = \"secret\"\n[feature]\nfeeds = false\n",
+	})
+	t.Setenv("CONFIG_FILE", filepath.Join(dir, "blog.toml"))
+
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Auth.JWTSecret != "secret" || cfg.Features.Feeds || !cfg.Features.Workers {
+		t.Fatalf("config = %+v", cfg)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	required := "DATABASE_URL=postgres://localhost/blog\nSUPABASE_JWT_SECRET=secret\n"
+	tests := []struct {
+		name  string
+		files map[string]string
+		args  []string
+		want  []string
+	}{
+		{"unknown file key", map[string]string{"c.yaml": "prot: 80\n", ".env": required}, []string{"-config", "c.yaml"}, []string{"unknown settings prot"}},
+		{"unsupported file", map[string]string{"c.json": "{}", ".env": required}, []string{"-config", "c.json"}, []string{".yaml, .yml or .toml"}},
+		{"missing file", map[string]string{".env": required}, []string{"-config", "nope.yaml"}, []string{"nope.yaml"}},
+		{"invalid value", map[string]string{".env": required + "PORT=http\n"}, nil, []string{`PORT (from .env): invalid integer "http"`}},
+		{"invalid values from flags", map[string]string{".env": required}, []string{"-score-interval", "soon", "-feature-feeds", "maybe"}, []string{
+			`SCORE_INTERVAL (from flags): invalid duration "soon"`, `FEATURE_FEEDS (from flags): invalid boolean "maybe"`,
+		}},
+		{"unknown flag", nil, []string{"-nope"}, []string{"flag provided but not defined"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inDir(t, tt.files)
+			_, _, err := Load(tt.args)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadDoesNotValidate(t *testing.T) {
+	inDir(t, map[string]string{".env": "PORT=0\n"})
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load = %v, want validation left to the caller", err)
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("Validate accepts port 0")
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	inDir(t, nil)
+	if _, _, err := Load([]string{"-help"}); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("err = %v, want flag.ErrHelp", err)
+	}
+}
+
+func TestDSN(t *testing.T) {
+	d := Database{Host: "db", Port: 5432, User: "blog", Password: `p'a ss\`, Name: "blog", SSLMode: "disable"}
+	if got, want := d.DSN(), `host=db port=5432 user=blog password='p\'a ss\\' dbname=blog sslmode=disable`; got != want {
+		t.Errorf("DSN = %s, want %s", got, want)
+	}
+	d.URL = "postgres://localhost/blog"
+	if d.DSN() != d.URL {
+		t.Errorf("DSN = %s, want the URL", d.DSN())
+	}
+}
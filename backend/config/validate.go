@@ -0,0 +1,179 @@
+package config
+
+import (
+	"blog-api/ratelimit"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Validate checks every setting and reports all the invalid ones.
+func (c *Config) Validate() error {
+	var p problems
+	if c.Port < 1 || c.Port > 65535 {
+		p.fail("PORT", "must be between 1 and 65535")
+	}
+	if c.SiteURL != "" && !absoluteURL(c.SiteURL) {
+		p.fail("SITE_URL", "must be an absolute http(s) URL")
+	}
+	if len(c.CORSAllowOrigins) == 0 {
+		p.fail("CORS_ALLOW_ORIGINS", "must list at least one origin, or *")
+	}
+	for _, origin := range c.CORSAllowOrigins {
+		if origin != "*" && !absoluteURL(origin) {
+			p.fail("CORS_ALLOW_ORIGINS", "%q is not an origin such as https://example.com", origin)
+		}
+	}
+
+	if c.ProxyHeader != "" && len(c.TrustedProxies) == 0 {
+		p.fail("TRUSTED_PROXIES", "is required with PROXY_HEADER, or anyone could spoof their address")
+	}
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				p.fail("TRUSTED_PROXIES", "%q is not an IP address or CIDR range", proxy)
+			}
+		}
+	}
+
+	srv := c.Server
+	p.notNegative("HTTP_READ_TIMEOUT", srv.ReadTimeout)
+	p.notNegative("HTTP_WRITE_TIMEOUT", srv.WriteTimeout)
+	p.notNegative("HTTP_IDLE_TIMEOUT", srv.IdleTimeout)
+	p.positive("HTTP_SHUTDOWN_TIMEOUT", srv.ShutdownTimeout)
+	p.notNegative("HTTP_SHUTDOWN_DELAY", srv.ShutdownDelay)
+	p.positive("HEALTH_CHECK_TIMEOUT", srv.HealthCheckTimeout)
+	if srv.BodyLimit < 1 {
+		p.fail("HTTP_BODY_LIMIT", "must be at least 1 byte")
+	}
+	if (srv.TLSCertFile == "") != (srv.TLSKeyFile == "") {
+		p.fail("TLS_CERT_FILE", "and TLS_KEY_FILE must be set together")
+	}
+	p.positive("TLS_RELOAD_INTERVAL", srv.TLSReloadInterval)
+
+	c.Database.check(&p)
+
+	if c.Auth.JWTSecret == "" && c.Auth.JWKSFile == "" {
+		p.fail("SUPABASE_JWT_SECRET", "or JWT_JWKS_FILE is required to verify tokens")
+	}
+
+	m := c.Media
+	p.oneOf("MEDIA_STORAGE", m.Storage, "local", "s3", "none")
+	if m.Storage == "local" && m.Dir == "" {
+		p.fail("MEDIA_DIR", "is required with local media storage")
+	}
+	if m.Storage == "s3" {
+		for _, required := range []struct{ env, value string }{
+			{"S3_ENDPOINT", m.S3Endpoint},
+			{"S3_BUCKET", m.S3Bucket},
+			{"S3_ACCESS_KEY_ID", m.S3AccessKeyID},
+			{"S3_SECRET_ACCESS_KEY", m.S3SecretAccessKey},
+		} {
+			if required.value == "" {
+				p.fail(required.env, "is required with S3 media storage")
+			}
+		}
+	}
+	if m.MaxUploadSize < 1 {
+		p.fail("MEDIA_MAX_UPLOAD_SIZE", "must be at least 1 byte")
+	}
+
+	if c.CommentMaxDepth < 1 {
+		p.fail("COMMENT_MAX_DEPTH", "must be at least 1")
+	}
+	p.oneOf("REALTIME_BROADCASTER", c.RealtimeBroadcaster, "local", "postgres")
+	p.oneOf("RATE_LIMIT_STORE", c.RateLimitStore, "memory", "postgres", "none")
+	if _, err := ratelimit.ParsePolicies(c.RateLimits); err != nil {
+		p.fail("RATE_LIMITS", "%v", err)
+	}
+	p.oneOf("IDEMPOTENCY_STORE", c.IdempotencyStore, "memory", "postgres", "none")
+	p.positive("IDEMPOTENCY_EXPIRY", c.IdempotencyExpiry)
+	p.positive("SCHEDULER_INTERVAL", c.SchedulerInterval)
+	p.positive("SCORE_INTERVAL", c.ScoreInterval)
+	p.positive("RENDER_INTERVAL", c.RenderInterval)
+
+	return p.err()
+}
+
+// Validate checks the database settings only, which is all the migrate
+// subcommand needs.
+func (d Database) Validate() error {
+	var p problems
+	d.check(&p)
+	return p.err()
+}
+
+func (d Database) check(p *problems) {
+	if d.URL != "" {
+		if u, err := url.Parse(d.URL); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+			p.fail("DATABASE_URL", "must be a postgres:// URL")
+		}
+	} else {
+		if d.Host == "" || d.User == "" || d.Name == "" {
+			p.fail("DATABASE_URL", "is required unless DB_HOST, DB_USER and DB_NAME are set")
+		}
+		if d.Port < 1 || d.Port > 65535 {
+			p.fail("DB_PORT", "must be between 1 and 65535")
+		}
+		p.oneOf("DB_SSLMODE", d.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	}
+	if d.MaxOpenConns < 0 {
+		p.fail("DB_MAX_OPEN_CONNS", "must not be negative")
+	}
+	if d.MaxIdleConns < 0 {
+		p.fail("DB_MAX_IDLE_CONNS", "must not be negative")
+	} else if d.MaxOpenConns > 0 && d.MaxIdleConns > d.MaxOpenConns {
+		p.fail("DB_MAX_IDLE_CONNS", "must not exceed DB_MAX_OPEN_CONNS (%d)", d.MaxOpenConns)
+	}
+	p.notNegative("DB_CONN_MAX_LIFETIME", d.ConnMaxLifetime)
+	p.notNegative("DB_CONN_MAX_IDLE_TIME", d.ConnMaxIdleTime)
+	p.positive("DB_CONNECT_TIMEOUT", d.ConnectTimeout)
+}
+
+// problems collects the invalid settings found by a validation.
+type problems []error
+
+func (p *problems) fail(env, format string, args ...any) {
+	*p = append(*p, fmt.Errorf("%s: "+format, append([]any{env}, args...)...))
+}
+
+func (p *problems) oneOf(env, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	p.fail(env, "must be one of %s, not %q", strings.Join(allowed, ", "), value)
+}
+
+func (p *problems) positive(env string, d time.Duration) {
+	if d <= 0 {
+		p.fail(env, "must be a positive duration")
+	}
+}
+
+func (p *problems) notNegative(env string, d time.Duration) {
+	if d < 0 {
+		p.fail(env, "must not be negative")
+	}
+}
+
+// err reports the problems one per line, or nil when there are none.
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	lines := make([]string, len(p))
+	for i, err := range p {
+		lines[i] = err.Error()
+	}
+	return errors.New("invalid configuration:\n  " + strings.Join(lines, "\n  "))
+}
+
+func absoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
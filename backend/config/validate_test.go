@@ -0,0 +1,98 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func valid() *Config {
+	cfg := Default()
+	cfg.Database.URL = "postgres://localhost/blog"
+	cfg.Auth.JWTSecret = "secret"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("defaults with a database and a secret: %v", err)
+	}
+	proxied := valid()
+	proxied.ProxyHeader, proxied.TrustedProxies = "X-Forwarded-For", []string{"10.0.0.0/8", "192.168.1.1", "::1"}
+	if err := proxied.Validate(); err != nil {
+		t.Fatalf("trusted proxies: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   string
+	}{
+		{"port", func(c *Config) { c.Port = 70000 }, "PORT: must be between 1 and 65535"},
+		{"site URL", func(c *Config) { c.SiteURL = "example.com" }, "SITE_URL: must be an absolute http(s) URL"},
+		{"no origin", func(c *Config) { c.CORSAllowOrigins = nil }, "CORS_ALLOW_ORIGINS: must list at least one origin"},
+		{"origin", func(c *Config) { c.CORSAllowOrigins = []string{"example.com"} }, `CORS_ALLOW_ORIGINS: "example.com" is not an origin`},
+		{"untrusted proxy header", func(c *Config) { c.ProxyHeader = "X-Forwarded-For" }, "TRUSTED_PROXIES: is required with PROXY_HEADER"},
+		{"trusted proxy", func(c *Config) { c.ProxyHeader, c.TrustedProxies = "X-Forwarded-For", []string{"10.0.0.0/8", "proxy"} }, `TRUSTED_PROXIES: "proxy" is not an IP address or CIDR range`},
+		{"database URL", func(c *Config) { c.Database.URL = "mysql://localhost/blog" }, "DATABASE_URL: must be a postgres:// URL"},
+		{"database fields", func(c *Config) {
+			c.Database = Database{Host: "db", User: "blog", Name: "blog", Port: 5432, SSLMode: "sometimes", ConnectTimeout: time.Second}
+		}, "DB_SSLMODE: must be one of"},
+		{"idle connections", func(c *Config) { c.Database.MaxIdleConns = 30 }, "DB_MAX_IDLE_CONNS: must not exceed DB_MAX_OPEN_CONNS (25)"},
+		{"negative lifetime", func(c *Config) { c.Database.ConnMaxLifetime = -time.Second }, "DB_CONN_MAX_LIFETIME: must not be negative"},
+		{"connect timeout", func(c *Config) { c.Database.ConnectTimeout = 0 }, "DB_CONNECT_TIMEOUT: must be a positive duration"},
+		{"token keys", func(c *Config) { c.Auth.JWTSecret = "" }, "SUPABASE_JWT_SECRET: or JWT_JWKS_FILE is required"},
+		{"media storage", func(c *Config) { c.Media.Storage = "ftp" }, "MEDIA_STORAGE: must be one of local, s3, none"},
+		{"media dir", func(c *Config) { c.Media.Dir = "" }, "MEDIA_DIR: is required with local media storage"},
+		{"s3", func(c *Config) { c.Media.Storage, c.Media.S3Endpoint = "s3", "https://s3.example.com" }, "S3_BUCKET: is required with S3 media storage"},
+		{"upload size", func(c *Config) { c.Media.MaxUploadSize = 0 }, "MEDIA_MAX_UPLOAD_SIZE: must be at least 1 byte"},
+		{"comment depth", func(c *Config) { c.CommentMaxDepth = 0 }, "COMMENT_MAX_DEPTH: must be at least 1"},
+		{"rate limits", func(c *Config) { c.RateLimits = "comments=often" }, "RATE_LIMITS: "},
+		{"scheduler", func(c *Config) { c.SchedulerInterval = 0 }, "SCHEDULER_INTERVAL: must be a positive duration"},
+		{"read timeout", func(c *Config) { c.Server.ReadTimeout = -time.Second }, "HTTP_READ_TIMEOUT: must not be negative"},
+		{"shutdown timeout", func(c *Config) { c.Server.ShutdownTimeout = 0 }, "HTTP_SHUTDOWN_TIMEOUT: must be a positive duration"},
+		{"shutdown delay", func(c *Config) { c.Server.ShutdownDelay = -time.Second }, "HTTP_SHUTDOWN_DELAY: must not be negative"},
+		{"health check timeout", func(c *Config) { c.Server.HealthCheckTimeout = 0 }, "HEALTH_CHECK_TIMEOUT: must be a positive duration"},
+		{"body limit", func(c *Config) { c.Server.BodyLimit = 0 }, "HTTP_BODY_LIMIT: must be at least 1 byte"},
+		{"TLS files", func(c *Config) { c.Server.TLSCertFile = "cert.pem" }, "TLS_CERT_FILE: and TLS_KEY_FILE must be set together"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.change(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// Every invalid setting is reported, one per line.
+	cfg := valid()
+	cfg.Port, cfg.CommentMaxDepth = 0, 0
+	err := cfg.Validate()
+	if err == nil || strings.Count(err.Error(), "\n  ") != 2 {
+		t.Fatalf("err = %v, want two indented lines", err)
+	}
+}
+
+func TestValidateDatabase(t *testing.T) {
+	// The migrate subcommand needs neither a token secret nor a port.
+	cfg := Default()
+	cfg.Port = 0
+	cfg.Database.URL = "postgres://localhost/blog"
+	if err := cfg.Database.Validate(); err != nil {
+		t.Fatalf("Database.Validate = %v", err)
+	}
+
+	cfg.Database = Database{Host: "db", Port: 5432, SSLMode: "require", MaxIdleConns: -1}
+	err := cfg.Database.Validate()
+	for _, want := range []string{"DATABASE_URL: is required", "DB_MAX_IDLE_CONNS: must not be negative", "DB_CONNECT_TIMEOUT"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "PORT:") || strings.Contains(err.Error(), "SUPABASE") {
+		t.Errorf("err = %v, want database settings only", err)
+	}
+}
@@ -1,35 +1,61 @@
-
 package db
 
 import (
+	"blog-api/config"
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
 
 	_ "github.com/lib/pq"
 )
 
 var DB *sql.DB
 
-func Init() {
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=require",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-	)
-
+// Connect opens the connection pool and checks that the database answers
+// within cfg.ConnectTimeout.
+func Connect(cfg config.Database) {
 	var err error
-	DB, err = sql.Open("postgres", connStr)
+	DB, err = sql.Open("postgres", cfg.DSN())
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	if err = DB.Ping(); err != nil {
+	DB.SetMaxOpenConns(cfg.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.MaxIdleConns)
+	DB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	DB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+	if err = DB.PingContext(ctx); err != nil {
 		log.Fatal(err)
 	}
 
 	log.Println("Connected to database")
-}
\ No newline at end of file
+}
+
+// Init connects to the database. With cfg.RequireSchema it refuses to start
+// while embedded migrations are still pending.
+func Init(cfg config.Database) {
+	Connect(cfg)
+
+	if !cfg.RequireSchema {
+		return
+	}
+
+	if err := CheckSchema(context.Background(), DB); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// CheckSchema reports an error when embedded migrations are still pending.
+func CheckSchema(ctx context.Context, db *sql.DB) error {
+	pending, err := PendingMigrations(ctx, db)
+	if err != nil {
+		return fmt.Errorf("could not check schema version: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is behind: %d pending migration(s), run \"migrate up\" first", len(pending))
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	// Versions start at 1 and leave no gap, so "migrate to N" and the down
+	// steps walk every one of them.
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s is number %d in order, want version %d", m.Version, m.Name, i+1, i+1)
+		}
+		if m.Name == "" || strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %d has an empty name or script", m.Version)
+		}
+	}
+
+	latest, err := LatestVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := migrations[len(migrations)-1].Version; latest != want {
+		t.Errorf("LatestVersion = %d, want %d", latest, want)
+	}
+	if !hasVersion(migrations, 1) || hasVersion(migrations, latest+1) {
+		t.Error("hasVersion does not match the embedded migrations")
+	}
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS likes;
+DROP TABLE IF EXISTS followers;
+DROP TABLE IF EXISTS favorites;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS articles;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,57 @@
+-- Tables previously created by frontend/supabase/migrations/db.sql, plus the
+-- likes and followers tables used by the API. IF NOT EXISTS keeps this
+-- migration safe on databases created from that script.
+
+CREATE TABLE IF NOT EXISTS users (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  email text UNIQUE NOT NULL,
+  firstname text,
+  lastname text,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS articles (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+  content text NOT NULL,
+  likes integer DEFAULT 0,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  article_id uuid REFERENCES articles(id) ON DELETE CASCADE NOT NULL,
+  user_id uuid REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+  content text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS favorites (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+  article_id uuid REFERENCES articles(id) ON DELETE CASCADE NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (user_id, article_id)
+);
+
+CREATE TABLE IF NOT EXISTS followers (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  follower_id uuid REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+  following_id uuid REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (follower_id, following_id),
+  CHECK (follower_id <> following_id)
+);
+
+CREATE TABLE IF NOT EXISTS likes (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  article_id uuid REFERENCES articles(id) ON DELETE CASCADE NOT NULL,
+  user_id uuid REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (user_id, article_id)
+);
+
+CREATE INDEX IF NOT EXISTS articles_created_at_idx ON articles (created_at DESC);
+CREATE INDEX IF NOT EXISTS comments_article_id_idx ON comments (article_id, created_at);
+CREATE INDEX IF NOT EXISTS followers_following_id_idx ON followers (following_id);
+CREATE INDEX IF NOT EXISTS likes_article_id_idx ON likes (article_id);
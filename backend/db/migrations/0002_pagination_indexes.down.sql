@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS followers_follower_created_at_id_idx;
+DROP INDEX IF EXISTS followers_following_created_at_id_idx;
+DROP INDEX IF EXISTS favorites_user_created_at_id_idx;
+DROP INDEX IF EXISTS comments_article_created_at_id_idx;
+DROP INDEX IF EXISTS articles_created_at_id_idx;
+
+CREATE INDEX IF NOT EXISTS articles_created_at_idx ON articles (created_at DESC);
+CREATE INDEX IF NOT EXISTS comments_article_id_idx ON comments (article_id, created_at);
+CREATE INDEX IF NOT EXISTS followers_following_id_idx ON followers (following_id);
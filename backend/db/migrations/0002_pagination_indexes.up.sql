@@ -0,0 +1,11 @@
+-- Keyset pagination orders every list by (created_at, id).
+
+DROP INDEX IF EXISTS articles_created_at_idx;
+DROP INDEX IF EXISTS comments_article_id_idx;
+DROP INDEX IF EXISTS followers_following_id_idx;
+
+CREATE INDEX articles_created_at_id_idx ON articles (created_at DESC, id DESC);
+CREATE INDEX comments_article_created_at_id_idx ON comments (article_id, created_at, id);
+CREATE INDEX favorites_user_created_at_id_idx ON favorites (user_id, created_at DESC, id DESC);
+CREATE INDEX followers_following_created_at_id_idx ON followers (following_id, created_at DESC, id DESC);
+CREATE INDEX followers_follower_created_at_id_idx ON followers (follower_id, created_at DESC, id DESC);
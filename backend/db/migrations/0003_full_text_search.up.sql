@@ -0,0 +1,14 @@
+-- Full-text search over article and comment content. The columns use the
+-- "simple" configuration (no stemming) so French and English posts behave
+-- the same and prefix queries match what authors typed.
+
+ALTER TABLE articles
+  ADD COLUMN search_vector tsvector
+  GENERATED ALWAYS AS (to_tsvector('simple', coalesce(content, ''))) STORED;
+
+ALTER TABLE comments
+  ADD COLUMN search_vector tsvector
+  GENERATED ALWAYS AS (to_tsvector('simple', coalesce(content, ''))) STORED;
+
+CREATE INDEX articles_search_vector_idx ON articles USING GIN (search_vector);
+CREATE INDEX comments_search_vector_idx ON comments USING GIN (search_vector);
@@ -0,0 +1,13 @@
+DROP INDEX articles_search_vector_idx;
+ALTER TABLE articles DROP COLUMN search_vector;
+ALTER TABLE articles
+  ADD COLUMN search_vector tsvector
+  GENERATED ALWAYS AS (to_tsvector('simple', coalesce(content, ''))) STORED;
+CREATE INDEX articles_search_vector_idx ON articles USING GIN (search_vector);
+
+DROP TABLE IF EXISTS article_slugs;
+
+ALTER TABLE articles
+  DROP COLUMN IF EXISTS slug,
+  DROP COLUMN IF EXISTS excerpt,
+  DROP COLUMN IF EXISTS title;
@@ -0,0 +1,31 @@
+ALTER TABLE articles
+  ADD COLUMN title text NOT NULL DEFAULT '',
+  ADD COLUMN excerpt text NOT NULL DEFAULT '',
+  ADD COLUMN slug text;
+
+-- Articles written before titles existed keep their UUID as slug.
+UPDATE articles SET slug = id::text WHERE slug IS NULL;
+
+ALTER TABLE articles
+  ALTER COLUMN slug SET NOT NULL,
+  ADD CONSTRAINT articles_slug_key UNIQUE (slug);
+
+-- Previous slugs of an article, so old permalinks can redirect.
+CREATE TABLE article_slugs (
+  slug text PRIMARY KEY,
+  article_id uuid REFERENCES articles(id) ON DELETE CASCADE NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX article_slugs_article_id_idx ON article_slugs (article_id);
+
+-- Titles weigh more than content in search ranking.
+DROP INDEX articles_search_vector_idx;
+ALTER TABLE articles DROP COLUMN search_vector;
+ALTER TABLE articles
+  ADD COLUMN search_vector tsvector
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(content, '')), 'B')
+  ) STORED;
+CREATE INDEX articles_search_vector_idx ON articles USING GIN (search_vector);
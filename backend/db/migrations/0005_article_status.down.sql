@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS articles_scheduled_publish_at_idx;
+DROP INDEX IF EXISTS articles_status_created_at_id_idx;
+
+ALTER TABLE articles
+  DROP CONSTRAINT IF EXISTS articles_scheduled_publish_at_check,
+  DROP COLUMN IF EXISTS publish_at,
+  DROP COLUMN IF EXISTS status;
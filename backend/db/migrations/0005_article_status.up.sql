@@ -0,0 +1,15 @@
+-- Existing articles were published when they were created.
+ALTER TABLE articles
+  ADD COLUMN status text NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'scheduled', 'published', 'archived')),
+  ADD COLUMN publish_at timestamptz;
+
+UPDATE articles SET publish_at = created_at;
+
+ALTER TABLE articles
+  ADD CONSTRAINT articles_scheduled_publish_at_check
+    CHECK (status <> 'scheduled' OR publish_at IS NOT NULL);
+
+-- Listings filter on status; the scheduler looks for due scheduled rows.
+CREATE INDEX articles_status_created_at_id_idx ON articles (status, created_at DESC, id DESC);
+CREATE INDEX articles_scheduled_publish_at_idx ON articles (publish_at) WHERE status = 'scheduled';
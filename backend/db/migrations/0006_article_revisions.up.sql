@@ -0,0 +1,17 @@
+-- Immutable history of article titles and content. Revision 1 is the
+-- article as created; every edit or restore appends the next number.
+CREATE TABLE article_revisions (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  article_id uuid REFERENCES articles(id) ON DELETE CASCADE NOT NULL,
+  number integer NOT NULL,
+  user_id uuid REFERENCES users(id) ON DELETE SET NULL,
+  title text NOT NULL,
+  content text NOT NULL,
+  restored_from integer,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (article_id, number)
+);
+
+-- Existing articles start their history with their current text.
+INSERT INTO article_revisions (article_id, number, user_id, title, content, created_at)
+SELECT id, 1, user_id, title, content, created_at FROM articles;
@@ -0,0 +1,15 @@
+-- Tag names are normalized by the API (lowercase ASCII words joined with
+-- dashes) before they reach the database.
+CREATE TABLE tags (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  name text UNIQUE NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE article_tags (
+  article_id uuid REFERENCES articles(id) ON DELETE CASCADE NOT NULL,
+  tag_id uuid REFERENCES tags(id) ON DELETE CASCADE NOT NULL,
+  PRIMARY KEY (article_id, tag_id)
+);
+
+CREATE INDEX article_tags_tag_id_idx ON article_tags (tag_id, article_id);
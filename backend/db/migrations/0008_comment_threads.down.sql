@@ -0,0 +1,9 @@
+-- Soft-deleted comments have no content left to restore.
+DELETE FROM comments WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS comments_article_roots_idx;
+DROP INDEX IF EXISTS comments_parent_id_idx;
+ALTER TABLE comments
+  DROP COLUMN deleted_at,
+  DROP COLUMN depth,
+  DROP COLUMN parent_id;
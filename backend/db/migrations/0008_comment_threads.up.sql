@@ -0,0 +1,12 @@
+-- Replies point at their parent comment. depth is 0 for top-level comments
+-- and is copied from the parent plus one, so the API can cap nesting without
+-- walking the tree.
+ALTER TABLE comments
+  ADD COLUMN parent_id uuid REFERENCES comments(id) ON DELETE CASCADE,
+  ADD COLUMN depth integer NOT NULL DEFAULT 0,
+  -- A deleted comment that still has replies keeps its row, with its
+  -- content cleared, so the thread stays intact.
+  ADD COLUMN deleted_at timestamptz;
+
+CREATE INDEX comments_parent_id_idx ON comments (parent_id, created_at);
+CREATE INDEX comments_article_roots_idx ON comments (article_id, created_at, id) WHERE parent_id IS NULL;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS articles_user_created_at_id_idx;
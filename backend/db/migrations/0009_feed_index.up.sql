@@ -0,0 +1,2 @@
+-- The feed reads the newest articles of a set of authors.
+CREATE INDEX articles_user_created_at_id_idx ON articles (user_id, created_at DESC, id DESC);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS articles_top_idx;
+DROP INDEX IF EXISTS articles_hot_idx;
+ALTER TABLE articles
+  DROP COLUMN hot_score,
+  DROP COLUMN engagement;
@@ -0,0 +1,12 @@
+-- Ranking scores are materialized by a background job (see
+-- ArticleStore.RefreshScores) so hot and top listings are plain index scans.
+-- engagement weighs likes, comments and favorites; hot_score decays it with
+-- the age of the article.
+ALTER TABLE articles
+  ADD COLUMN engagement integer NOT NULL DEFAULT 0,
+  ADD COLUMN hot_score double precision NOT NULL DEFAULT 0;
+
+CREATE INDEX articles_hot_idx ON articles (hot_score DESC, created_at DESC, id DESC)
+  WHERE status = 'published';
+CREATE INDEX articles_top_idx ON articles (engagement DESC, created_at DESC, id DESC)
+  WHERE status = 'published';
@@ -0,0 +1,32 @@
+-- A notification groups every event of one kind on one target while it is
+-- unread: group_key identifies the target, and the partial unique index lets
+-- new events join the existing unread row instead of adding another.
+CREATE TABLE notifications (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+  kind text NOT NULL CHECK (kind IN ('follow', 'like', 'comment', 'reply')),
+  article_id uuid REFERENCES articles(id) ON DELETE CASCADE,
+  comment_id uuid REFERENCES comments(id) ON DELETE CASCADE,
+  group_key text NOT NULL,
+  read_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX notifications_unread_group_idx ON notifications (user_id, group_key) WHERE read_at IS NULL;
+CREATE INDEX notifications_user_updated_at_id_idx ON notifications (user_id, updated_at DESC, id DESC);
+
+-- The users behind a notification, one row each however often they act.
+CREATE TABLE notification_actors (
+  notification_id uuid REFERENCES notifications(id) ON DELETE CASCADE NOT NULL,
+  actor_id uuid REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (notification_id, actor_id)
+);
+
+-- Notification kinds a user does not want to receive.
+CREATE TABLE notification_mutes (
+  user_id uuid REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+  kind text NOT NULL,
+  PRIMARY KEY (user_id, kind)
+);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS comments_render_version_idx;
+DROP INDEX IF EXISTS articles_render_version_idx;
+ALTER TABLE comments
+  DROP COLUMN render_version,
+  DROP COLUMN content_html;
+ALTER TABLE articles
+  DROP COLUMN render_version,
+  DROP COLUMN content_html;
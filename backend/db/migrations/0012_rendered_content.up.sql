@@ -0,0 +1,12 @@
+-- content keeps the Markdown source; content_html is its sanitized rendering
+-- and render_version the markdown.Version that produced it. Existing rows
+-- start at version 0 and are rendered by the render-content job.
+ALTER TABLE articles
+  ADD COLUMN content_html text NOT NULL DEFAULT '',
+  ADD COLUMN render_version integer NOT NULL DEFAULT 0;
+ALTER TABLE comments
+  ADD COLUMN content_html text NOT NULL DEFAULT '',
+  ADD COLUMN render_version integer NOT NULL DEFAULT 0;
+
+CREATE INDEX articles_render_version_idx ON articles (render_version);
+CREATE INDEX comments_render_version_idx ON comments (render_version);
@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN avatar_media_id;
+ALTER TABLE articles DROP COLUMN cover_media_id;
+DROP TABLE IF EXISTS media_variants;
+DROP TABLE IF EXISTS media;
@@ -0,0 +1,28 @@
+-- Uploaded images. storage_key locates the file in the media storage (local
+-- directory or S3 bucket); each resized copy is a row of media_variants.
+CREATE TABLE media (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+  content_type text NOT NULL,
+  size bigint NOT NULL,
+  width integer NOT NULL,
+  height integer NOT NULL,
+  storage_key text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX media_user_id_idx ON media (user_id);
+
+CREATE TABLE media_variants (
+  media_id uuid REFERENCES media(id) ON DELETE CASCADE NOT NULL,
+  name text NOT NULL,
+  content_type text NOT NULL,
+  size bigint NOT NULL,
+  width integer NOT NULL,
+  height integer NOT NULL,
+  storage_key text NOT NULL,
+  PRIMARY KEY (media_id, name)
+);
+
+ALTER TABLE articles ADD COLUMN cover_media_id uuid REFERENCES media(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN avatar_media_id uuid REFERENCES media(id) ON DELETE SET NULL;
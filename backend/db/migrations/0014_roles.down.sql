@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS users_role_created_at_id_idx;
+ALTER TABLE articles DROP COLUMN hidden;
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,12 @@
+-- Existing users keep writing articles; admins are promoted by hand (see
+-- the readme).
+ALTER TABLE users
+  ADD COLUMN role text NOT NULL DEFAULT 'author'
+    CHECK (role IN ('reader', 'author', 'moderator', 'admin'));
+
+-- Hidden articles are taken down by a moderator: only their author and
+-- moderators still see them.
+ALTER TABLE articles ADD COLUMN hidden boolean NOT NULL DEFAULT false;
+
+-- The admin user listing can filter on role.
+CREATE INDEX users_role_created_at_id_idx ON users (role, created_at DESC, id DESC);
@@ -0,0 +1,10 @@
+-- Token buckets of the PostgreSQL rate limit store, one per route policy
+-- and client. full_at is when a bucket is full again and can be pruned.
+CREATE UNLOGGED TABLE rate_limits (
+  key text PRIMARY KEY,
+  tokens double precision NOT NULL,
+  updated_at timestamptz NOT NULL,
+  full_at timestamptz NOT NULL
+);
+
+CREATE INDEX rate_limits_full_at_idx ON rate_limits (full_at);
@@ -0,0 +1,14 @@
+-- Responses of create requests sent with an Idempotency-Key header. key is
+-- the user ID and the header value; status is NULL while the first request
+-- is in progress.
+CREATE TABLE idempotency_keys (
+  key text PRIMARY KEY,
+  fingerprint text NOT NULL,
+  status integer,
+  content_type text,
+  body bytea,
+  created_at timestamptz NOT NULL,
+  expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS articles_user_published_at_id_idx;
+DROP INDEX IF EXISTS articles_status_published_at_id_idx;
+DROP INDEX IF EXISTS articles_published_at_id_idx;
+
+CREATE INDEX articles_created_at_id_idx ON articles (created_at DESC, id DESC);
+CREATE INDEX articles_status_created_at_id_idx ON articles (status, created_at DESC, id DESC);
+CREATE INDEX articles_user_created_at_id_idx ON articles (user_id, created_at DESC, id DESC);
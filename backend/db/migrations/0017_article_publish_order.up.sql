@@ -0,0 +1,11 @@
+-- Listings and feeds order articles by publication time, falling back to the
+-- creation time of articles that have none, so that a scheduled article
+-- comes out on top when it is published.
+
+DROP INDEX IF EXISTS articles_created_at_id_idx;
+DROP INDEX IF EXISTS articles_status_created_at_id_idx;
+DROP INDEX IF EXISTS articles_user_created_at_id_idx;
+
+CREATE INDEX articles_published_at_id_idx ON articles ((COALESCE(publish_at, created_at)) DESC, id DESC);
+CREATE INDEX articles_status_published_at_id_idx ON articles (status, (COALESCE(publish_at, created_at)) DESC, id DESC);
+CREATE INDEX articles_user_published_at_id_idx ON articles (user_id, (COALESCE(publish_at, created_at)) DESC, id DESC);
@@ -0,0 +1,148 @@
+// Package diff compares two texts line by line or word by word using the
+// Myers algorithm.
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Chunk operations.
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// Chunk is a run of text that is unchanged, inserted or deleted.
+// Concatenating the equal and delete chunks gives the old text; the equal
+// and insert chunks give the new one.
+type Chunk struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines diffs a and b line by line.
+func Lines(a, b string) []Chunk {
+	return diff(splitLines(a), splitLines(b))
+}
+
+// Words diffs a and b word by word, treating runs of whitespace as tokens.
+func Words(a, b string) []Chunk {
+	return diff(splitWords(a), splitWords(b))
+}
+
+// Stats counts the inserted and deleted tokens' characters.
+func Stats(chunks []Chunk) (insertions, deletions int) {
+	for _, c := range chunks {
+		switch c.Op {
+		case Insert:
+			insertions += len([]rune(c.Text))
+		case Delete:
+			deletions += len([]rune(c.Text))
+		}
+	}
+	return insertions, deletions
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func splitWords(s string) []string {
+	var tokens []string
+	start, inSpace := 0, false
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if i > start && space != inSpace {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+// diff finds a shortest edit script from a to b (Myers, "An O(ND) Difference
+// Algorithm and Its Variations") and returns it as merged chunks.
+func diff(a, b []string) []Chunk {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+
+	// trace[d] holds v as it was before step d, for backtracking.
+	var trace [][]int
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk back from (n, m), collecting operations in reverse.
+	var ops []Chunk
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, Chunk{Equal, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, Chunk{Insert, b[y-1]})
+			} else {
+				ops = append(ops, Chunk{Delete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	chunks := []Chunk{}
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+		if last := len(chunks) - 1; last >= 0 && chunks[last].Op == op.Op {
+			chunks[last].Text += op.Text
+		} else {
+			chunks = append(chunks, op)
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,93 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want []Chunk
+	}{
+		{"", "", []Chunk{}},
+		{"", "a\n", []Chunk{{Insert, "a\n"}}},
+		{"a\n", "", []Chunk{{Delete, "a\n"}}},
+		{"a\nb\nc\n", "a\nb\nc\n", []Chunk{{Equal, "a\nb\nc\n"}}},
+		{"a\nb\nc\n", "a\nx\nc\n", []Chunk{{Equal, "a\n"}, {Delete, "b\n"}, {Insert, "x\n"}, {Equal, "c\n"}}},
+		{"a\nb", "a\nb\n", []Chunk{{Equal, "a\n"}, {Delete, "b"}, {Insert, "b\n"}}},
+	}
+	for _, tt := range tests {
+		if got := Lines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lines(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	got := Words("the quick brown fox", "the slow brown  fox")
+	want := []Chunk{{Equal, "the "}, {Delete, "quick"}, {Insert, "slow"}, {Equal, " brown"}, {Delete, " "}, {Insert, "  "}, {Equal, "fox"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Words = %v, want %v", got, want)
+	}
+	if ins, del := Stats(got); ins != 6 || del != 6 {
+		t.Fatalf("Stats = %d, %d; want 6, 6", ins, del)
+	}
+}
+
+// TestRoundTrip checks on random texts that the chunks rebuild both sides
+// and keep a longest common subsequence unchanged.
+func TestRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	words := []string{"a", "b", "c", "é"}
+	text := func() string {
+		var sb strings.Builder
+		for range rng.Intn(12) {
+			sb.WriteString(words[rng.Intn(len(words))])
+			sb.WriteString(" ")
+		}
+		return sb.String()
+	}
+	for range 500 {
+		a, b := text(), text()
+		chunks := Words(a, b)
+		var old, new strings.Builder
+		kept := 0
+		for _, c := range chunks {
+			if c.Op != Insert {
+				old.WriteString(c.Text)
+			}
+			if c.Op != Delete {
+				new.WriteString(c.Text)
+			}
+			if c.Op == Equal {
+				kept += len(splitWords(c.Text))
+			}
+		}
+		if old.String() != a || new.String() != b {
+			t.Fatalf("Words(%q, %q) = %v rebuilds %q and %q", a, b, chunks, old.String(), new.String())
+		}
+		if want := lcs(splitWords(a), splitWords(b)); kept != want {
+			t.Fatalf("Words(%q, %q) kept %d tokens, want %d", a, b, kept, want)
+		}
+	}
+}
+
+func lcs(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"blog-api/apperr"
+	"blog-api/policy"
+	"blog-api/store"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The /api/admin routes require policy.ManageRoles.
+
+// GET /api/admin/users?role=&limit=&before=&after=
+func (h *Handler) GetAdminUsers(c *fiber.Ctx) error {
+	page, err := parsePage(c)
+	if err != nil {
+		return err
+	}
+
+	role := c.Query("role")
+	if role != "" && !policy.ValidRole(role) {
+		return apperr.BadRequest("invalid_role", "role must be reader, author, moderator or admin")
+	}
+
+	users, err := h.users.List(c.UserContext(), role, page)
+	if err != nil {
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(newPageResponse(users))
+}
+
+// PUT /api/admin/users/:id/role
+func (h *Handler) SetUserRole(c *fiber.Ctx) error {
+	actor, err := h.actor(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+	var req roleRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.users.Get(c.UserContext(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return errUserNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
+	}
+	if !policy.CanSetRole(actor, user) {
+		return apperr.Forbidden("own_role", "You cannot change your own role")
+	}
+
+	err = h.users.SetRole(c.UserContext(), id, req.Role)
+	if errors.Is(err, store.ErrNotFound) {
+		return errUserNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
+	}
+
+	user.Role = req.Role
+	return c.JSON(user)
+}
@@ -0,0 +1,74 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAdminUsers(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	admin := api.addUser(t, models.RoleAdmin)
+	reader := api.addUser(t, models.RoleReader)
+	author := api.addUser(t, models.RoleAuthor)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{author, reader, admin}},
+		{"?role=reader", []string{reader}},
+		{"?role=moderator", nil},
+		{"?limit=1", []string{author}},
+	}
+	for _, tt := range tests {
+		if got, _, _ := pageIDs(t, api.do(t, http.MethodGet, "/api/admin/users"+tt.query, admin, nil)); !slices.Equal(got, tt.want) {
+			t.Errorf("%q = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// A promoted user gets the permissions of their new role at once.
+	expect(t, api.do(t, http.MethodPost, "/api/articles", reader, map[string]any{"content": "x"}), http.StatusForbidden, "permission_denied")
+	r := api.do(t, http.MethodPut, "/api/admin/users/"+reader+"/role", admin, map[string]any{"role": models.RoleAuthor})
+	if expect(t, r, http.StatusOK, ""); r.str("role") != models.RoleAuthor {
+		t.Fatalf("role = %q, want author", r.str("role"))
+	}
+	expect(t, api.do(t, http.MethodPost, "/api/articles", reader, map[string]any{"content": "x"}), http.StatusCreated, "")
+	r = api.do(t, http.MethodGet, "/api/users/"+reader, "", nil)
+	if r.str("role") != models.RoleAuthor {
+		t.Fatalf("stored role = %q, want author", r.str("role"))
+	}
+}
+
+func TestAdminErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	admin := api.addUser(t, models.RoleAdmin)
+	moderator := api.addUser(t, models.RoleModerator)
+	role := map[string]any{"role": models.RoleModerator}
+
+	tests := []struct {
+		name         string
+		method, path string
+		userID       string
+		body         any
+		status       int
+		code         string
+	}{
+		{"list anonymously", http.MethodGet, "/api/admin/users", "", nil, http.StatusUnauthorized, "unauthenticated"},
+		{"list as a moderator", http.MethodGet, "/api/admin/users", moderator, nil, http.StatusForbidden, "permission_denied"},
+		{"list an unknown role", http.MethodGet, "/api/admin/users?role=owner", admin, nil, http.StatusBadRequest, "invalid_role"},
+		{"set a role as a moderator", http.MethodPut, "/api/admin/users/" + moderator + "/role", moderator, role, http.StatusForbidden, "permission_denied"},
+		{"set an unknown role", http.MethodPut, "/api/admin/users/" + moderator + "/role", admin, map[string]any{"role": "owner"}, http.StatusUnprocessableEntity, "validation_failed"},
+		{"set the role of an unknown user", http.MethodPut, "/api/admin/users/" + uuid.NewString() + "/role", admin, role, http.StatusNotFound, "user_not_found"},
+		{"set one's own role", http.MethodPut, "/api/admin/users/" + admin + "/role", admin, map[string]any{"role": models.RoleReader}, http.StatusForbidden, "own_role"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect(t, api.do(t, tt.method, tt.path, tt.userID, tt.body), tt.status, tt.code)
+		})
+	}
+}
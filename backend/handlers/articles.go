@@ -1,155 +1,356 @@
 package handlers
 
 import (
-	"blog-api/db"
+	"blog-api/apperr"
+	"blog-api/markdown"
+	"blog-api/middleware"
 	"blog-api/models"
-	"database/sql"
+	"blog-api/policy"
+	"blog-api/slug"
+	"blog-api/store"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
-// GET /api/articles
-func GetArticles(c *fiber.Ctx) error {
-	rows, err := db.DB.Query(`
-		SELECT a.id, a.user_id, a.content, a.likes, a.created_at,
-		       u.email, u.firstname, u.lastname, u.created_at
-		FROM articles a
-		LEFT JOIN users u ON a.user_id = u.id
-		ORDER BY a.created_at DESC
-		LIMIT 5
-	`)
+// excerptLength is the maximum number of characters of a generated excerpt.
+const excerptLength = 200
+
+// GET /api/articles?author=&status=&tag=&tag_match=&sort=&window=&limit=&before=&after=
+//
+// Only published articles are listed, unless an author asks for their own
+// articles with status=draft|scheduled|archived|all. Articles hidden by
+// moderators are only listed to their author. tag takes a
+// comma-separated list matched with any of them, or all with tag_match=all.
+// sort=hot|top ranks by the periodically refreshed scores instead of date;
+// window=day|week|month|all limits top to recently published articles.
+func (h *Handler) GetArticles(c *fiber.Ctx) error {
+	page, err := parsePage(c)
+	if err != nil {
+		return err
+	}
+
+	authorID, err := idQuery(c, "author")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Database error: " + err.Error()})
-	}
-	defer rows.Close()
-
-	var articles []models.Article
-	for rows.Next() {
-		var article models.Article
-		var author models.Profile
-		var firstName, lastName sql.NullString
-
-		err := rows.Scan(
-			&article.ID,
-			&article.UserID, 
-			&article.Content,
-			&article.Likes,
-			&article.CreatedAt,
-			&author.Email,
-			&firstName,
-			&lastName,
-			&author.CreatedAt,
-		)
+		return err
+	}
+
+	filter := store.ArticleFilter{
+		AuthorID: authorID,
+		Statuses: []string{models.ArticlePublished},
+	}
+	if err := tagFilter(c, &filter); err != nil {
+		return err
+	}
+	if err := sortFilter(c, &filter); err != nil {
+		return err
+	}
+	if status := c.Query("status"); status != "" && status != models.ArticlePublished {
+		userID, err := requireUser(c)
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Scan error: " + err.Error()})
+			return err
+		}
+		if filter.AuthorID == "" {
+			filter.AuthorID = userID
+		} else if filter.AuthorID != userID {
+			return apperr.Forbidden("forbidden", "You can only list your own unpublished articles")
+		}
+
+		if status == "all" {
+			filter.Statuses = nil
+		} else if validStatus(status) {
+			filter.Statuses = []string{status}
+		} else {
+			return apperr.BadRequest("invalid_status", "Invalid status")
 		}
-		author.FirstName = firstName.String
-		author.LastName = lastName.String
-		article.Author = &author
-		articles = append(articles, article)
+	}
+	filter.IncludeHidden = filter.AuthorID != "" && filter.AuthorID == middleware.UserID(c)
+
+	articles, err := h.articles.List(c.UserContext(), filter, page)
+	if err != nil {
+		return apperr.Internal(err)
 	}
 
-	return c.JSON(articles)
+	return c.JSON(newPageResponse(articles))
 }
 
 // GET /api/articles/:id
-func GetArticle(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var article models.Article
-	var author models.Profile
-	var firstName, lastName sql.NullString
-
-	err := db.DB.QueryRow(`
-		SELECT a.id, a.user_id, a.content, a.likes, a.created_at,
-		       u.email, u.firstname, u.lastname, u.created_at
-		FROM articles a
-		LEFT JOIN users u ON a.user_id = u.id
-		WHERE a.id = $1
-	`, id).Scan(
-		&article.ID,
-		&article.UserID,
-		&article.Content,
-		&article.Likes,
-		&article.CreatedAt,
-		&author.Email,
-		&firstName,
-		&lastName,
-		&author.CreatedAt,
-	)
-
-	if err == sql.ErrNoRows {
-		return c.Status(404).JSON(fiber.Map{"error": "Article not found"})
+func (h *Handler) GetArticle(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	article, err := h.visibleArticle(c, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(article)
+}
+
+// GET /api/articles/by-slug/:slug
+//
+// Old slugs answer with a 301 to the article's current slug.
+func (h *Handler) GetArticleBySlug(c *fiber.Ctx) error {
+	requested := c.Params("slug")
+	article, err := h.articles.GetBySlug(c.UserContext(), requested)
+	if err == nil {
+		err = h.checkView(c, article)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return errArticleNotFound
 	} else if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Database error: " + err.Error()})
+		return apperr.Internal(err)
 	}
 
-	author.FirstName = firstName.String
-	author.LastName = lastName.String
-	article.Author = &author
+	if article.Slug != requested {
+		return c.Redirect("/api/articles/by-slug/"+url.PathEscape(article.Slug), fiber.StatusMovedPermanently)
+	}
 	return c.JSON(article)
 }
 
 // POST /api/articles
-func CreateArticle(c *fiber.Ctx) error {
-	article := new(models.Article)
-	if err := c.BodyParser(article); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+func (h *Handler) CreateArticle(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req articleRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	if err := h.ownMedia(c, "cover_media_id", req.CoverMediaID, userID); err != nil {
+		return err
 	}
 
+	article := req.article()
 	article.ID = uuid.New().String()
-	_, err := db.DB.Exec(`
-		INSERT INTO articles (id, user_id, content, likes)
-		VALUES ($1, $2, $3, $4)
-	`, article.ID, article.UserID, article.Content, 0)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not create article: " + err.Error()})
+	article.UserID = userID
+	if article.Status == "" {
+		article.Status = models.ArticlePublished
+	}
+	if article.Tags == nil {
+		article.Tags = []string{}
+	}
+	if err := prepareArticle(article, time.Now()); err != nil {
+		return err
+	}
+	err = h.articles.Create(c.UserContext(), article)
+	if errors.Is(err, store.ErrConflict) {
+		return errSlugConflict
+	} else if err != nil {
+		return apperr.Internal(err)
 	}
 
 	return c.Status(201).JSON(article)
 }
 
 // PUT /api/articles/:id
-func UpdateArticle(c *fiber.Ctx) error {
-	id := c.Params("id")
-	article := new(models.Article)
-	if err := c.BodyParser(article); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
-	}
-
-	result, err := db.DB.Exec(`
-		UPDATE articles
-		SET content = $1
-		WHERE id = $2 AND user_id = $3
-	`, article.Content, id, article.UserID)
+func (h *Handler) UpdateArticle(c *fiber.Ctx) error {
+	existing, err := h.ownArticle(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not update article: " + err.Error()})
+		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "Article not found or unauthorized"})
+	var req articleRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	if err := h.ownMedia(c, "cover_media_id", req.CoverMediaID, existing.UserID); err != nil {
+		return err
+	}
+
+	article := req.article()
+	article.ID = existing.ID
+	article.UserID = existing.UserID
+	if article.Status == "" {
+		article.Status = existing.Status
+	}
+	if article.Status == existing.Status && article.PublishAt == nil {
+		article.PublishAt = existing.PublishAt
+	}
+	if err := prepareArticle(article, time.Now()); err != nil {
+		return err
+	}
+	err = h.articles.Update(c.UserContext(), article)
+	if errors.Is(err, store.ErrNotFound) {
+		return errArticleNotFound
+	} else if errors.Is(err, store.ErrConflict) {
+		return errSlugConflict
+	} else if err != nil {
+		return apperr.Internal(err)
 	}
 
-	return c.JSON(fiber.Map{"message": "Article updated successfully"})
+	return c.JSON(fiber.Map{"message": "Article updated successfully", "slug": article.Slug, "status": article.Status, "tags": article.Tags})
 }
 
 // DELETE /api/articles/:id
-func DeleteArticle(c *fiber.Ctx) error {
-	id := c.Params("id")
-	userID := c.Query("user_id") 
-
-	result, err := db.DB.Exec(`
-		DELETE FROM articles
-		WHERE id = $1 AND user_id = $2
-	`, id, userID)
+//
+// Admins may delete any article.
+func (h *Handler) DeleteArticle(c *fiber.Ctx) error {
+	if _, err := requireUser(c); err != nil {
+		return err
+	}
+	actor, err := h.actor(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := idParam(c, "id")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not delete article: " + err.Error()})
+		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "Article not found or unauthorized"})
+	article, err := h.articles.Get(c.UserContext(), id)
+	if err == nil && !policy.CanDeleteArticle(actor, article) {
+		err = store.ErrNotFound
+	}
+	if err == nil {
+		err = h.articles.Delete(c.UserContext(), id, article.UserID)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return errArticleNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
 	}
 
 	return c.JSON(fiber.Map{"message": "Article deleted successfully"})
 }
+
+// prepareArticle checks the status and publish date, trims the client
+// fields, normalizes the tags, fills in a missing excerpt and sets the base
+// slug the store will make unique.
+func prepareArticle(article *models.Article, now time.Time) error {
+	switch article.Status {
+	case models.ArticleScheduled:
+		if article.PublishAt == nil {
+			return apperr.BadRequest("publish_at_required", "publish_at is required for scheduled articles")
+		}
+		if !article.PublishAt.After(now) {
+			article.Status = models.ArticlePublished
+		}
+	case models.ArticlePublished:
+		if article.PublishAt == nil || article.PublishAt.After(now) {
+			article.PublishAt = &now
+		}
+	case models.ArticleDraft, models.ArticleArchived:
+	default:
+		return apperr.BadRequest("invalid_status", "status must be draft, scheduled, published or archived")
+	}
+
+	tags, err := normalizeTags(article.Tags)
+	if err != nil {
+		return err
+	}
+	article.Tags = tags
+
+	article.Title = strings.TrimSpace(article.Title)
+	article.Excerpt = strings.TrimSpace(article.Excerpt)
+	if article.Excerpt == "" {
+		article.Excerpt = makeExcerpt(article.Content)
+	}
+
+	article.Slug = slug.Make(article.Title)
+	if article.Slug == "" {
+		article.Slug = slug.Make(makeExcerpt(article.Content))
+	}
+	if article.Slug == "" {
+		article.Slug = "article"
+	}
+	return nil
+}
+
+func validStatus(status string) bool {
+	switch status {
+	case models.ArticleDraft, models.ArticleScheduled, models.ArticlePublished, models.ArticleArchived:
+		return true
+	}
+	return false
+}
+
+// checkView returns store.ErrNotFound when policy.CanViewArticle keeps the
+// caller from reading the article. The caller's role is only loaded for
+// articles that are not public.
+func (h *Handler) checkView(c *fiber.Ctx, article *models.Article) error {
+	if article.Status == models.ArticlePublished && !article.Hidden {
+		return nil
+	}
+	actor, err := h.actor(c)
+	if err != nil {
+		return err
+	}
+	if !policy.CanViewArticle(actor, article) {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// visibleArticle returns the article id, or errArticleNotFound when it does
+// not exist or checkView keeps the caller from reading it. Routes about an
+// article's comments or likes use it to answer as GET /api/articles/:id.
+func (h *Handler) visibleArticle(c *fiber.Ctx, id string) (*models.Article, error) {
+	article, err := h.articles.Get(c.UserContext(), id)
+	if err == nil {
+		err = h.checkView(c, article)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, errArticleNotFound
+	} else if err != nil {
+		return nil, apperr.Internal(err)
+	}
+	return article, nil
+}
+
+// POST /api/articles/:id/hide
+func (h *Handler) HideArticle(c *fiber.Ctx) error {
+	return h.setHidden(c, true)
+}
+
+// POST /api/articles/:id/unhide
+func (h *Handler) UnhideArticle(c *fiber.Ctx) error {
+	return h.setHidden(c, false)
+}
+
+// setHidden backs HideArticle and UnhideArticle, whose routes require
+// policy.ModerateArticles.
+func (h *Handler) setHidden(c *fiber.Ctx, hidden bool) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	err = h.articles.SetHidden(c.UserContext(), id, hidden)
+	if errors.Is(err, store.ErrNotFound) {
+		return errArticleNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(fiber.Map{"message": "Article visibility updated", "hidden": hidden})
+}
+
+// makeExcerpt returns the start of the content's text, without its Markdown
+// syntax, cut at a word boundary.
+func makeExcerpt(content string) string {
+	text := strings.Join(strings.Fields(markdown.Text(content)), " ")
+	runes := []rune(text)
+	if len(runes) <= excerptLength {
+		return text
+	}
+
+	cut := excerptLength
+	for cut > 0 && !unicode.IsSpace(runes[cut]) {
+		cut--
+	}
+	if cut == 0 {
+		cut = excerptLength
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsPunct) + "…"
+}
@@ -0,0 +1,450 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"blog-api/store"
+	"blog-api/store/memory"
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestArticleCRUD(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	other := api.addUser(t, models.RoleAuthor)
+
+	id := api.createArticle(t, author, "Hello World")
+	r := api.do(t, http.MethodGet, "/api/articles/"+id, "", nil)
+	expect(t, r, http.StatusOK, "")
+	if r.str("title") != "Hello World" || r.str("slug") != "hello-world" || r.str("user_id") != author {
+		t.Fatalf("created article: %v", r.body)
+	}
+
+	update := map[string]any{"title": "Hello Again", "content": "New content."}
+	expect(t, api.do(t, http.MethodPut, "/api/articles/"+id, other, update), http.StatusNotFound, "article_not_found")
+	r = api.do(t, http.MethodPut, "/api/articles/"+id, author, update)
+	expect(t, r, http.StatusOK, "")
+	if r.str("slug") != "hello-again" {
+		t.Fatalf("updated slug = %q, want hello-again", r.str("slug"))
+	}
+	// The old slug redirects to the new one.
+	r = api.do(t, http.MethodGet, "/api/articles/by-slug/hello-world", "", nil)
+	expect(t, r, http.StatusMovedPermanently, "")
+	r = api.do(t, http.MethodGet, "/api/articles/by-slug/hello-again", "", nil)
+	if expect(t, r, http.StatusOK, ""); r.str("id") != id || r.str("content") != "New content." {
+		t.Fatalf("article by slug: %v", r.body)
+	}
+
+	expect(t, api.do(t, http.MethodDelete, "/api/articles/"+id, other, nil), http.StatusNotFound, "article_not_found")
+	expect(t, api.do(t, http.MethodDelete, "/api/articles/"+id, author, nil), http.StatusOK, "")
+	expect(t, api.do(t, http.MethodGet, "/api/articles/"+id, "", nil), http.StatusNotFound, "article_not_found")
+	expect(t, api.do(t, http.MethodGet, "/api/articles/by-slug/hello-world", "", nil), http.StatusNotFound, "article_not_found")
+}
+
+func TestArticleSlugs(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	slugOf := func(body map[string]any) string {
+		t.Helper()
+		r := api.do(t, http.MethodPost, "/api/articles", author, body)
+		expect(t, r, http.StatusCreated, "")
+		return r.str("slug")
+	}
+
+	tests := []struct {
+		body map[string]any
+		want string
+	}{
+		{map[string]any{"title": "Élève à l'œuvre", "content": "x"}, "eleve-a-l-oeuvre"},
+		{map[string]any{"title": "Élève à l'œuvre!", "content": "x"}, "eleve-a-l-oeuvre-2"},
+		{map[string]any{"title": "  Élève à l'œuvre  ", "content": "x"}, "eleve-a-l-oeuvre-3"},
+		// Without a title the slug comes from the content.
+		{map[string]any{"content": "No title here."}, "no-title-here"},
+		{map[string]any{"title": "???", "content": "!!!"}, "article"},
+	}
+	for _, tt := range tests {
+		if got := slugOf(tt.body); got != tt.want {
+			t.Errorf("slug of %v = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+
+	// A slug given up by a rename still belongs to its article.
+	id := api.createArticle(t, author, "Taken")
+	expect(t, api.do(t, http.MethodPut, "/api/articles/"+id, author, map[string]any{"title": "Renamed", "content": "x"}), http.StatusOK, "")
+	if got := slugOf(map[string]any{"title": "Taken", "content": "x"}); got != "taken-2" {
+		t.Errorf("slug of a reused title = %q, want taken-2", got)
+	}
+	// Renaming back takes the old slug again.
+	r := api.do(t, http.MethodPut, "/api/articles/"+id, author, map[string]any{"title": "Taken", "content": "x"})
+	if expect(t, r, http.StatusOK, ""); r.str("slug") != "taken" {
+		t.Errorf("slug after renaming back = %q, want taken", r.str("slug"))
+	}
+}
+
+// racingArticles is an ArticleStore whose writes lose a race for their
+// slug once armed.
+type racingArticles struct {
+	store.ArticleStore
+	armed bool
+}
+
+func (s *racingArticles) Create(ctx context.Context, article *models.Article) error {
+	if s.armed {
+		return store.ErrConflict
+	}
+	return s.ArticleStore.Create(ctx, article)
+}
+
+func (s *racingArticles) Update(ctx context.Context, article *models.Article) error {
+	if s.armed {
+		return store.ErrConflict
+	}
+	return s.ArticleStore.Update(ctx, article)
+}
+
+func (s *racingArticles) Restore(ctx context.Context, article *models.Article, number int) error {
+	if s.armed {
+		return store.ErrConflict
+	}
+	return s.ArticleStore.Restore(ctx, article, number)
+}
+
+func TestArticleSlugConflict(t *testing.T) {
+	stores := memory.New()
+	articles := &racingArticles{ArticleStore: stores.Articles}
+	stores.Articles = articles
+	api := newTestAPIOver(t, stores, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	id := api.createArticle(t, author, "Racing")
+
+	articles.armed = true
+	body := map[string]any{"title": "Racing", "content": "x"}
+	expect(t, api.do(t, http.MethodPost, "/api/articles", author, body), http.StatusConflict, "slug_conflict")
+	expect(t, api.do(t, http.MethodPut, "/api/articles/"+id, author, body), http.StatusConflict, "slug_conflict")
+	expect(t, api.do(t, http.MethodPost, "/api/articles/"+id+"/revisions/1/restore", author, nil), http.StatusConflict, "slug_conflict")
+}
+
+func TestArticleExcerpt(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	long := strings.Repeat("word ", 60)
+
+	tests := []struct {
+		body map[string]any
+		want string
+	}{
+		{map[string]any{"content": "Short   text\non lines."}, "Short text on lines."},
+		{map[string]any{"content": long}, strings.TrimSpace(long[:200]) + "…"},
+		{map[string]any{"content": strings.Repeat("x", 250)}, strings.Repeat("x", 200) + "…"},
+		{map[string]any{"content": long, "excerpt": "  Given.  "}, "Given."},
+	}
+	for _, tt := range tests {
+		r := api.do(t, http.MethodPost, "/api/articles", author, tt.body)
+		if expect(t, r, http.StatusCreated, ""); r.str("excerpt") != tt.want {
+			t.Errorf("excerpt = %q, want %q", r.str("excerpt"), tt.want)
+		}
+	}
+}
+
+func TestArticleErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	other := api.addUser(t, models.RoleAuthor)
+	reader := api.addUser(t, models.RoleReader)
+	admin := api.addUser(t, models.RoleAdmin)
+	id := api.createArticle(t, author, "Mine")
+	body := map[string]any{"content": "Text"}
+
+	tests := []struct {
+		name         string
+		method, path string
+		userID       string
+		body         any
+		status       int
+		code         string
+	}{
+		{"create anonymously", http.MethodPost, "/api/articles", "", body, http.StatusUnauthorized, "unauthenticated"},
+		{"create as a reader", http.MethodPost, "/api/articles", reader, body, http.StatusForbidden, "permission_denied"},
+		{"get an unknown ID", http.MethodGet, "/api/articles/" + uuid.NewString(), "", nil, http.StatusNotFound, "article_not_found"},
+		{"get an unknown slug", http.MethodGet, "/api/articles/by-slug/nope", "", nil, http.StatusNotFound, "article_not_found"},
+		{"update anonymously", http.MethodPut, "/api/articles/" + id, "", body, http.StatusUnauthorized, "unauthenticated"},
+		{"update an unknown ID", http.MethodPut, "/api/articles/" + uuid.NewString(), author, body, http.StatusNotFound, "article_not_found"},
+		{"update as a reader", http.MethodPut, "/api/articles/" + id, reader, body, http.StatusForbidden, "permission_denied"},
+		{"update as an admin", http.MethodPut, "/api/articles/" + id, admin, body, http.StatusNotFound, "article_not_found"},
+		{"restore as a reader", http.MethodPost, "/api/articles/" + id + "/revisions/1/restore", reader, nil, http.StatusForbidden, "permission_denied"},
+		{"delete anonymously", http.MethodDelete, "/api/articles/" + id, "", nil, http.StatusUnauthorized, "unauthenticated"},
+		{"delete another author's article", http.MethodDelete, "/api/articles/" + id, other, nil, http.StatusNotFound, "article_not_found"},
+		{"hide anonymously", http.MethodPost, "/api/articles/" + id + "/hide", "", nil, http.StatusUnauthorized, "unauthenticated"},
+		{"hide as an author", http.MethodPost, "/api/articles/" + id + "/hide", author, nil, http.StatusForbidden, "permission_denied"},
+		{"hide an unknown ID", http.MethodPost, "/api/articles/" + uuid.NewString() + "/hide", admin, nil, http.StatusNotFound, "article_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect(t, api.do(t, tt.method, tt.path, tt.userID, tt.body), tt.status, tt.code)
+		})
+	}
+
+	// Admins delete any article.
+	expect(t, api.do(t, http.MethodDelete, "/api/articles/"+id, admin, nil), http.StatusOK, "")
+	expect(t, api.do(t, http.MethodGet, "/api/articles/"+id, author, nil), http.StatusNotFound, "article_not_found")
+}
+
+func TestArticleHiding(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	reader := api.addUser(t, models.RoleReader)
+	moderator := api.addUser(t, models.RoleModerator)
+	hidden := api.createArticle(t, author, "Hidden")
+	shown := api.createArticle(t, author, "Shown")
+	expect(t, api.do(t, http.MethodPost, "/api/favorites", reader, map[string]any{"article_id": hidden}), http.StatusCreated, "")
+
+	r := api.do(t, http.MethodPost, "/api/articles/"+hidden+"/hide", moderator, nil)
+	if expect(t, r, http.StatusOK, ""); r.body["hidden"] != true {
+		t.Fatalf("hide = %v", r.body)
+	}
+	for userID, status := range map[string]int{"": http.StatusNotFound, reader: http.StatusNotFound, author: http.StatusOK, moderator: http.StatusOK} {
+		r := api.do(t, http.MethodGet, "/api/articles/"+hidden, userID, nil)
+		expect(t, r, status, "")
+		if status == http.StatusOK && r.body["hidden"] != true {
+			t.Errorf("hidden = %v, want true", r.body["hidden"])
+		}
+	}
+	expect(t, api.do(t, http.MethodGet, "/api/articles/by-slug/hidden", reader, nil), http.StatusNotFound, "article_not_found")
+
+	// Hidden articles are only listed to their author.
+	lists := []struct {
+		path, userID string
+		want         []string
+	}{
+		{"/api/articles", "", []string{shown}},
+		{"/api/articles", moderator, []string{shown}},
+		{"/api/articles?author=" + author, reader, []string{shown}},
+		{"/api/articles?author=" + author, author, []string{shown, hidden}},
+	}
+	for _, tt := range lists {
+		if got, _, _ := pageIDs(t, api.do(t, http.MethodGet, tt.path, tt.userID, nil)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s as %q = %v, want %v", tt.path, tt.userID, got, tt.want)
+		}
+	}
+
+	favorites := func() []string {
+		t.Helper()
+		ids, _, _ := pageIDs(t, api.do(t, http.MethodGet, "/api/favorites/user/"+reader, "", nil))
+		return ids
+	}
+	if got := favorites(); len(got) != 0 {
+		t.Errorf("favorites = %v, want the hidden article left out", got)
+	}
+
+	// Its comments and likes are as hidden as the article.
+	for userID, status := range map[string]int{reader: http.StatusNotFound, author: http.StatusOK, moderator: http.StatusOK} {
+		expect(t, api.do(t, http.MethodGet, "/api/comments/article/"+hidden, userID, nil), status, "")
+		expect(t, api.do(t, http.MethodGet, "/api/comments/article/"+hidden+"/tree", userID, nil), status, "")
+		expect(t, api.do(t, http.MethodPost, "/api/likes", userID, map[string]any{"article_id": hidden}), status, "")
+	}
+	expect(t, api.do(t, http.MethodPost, "/api/comments", reader, map[string]any{"article_id": hidden, "content": "x"}), http.StatusNotFound, "article_not_found")
+	expect(t, api.do(t, http.MethodPost, "/api/comments", author, map[string]any{"article_id": hidden, "content": "x"}), http.StatusCreated, "")
+
+	expect(t, api.do(t, http.MethodPost, "/api/articles/"+hidden+"/unhide", moderator, nil), http.StatusOK, "")
+	expect(t, api.do(t, http.MethodGet, "/api/articles/"+hidden, reader, nil), http.StatusOK, "")
+	if got := favorites(); len(got) != 1 {
+		t.Errorf("favorites = %v, want the unhidden article back", got)
+	}
+}
+
+func TestArticlePagination(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	var created []string
+	for _, title := range []string{"One", "Two", "Three", "Four", "Five"} {
+		created = append(created, api.createArticle(t, author, title))
+	}
+	slices.Reverse(created) // newest first
+
+	var pages [][]string
+	var prev string
+	for path := "/api/articles?limit=2"; ; {
+		ids, next, p := pageIDs(t, api.do(t, http.MethodGet, path, "", nil))
+		pages = append(pages, ids)
+		prev = p
+		if next == "" {
+			break
+		}
+		path = "/api/articles?limit=2&after=" + next
+	}
+	if got := slices.Concat(pages...); !slices.Equal(got, created) || len(pages) != 3 {
+		t.Fatalf("pages = %v, want %v in pages of 2", pages, created)
+	}
+
+	// Walking back from the last page returns the previous one.
+	ids, next, _ := pageIDs(t, api.do(t, http.MethodGet, "/api/articles?limit=2&before="+prev, "", nil))
+	if !slices.Equal(ids, pages[1]) || next == "" {
+		t.Fatalf("previous page = %v (next %q), want %v", ids, next, pages[1])
+	}
+
+	// The first page has no previous cursor, and the limit defaults to 20.
+	ids, _, prev = pageIDs(t, api.do(t, http.MethodGet, "/api/articles", "", nil))
+	if len(ids) != len(created) || prev != "" {
+		t.Fatalf("default page = %v (prev %q), want all %d articles", ids, prev, len(created))
+	}
+}
+
+func TestPageErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	cursor := uuid.NewString()
+	for query, code := range map[string]string{
+		"limit=0":          "invalid_limit",
+		"limit=x":          "invalid_limit",
+		"after=" + cursor:  "invalid_cursor",
+		"before=@@":        "invalid_cursor",
+		"before=x&after=y": "invalid_cursor",
+	} {
+		t.Run(query, func(t *testing.T) {
+			expect(t, api.do(t, http.MethodGet, "/api/articles?"+query, "", nil), http.StatusBadRequest, code)
+		})
+	}
+}
+
+func TestArticleStatuses(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author, reader := api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	create := func(body map[string]any) string {
+		t.Helper()
+		r := api.do(t, http.MethodPost, "/api/articles", author, body)
+		expect(t, r, http.StatusCreated, "")
+		return r.str("id")
+	}
+	published := create(map[string]any{"title": "Published", "content": "x"})
+	draft := create(map[string]any{"title": "Draft", "content": "x", "status": "draft"})
+	scheduled := create(map[string]any{"title": "Scheduled", "content": "x", "status": "scheduled", "publish_at": future})
+	archived := create(map[string]any{"title": "Archived", "content": "x", "status": "archived"})
+
+	r := api.do(t, http.MethodGet, "/api/articles/"+published, "", nil)
+	if expect(t, r, http.StatusOK, ""); r.str("status") != "published" || r.str("publish_at") == "" {
+		t.Fatalf("published article = %v", r.body)
+	}
+
+	// Unpublished articles are only visible to their author.
+	for _, id := range []string{draft, scheduled, archived} {
+		expect(t, api.do(t, http.MethodGet, "/api/articles/"+id, "", nil), http.StatusNotFound, "article_not_found")
+		expect(t, api.do(t, http.MethodGet, "/api/articles/"+id, reader, nil), http.StatusNotFound, "article_not_found")
+		expect(t, api.do(t, http.MethodGet, "/api/articles/"+id, author, nil), http.StatusOK, "")
+	}
+	r = api.do(t, http.MethodGet, "/api/articles/"+draft, author, nil)
+	expect(t, api.do(t, http.MethodGet, "/api/articles/by-slug/"+r.str("slug"), reader, nil), http.StatusNotFound, "article_not_found")
+	expect(t, api.do(t, http.MethodGet, "/api/articles/by-slug/"+r.str("slug"), author, nil), http.StatusOK, "")
+
+	// A scheduled date in the past publishes right away.
+	r = api.do(t, http.MethodPost, "/api/articles", author, map[string]any{"title": "Late", "content": "x", "status": "scheduled", "publish_at": past})
+	if expect(t, r, http.StatusCreated, ""); r.str("status") != "published" {
+		t.Fatalf("status = %q, want published", r.str("status"))
+	}
+	late := r.str("id")
+
+	// Publishing a draft makes it visible; an update without a status keeps it.
+	r = api.do(t, http.MethodPut, "/api/articles/"+draft, author, map[string]any{"title": "Draft", "content": "y"})
+	if expect(t, r, http.StatusOK, ""); r.str("status") != "draft" {
+		t.Fatalf("status = %q, want draft", r.str("status"))
+	}
+	expect(t, api.do(t, http.MethodPut, "/api/articles/"+draft, author, map[string]any{"title": "Draft", "content": "y", "status": "published"}), http.StatusOK, "")
+	expect(t, api.do(t, http.MethodGet, "/api/articles/"+draft, reader, nil), http.StatusOK, "")
+
+	tests := []struct {
+		name   string
+		query  string
+		userID string
+		want   []string
+	}{
+		// Latest published first: the draft published last comes first and
+		// the article scheduled in the past last.
+		{"published by default", "", reader, []string{draft, published, late}},
+		{"own drafts", "?status=draft", author, nil},
+		{"own scheduled", "?status=scheduled", author, []string{scheduled}},
+		{"own archived", "?author=" + author + "&status=archived", author, []string{archived}},
+		{"all own", "?status=all", author, []string{scheduled, draft, archived, published, late}},
+		{"published of an author", "?author=" + author + "&status=published", reader, []string{draft, published, late}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := api.do(t, http.MethodGet, "/api/articles"+tt.query, tt.userID, nil)
+			expect(t, r, http.StatusOK, "")
+			got, _, _ := pageIDs(t, r)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("articles = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArticleStatusErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author, reader := api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor)
+	id := api.createArticle(t, author, "Hello")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		userID string
+		body   any
+		status int
+		code   string
+	}{
+		{"unknown status", http.MethodPost, "/api/articles", author, map[string]any{"title": "x", "content": "x", "status": "secret"}, http.StatusUnprocessableEntity, "validation_failed"},
+		{"scheduled without date", http.MethodPost, "/api/articles", author, map[string]any{"title": "x", "content": "x", "status": "scheduled"}, http.StatusBadRequest, "publish_at_required"},
+		{"update to unknown status", http.MethodPut, "/api/articles/" + id, author, map[string]any{"title": "x", "content": "x", "status": "secret"}, http.StatusUnprocessableEntity, "validation_failed"},
+		{"anonymous status list", http.MethodGet, "/api/articles?status=draft", "", nil, http.StatusUnauthorized, "unauthenticated"},
+		{"another author's drafts", http.MethodGet, "/api/articles?author=" + author + "&status=draft", reader, nil, http.StatusForbidden, "forbidden"},
+		{"invalid status list", http.MethodGet, "/api/articles?status=secret", author, nil, http.StatusBadRequest, "invalid_status"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect(t, api.do(t, tt.method, tt.path, tt.userID, tt.body), tt.status, tt.code)
+		})
+	}
+}
+
+func TestUnpublishedArticleInteractions(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author, reader := api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor)
+	r := api.do(t, http.MethodPost, "/api/articles", author, map[string]any{"title": "Draft", "content": "x", "status": models.ArticleDraft})
+	expect(t, r, http.StatusCreated, "")
+	draft := r.str("id")
+
+	// A draft's comments, likes, favorites and live events are only for
+	// those who can read it.
+	for userID, status := range map[string]int{"": http.StatusNotFound, reader: http.StatusNotFound, author: http.StatusOK} {
+		for _, path := range []string{
+			"/api/comments/article/" + draft,
+			"/api/likes/count/" + draft,
+			"/api/likes/status?article_id=" + draft,
+		} {
+			expect(t, api.do(t, http.MethodGet, path, userID, nil), status, "")
+		}
+		if status == http.StatusNotFound {
+			expect(t, api.do(t, http.MethodGet, "/api/stream?articles="+draft, userID, nil), status, "article_not_found")
+		}
+	}
+	expect(t, api.do(t, http.MethodPost, "/api/favorites", reader, map[string]any{"article_id": draft}), http.StatusNotFound, "article_not_found")
+
+	// The author may favorite the draft, but favorites list published
+	// articles only.
+	expect(t, api.do(t, http.MethodPost, "/api/favorites", author, map[string]any{"article_id": draft}), http.StatusCreated, "")
+	if ids, _, _ := pageIDs(t, api.do(t, http.MethodGet, "/api/favorites/user/"+author, author, nil)); len(ids) != 0 {
+		t.Fatalf("favorites = %v, want the draft left out", ids)
+	}
+	expect(t, api.do(t, http.MethodPut, "/api/articles/"+draft, author, map[string]any{"title": "Draft", "content": "x", "status": models.ArticlePublished}), http.StatusOK, "")
+	if ids, _, _ := pageIDs(t, api.do(t, http.MethodGet, "/api/favorites/user/"+author, author, nil)); len(ids) != 1 {
+		t.Fatalf("favorites = %v, want the published article", ids)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"blog-api/apperr"
+	"blog-api/middleware"
+	"blog-api/models"
+	"blog-api/policy"
+	"blog-api/store"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// actorKey is the c.Locals key caching the policy.Actor of the request.
+const actorKey = "actor"
+
+// requireUser returns the authenticated user ID, or a 401 error when the
+// request carries no valid token.
+func requireUser(c *fiber.Ctx) (string, error) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		return "", errUnauthenticated
+	}
+	return userID, nil
+}
+
+// actor returns the caller with their role, loaded once per request.
+// Anonymous callers get the zero Actor, and authenticated users without a
+// profile yet are readers.
+func (h *Handler) actor(c *fiber.Ctx) (policy.Actor, error) {
+	if actor, ok := c.Locals(actorKey).(policy.Actor); ok {
+		return actor, nil
+	}
+	userID := middleware.UserID(c)
+	if userID == "" {
+		return policy.Actor{}, nil
+	}
+
+	actor := policy.Actor{ID: userID, Role: models.RoleReader}
+	user, err := h.users.Get(c.UserContext(), userID)
+	if err == nil {
+		actor.Role = user.Role
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return policy.Actor{}, apperr.Internal(err)
+	}
+	c.Locals(actorKey, actor)
+	return actor, nil
+}
+
+// RequirePermission rejects requests from anonymous callers with a 401,
+// and from users whose role does not grant p with a 403.
+func (h *Handler) RequirePermission(p policy.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, err := requireUser(c); err != nil {
+			return err
+		}
+		actor, err := h.actor(c)
+		if err != nil {
+			return err
+		}
+		if !actor.Can(p) {
+			return errPermissionDenied
+		}
+		return c.Next()
+	}
+}
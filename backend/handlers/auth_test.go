@@ -0,0 +1,29 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRequirePermission(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	article := api.createArticle(t, author, "Discussed")
+
+	// Users without a profile yet are readers: they comment but do not
+	// write articles.
+	newcomer := uuid.NewString()
+	expect(t, api.do(t, http.MethodPost, "/api/comments", newcomer, map[string]any{"article_id": article, "content": "Hi"}), http.StatusCreated, "")
+	expect(t, api.do(t, http.MethodPost, "/api/articles", newcomer, map[string]any{"content": "x"}), http.StatusForbidden, "permission_denied")
+
+	// Users created through the API are authors.
+	r := api.do(t, http.MethodPost, "/api/users", newcomer, map[string]any{"email": "new@example.com"})
+	if expect(t, r, http.StatusCreated, ""); r.str("role") != models.RoleAuthor {
+		t.Fatalf("role = %q, want author", r.str("role"))
+	}
+	expect(t, api.do(t, http.MethodPost, "/api/articles", newcomer, map[string]any{"content": "x"}), http.StatusCreated, "")
+}
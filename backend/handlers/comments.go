@@ -1,122 +1,228 @@
 package handlers
 
 import (
-	"blog-api/db"
+	"blog-api/apperr"
 	"blog-api/models"
-	"database/sql"
+	"blog-api/notify"
+	"blog-api/policy"
+	"blog-api/realtime"
+	"blog-api/store"
+	"blog-api/validate"
+	"context"
+	"errors"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
-// GetArticleComments - GET /api/comments/article/:id
-func GetArticleComments(c *fiber.Ctx) error {
-	articleID := c.Params("id")
-
-	rows, err := db.DB.Query(`
-		SELECT c.id, c.article_id, c.user_id, c.content, c.created_at,
-		       u.email, u.firstname, u.lastname, u.created_at
-		FROM comments c
-		LEFT JOIN users u ON c.user_id = u.id
-		WHERE c.article_id = $1
-		ORDER BY c.created_at ASC
-	`, articleID)
+// GetArticleComments - GET /api/comments/article/:id?limit=&before=&after=
+//
+// Every comment of the article in chronological order, with its parent_id
+// and depth.
+func (h *Handler) GetArticleComments(c *fiber.Ctx) error {
+	page, err := parsePage(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Database error: " + err.Error()})
-	}
-	defer rows.Close()
-
-	var comments []models.Comment
-	for rows.Next() {
-		var comment models.Comment
-		var author models.Profile
-		var firstName, lastName sql.NullString
-
-		err := rows.Scan(
-			&comment.ID,
-			&comment.ArticleID,
-			&comment.UserID,
-			&comment.Content,
-			&comment.CreatedAt,
-			&author.Email,
-			&firstName,
-			&lastName,
-			&author.CreatedAt,
-		)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Scan error: " + err.Error()})
-		}
-		author.FirstName = firstName.String
-		author.LastName = lastName.String
-		comment.Author = &author
-		comments = append(comments, comment)
+		return err
+	}
+
+	articleID, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.visibleArticle(c, articleID); err != nil {
+		return err
 	}
 
-	return c.JSON(comments)
+	comments, err := h.comments.ListByArticle(c.UserContext(), articleID, page)
+	if err != nil {
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(newPageResponse(comments))
 }
 
-// CreateComment - POST /api/comments
-func CreateComment(c *fiber.Ctx) error {
-	comment := new(models.Comment)
-	if err := c.BodyParser(comment); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+// GetArticleCommentTree - GET /api/comments/article/:id/tree?limit=&before=&after=
+//
+// Pages through the top-level comments, each with its nested replies.
+func (h *Handler) GetArticleCommentTree(c *fiber.Ctx) error {
+	page, err := parsePage(c)
+	if err != nil {
+		return err
 	}
 
-	comment.ID = uuid.New().String()
+	articleID, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
 
-	_, err := db.DB.Exec(`
-		INSERT INTO comments (id, article_id, user_id, content)
-		VALUES ($1, $2, $3, $4)
-	`, comment.ID, comment.ArticleID, comment.UserID, comment.Content)
+	if _, err := h.visibleArticle(c, articleID); err != nil {
+		return err
+	}
+
+	comments, err := h.comments.Thread(c.UserContext(), articleID, page)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not create comment: " + err.Error()})
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(newPageResponse(comments))
+}
+
+// CreateComment - POST /api/comments
+//
+// A parent_id makes the comment a reply, up to the configured depth; a blank
+// one is ignored.
+func (h *Handler) CreateComment(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req createCommentRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	if _, err := h.visibleArticle(c, req.ArticleID); err != nil {
+		return err
+	}
+
+	comment := &models.Comment{
+		ID:        uuid.New().String(),
+		ArticleID: req.ArticleID,
+		ParentID:  req.ParentID,
+		Content:   req.Content,
+		UserID:    userID,
+	}
+	if comment.ParentID != nil {
+		if parentID := strings.TrimSpace(*comment.ParentID); parentID != "" {
+			comment.ParentID = &parentID
+		} else {
+			comment.ParentID = nil
+		}
+	}
+	var parent *models.Comment
+	if comment.ParentID != nil {
+		if err := validate.Var("parent_id", *comment.ParentID, "uuid"); err != nil {
+			return err
+		}
+		var err error
+		parent, err = h.comments.Get(c.UserContext(), *comment.ParentID)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && (parent.Deleted || parent.ArticleID != comment.ArticleID)) {
+			return apperr.NotFound("parent_comment_not_found", "Parent comment not found")
+		} else if err != nil {
+			return apperr.Internal(err)
+		}
+		if parent.Depth >= h.maxCommentDepth {
+			return apperr.BadRequest("max_depth_reached", "Maximum reply depth reached")
+		}
+		comment.Depth = parent.Depth + 1
 	}
 
+	err = h.comments.Create(c.UserContext(), comment)
+	if errors.Is(err, store.ErrNotFound) {
+		return errArticleNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
+	}
+
+	h.publish(c.UserContext(), realtime.ArticleChannel(comment.ArticleID), "comment", comment)
+	h.notifyComment(c.UserContext(), comment, parent)
 	return c.Status(201).JSON(comment)
 }
 
 // UpdateComment - PUT /api/comments/:id
-func UpdateComment(c *fiber.Ctx) error {
-	id := c.Params("id")
-	comment := new(models.Comment)
-	if err := c.BodyParser(comment); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
-	}
-
-	result, err := db.DB.Exec(`
-		UPDATE comments
-		SET content = $1
-		WHERE id = $2 AND user_id = $3
-	`, comment.Content, id, comment.UserID)
+//
+// Moderators may edit any comment.
+func (h *Handler) UpdateComment(c *fiber.Ctx) error {
+	if _, err := requireUser(c); err != nil {
+		return err
+	}
+	actor, err := h.actor(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not update comment: " + err.Error()})
+		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "Comment not found or unauthorized"})
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+	var req updateCommentRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	comment, err := h.comments.Get(c.UserContext(), id)
+	if err == nil && !policy.CanEditComment(actor, comment) {
+		err = store.ErrNotFound
+	}
+	if err == nil {
+		err = h.comments.Update(c.UserContext(), id, comment.UserID, req.Content)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return errCommentNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
 	}
 
 	return c.JSON(fiber.Map{"message": "Comment updated successfully"})
 }
 
 // DeleteComment - DELETE /api/comments/:id
-func DeleteComment(c *fiber.Ctx) error {
-	id := c.Params("id")
-	// On attend que l'id de l'utilisateur soit passé en query string, par exemple ?user_id=xxx
-	userID := c.Query("user_id")
-
-	result, err := db.DB.Exec(`
-		DELETE FROM comments
-		WHERE id = $1 AND user_id = $2
-	`, id, userID)
+//
+// Moderators may delete any comment.
+func (h *Handler) DeleteComment(c *fiber.Ctx) error {
+	if _, err := requireUser(c); err != nil {
+		return err
+	}
+	actor, err := h.actor(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not delete comment: " + err.Error()})
+		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "Comment not found or unauthorized"})
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	comment, err := h.comments.Get(c.UserContext(), id)
+	if err == nil && !policy.CanDeleteComment(actor, comment) {
+		err = store.ErrNotFound
+	}
+	if err == nil {
+		err = h.comments.Delete(c.UserContext(), id, comment.UserID)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return errCommentNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
 	}
 
 	return c.JSON(fiber.Map{"message": "Comment deleted successfully"})
 }
+
+// notifyComment tells the author of the parent comment about a reply, and
+// the article author about any new comment they were not already told of.
+func (h *Handler) notifyComment(ctx context.Context, comment, parent *models.Comment) {
+	if parent != nil {
+		h.notify(ctx, notify.Event{
+			Kind:        models.NotificationReply,
+			RecipientID: parent.UserID,
+			ActorID:     comment.UserID,
+			ArticleID:   comment.ArticleID,
+			CommentID:   parent.ID,
+		})
+	}
+
+	article, err := h.articles.Get(ctx, comment.ArticleID)
+	if err != nil || (parent != nil && parent.UserID == article.UserID) {
+		return
+	}
+	h.notify(ctx, notify.Event{
+		Kind:        models.NotificationComment,
+		RecipientID: article.UserID,
+		ActorID:     comment.UserID,
+		ArticleID:   article.ID,
+	})
+}
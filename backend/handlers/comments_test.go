@@ -0,0 +1,186 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCommentCRUD(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	reader := api.addUser(t, models.RoleReader)
+	moderator := api.addUser(t, models.RoleModerator)
+	article := api.createArticle(t, author, "Discussed")
+
+	r := api.do(t, http.MethodPost, "/api/comments", reader, map[string]any{"article_id": article, "content": "First"})
+	expect(t, r, http.StatusCreated, "")
+	comment := r.str("id")
+	if r.str("user_id") != reader {
+		t.Fatalf("comment user_id = %q, want the token subject %q", r.str("user_id"), reader)
+	}
+	// A blank parent_id makes a top-level comment.
+	r = api.do(t, http.MethodPost, "/api/comments", author, map[string]any{"article_id": article, "parent_id": " ", "content": "Second"})
+	expect(t, r, http.StatusCreated, "")
+	second := r.str("id")
+	if r.body["parent_id"] != nil {
+		t.Fatalf("parent_id = %v, want null", r.body["parent_id"])
+	}
+
+	if got, _, _ := pageIDs(t, api.do(t, http.MethodGet, "/api/comments/article/"+article, "", nil)); !slices.Equal(got, []string{comment, second}) {
+		t.Fatalf("comments = %v, want %v in chronological order", got, []string{comment, second})
+	}
+
+	edit := map[string]any{"content": "Edited"}
+	expect(t, api.do(t, http.MethodPut, "/api/comments/"+comment, author, edit), http.StatusNotFound, "comment_not_found")
+	expect(t, api.do(t, http.MethodPut, "/api/comments/"+comment, reader, edit), http.StatusOK, "")
+
+	// Moderators edit and delete anyone's comments.
+	expect(t, api.do(t, http.MethodPut, "/api/comments/"+second, moderator, map[string]any{"content": "Moderated"}), http.StatusOK, "")
+	r = api.do(t, http.MethodGet, "/api/comments/article/"+article, "", nil)
+	if moderated := r.body["data"].([]any)[1].(map[string]any); moderated["content"] != "Moderated" || moderated["user_id"] != author {
+		t.Fatalf("moderated comment = %v", moderated)
+	}
+
+	expect(t, api.do(t, http.MethodDelete, "/api/comments/"+comment, author, nil), http.StatusNotFound, "comment_not_found")
+	expect(t, api.do(t, http.MethodDelete, "/api/comments/"+comment, moderator, nil), http.StatusOK, "")
+	expect(t, api.do(t, http.MethodDelete, "/api/comments/"+comment, reader, nil), http.StatusNotFound, "comment_not_found")
+
+	// Deleting the article removes its comments.
+	expect(t, api.do(t, http.MethodDelete, "/api/articles/"+article, author, nil), http.StatusOK, "")
+	expect(t, api.do(t, http.MethodPut, "/api/comments/"+second, author, edit), http.StatusNotFound, "comment_not_found")
+}
+
+func TestCommentErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	article := api.createArticle(t, author, "Discussed")
+
+	tests := []struct {
+		name         string
+		method, path string
+		userID       string
+		body         any
+		status       int
+		code         string
+	}{
+		{"create anonymously", http.MethodPost, "/api/comments", "", map[string]any{"article_id": article, "content": "Hi"}, http.StatusUnauthorized, "unauthenticated"},
+		{"create on an unknown article", http.MethodPost, "/api/comments", author, map[string]any{"article_id": uuid.NewString(), "content": "Hi"}, http.StatusNotFound, "article_not_found"},
+		{"update anonymously", http.MethodPut, "/api/comments/" + uuid.NewString(), "", map[string]any{"content": "Hi"}, http.StatusUnauthorized, "unauthenticated"},
+		{"update an unknown comment", http.MethodPut, "/api/comments/" + uuid.NewString(), author, map[string]any{"content": "Hi"}, http.StatusNotFound, "comment_not_found"},
+		{"delete anonymously", http.MethodDelete, "/api/comments/" + uuid.NewString(), "", nil, http.StatusUnauthorized, "unauthenticated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect(t, api.do(t, tt.method, tt.path, tt.userID, tt.body), tt.status, tt.code)
+		})
+	}
+}
+
+func TestCommentPagination(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	article := api.createArticle(t, author, "Discussed")
+	var created []string
+	for _, content := range []string{"One", "Two", "Three"} {
+		r := api.do(t, http.MethodPost, "/api/comments", author, map[string]any{"article_id": article, "content": content})
+		expect(t, r, http.StatusCreated, "")
+		created = append(created, r.str("id"))
+	}
+
+	base := "/api/comments/article/" + article + "?limit=2"
+	first, next, _ := pageIDs(t, api.do(t, http.MethodGet, base, "", nil))
+	second, last, prev := pageIDs(t, api.do(t, http.MethodGet, base+"&after="+next, "", nil))
+	if got := slices.Concat(first, second); !slices.Equal(got, created) || last != "" {
+		t.Fatalf("pages = %v %v (next %q), want %v in chronological order", first, second, last, created)
+	}
+	back, _, _ := pageIDs(t, api.do(t, http.MethodGet, base+"&before="+prev, "", nil))
+	if !slices.Equal(back, first) {
+		t.Fatalf("previous page = %v, want %v", back, first)
+	}
+}
+
+func TestCommentThreads(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{MaxCommentDepth: 2})
+	author, reader := api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor)
+	article := api.createArticle(t, author, "Discussed")
+	comment := func(userID, parentID, content string) string {
+		t.Helper()
+		body := map[string]any{"article_id": article, "content": content}
+		if parentID != "" {
+			body["parent_id"] = parentID
+		}
+		r := api.do(t, http.MethodPost, "/api/comments", userID, body)
+		expect(t, r, http.StatusCreated, "")
+		return r.str("id")
+	}
+	root := comment(reader, "", "Root")
+	reply := comment(author, root, "Reply")
+	nested := comment(reader, reply, "Nested")
+	other := comment(author, "", "Other")
+
+	r := api.do(t, http.MethodPost, "/api/comments", author, map[string]any{"article_id": article, "parent_id": nested, "content": "Too deep"})
+	expect(t, r, http.StatusBadRequest, "max_depth_reached")
+
+	// The flat list has every comment with its depth.
+	r = api.do(t, http.MethodGet, "/api/comments/article/"+article, "", nil)
+	ids, _, _ := pageIDs(t, r)
+	if !slices.Equal(ids, []string{root, reply, nested, other}) {
+		t.Fatalf("comments = %v", ids)
+	}
+	if depth := r.body["data"].([]any)[2].(map[string]any)["depth"]; depth != 2.0 {
+		t.Fatalf("nested depth = %v, want 2", depth)
+	}
+
+	// The tree pages through top-level comments with nested replies.
+	r = api.do(t, http.MethodGet, "/api/comments/article/"+article+"/tree?limit=1", "", nil)
+	ids, next, _ := pageIDs(t, r)
+	if !slices.Equal(ids, []string{root}) || next == "" {
+		t.Fatalf("tree page = %v (next %q), want the root comment", ids, next)
+	}
+	top := r.body["data"].([]any)[0].(map[string]any)
+	replies := top["replies"].([]any)
+	if top["reply_count"] != 1.0 || len(replies) != 1 || replies[0].(map[string]any)["id"] != reply {
+		t.Fatalf("root = %v, want one reply", top)
+	}
+	if deeper := replies[0].(map[string]any)["replies"].([]any); len(deeper) != 1 || deeper[0].(map[string]any)["id"] != nested {
+		t.Fatalf("reply = %v, want the nested reply", replies[0])
+	}
+	ids, _, _ = pageIDs(t, api.do(t, http.MethodGet, "/api/comments/article/"+article+"/tree?limit=1&after="+next, "", nil))
+	if !slices.Equal(ids, []string{other}) {
+		t.Fatalf("second tree page = %v, want %v", ids, []string{other})
+	}
+
+	// A deleted comment with replies stays as a placeholder, and cannot be
+	// replied to.
+	expect(t, api.do(t, http.MethodDelete, "/api/comments/"+reply, author, nil), http.StatusOK, "")
+	r = api.do(t, http.MethodGet, "/api/comments/article/"+article, "", nil)
+	placeholder := r.body["data"].([]any)[1].(map[string]any)
+	if placeholder["id"] != reply || placeholder["deleted"] != true || placeholder["content"] != models.DeletedCommentContent || placeholder["user_id"] != "" {
+		t.Fatalf("deleted comment = %v, want a placeholder", placeholder)
+	}
+	r = api.do(t, http.MethodPost, "/api/comments", reader, map[string]any{"article_id": article, "parent_id": reply, "content": "Late"})
+	expect(t, r, http.StatusNotFound, "parent_comment_not_found")
+
+	// Deleting its last reply removes the placeholder too.
+	expect(t, api.do(t, http.MethodDelete, "/api/comments/"+nested, reader, nil), http.StatusOK, "")
+	ids, _, _ = pageIDs(t, api.do(t, http.MethodGet, "/api/comments/article/"+article, "", nil))
+	if !slices.Equal(ids, []string{root, other}) {
+		t.Fatalf("comments after deletes = %v, want %v", ids, []string{root, other})
+	}
+}
+
+func TestCommentReplyOnAnotherArticle(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	first, second := api.createArticle(t, author, "First"), api.createArticle(t, author, "Second")
+	r := api.do(t, http.MethodPost, "/api/comments", author, map[string]any{"article_id": first, "content": "Hi"})
+	expect(t, r, http.StatusCreated, "")
+
+	r = api.do(t, http.MethodPost, "/api/comments", author, map[string]any{"article_id": second, "parent_id": r.str("id"), "content": "Hi"})
+	expect(t, r, http.StatusNotFound, "parent_comment_not_found")
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import "blog-api/apperr"
+
+// Errors returned by several handlers. Their codes are part of the API:
+// clients switch on them, so they never change.
+var (
+	errUnauthenticated  = apperr.Unauthorized("unauthenticated", "Authentication required")
+	errPermissionDenied = apperr.Forbidden("permission_denied", "Your role does not allow this action")
+	errInvalidBody      = apperr.BadRequest("invalid_body", "Invalid request body")
+	errInvalidTag       = apperr.BadRequest("invalid_tag", "Invalid tag")
+	errArticleNotFound  = apperr.NotFound("article_not_found", "Article not found")
+	errCommentNotFound  = apperr.NotFound("comment_not_found", "Comment not found")
+	errUserNotFound     = apperr.NotFound("user_not_found", "User not found")
+	errMediaNotFound    = apperr.NotFound("media_not_found", "Media not found")
+	errSlugConflict     = apperr.Conflict("slug_conflict", "Another article took the slug at the same time, try again")
+)
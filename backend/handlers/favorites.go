@@ -1,103 +1,86 @@
 package handlers
 
 import (
-	"blog-api/db"
+	"blog-api/apperr"
 	"blog-api/models"
-	"database/sql"
+	"blog-api/store"
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
-// GET /api/favorites/user/:id
-func GetUserFavorites(c *fiber.Ctx) error {
-	userID := c.Params("id")
-
-	rows, err := db.DB.Query(`
-		SELECT f.id, f.user_id, f.article_id, f.created_at,
-		       a.id, a.user_id, a.content, a.likes, a.created_at,
-		       u.email, u.firstname, u.lastname, u.created_at
-		FROM favorites f
-		LEFT JOIN articles a ON f.article_id = a.id
-		LEFT JOIN users u ON a.user_id = u.id
-		WHERE f.user_id = $1
-		ORDER BY f.created_at DESC
-	`, userID)
+// GET /api/favorites/user/:id?limit=&before=&after=
+func (h *Handler) GetUserFavorites(c *fiber.Ctx) error {
+	page, err := parsePage(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := idParam(c, "id")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Database error: " + err.Error()})
+		return err
 	}
-	defer rows.Close()
-
-	var favorites []models.Favorite
-	for rows.Next() {
-		var fav models.Favorite
-		var article models.Article
-		var author models.Profile
-		var firstName, lastName sql.NullString
-
-		err := rows.Scan(
-			&fav.ID,
-			&fav.ProfileID, // f.user_id
-			&fav.ArticleID,
-			&fav.CreatedAt,
-			&article.ID,
-			&article.ProfileID, // a.user_id
-			&article.Content,
-			&article.Likes,
-			&article.CreatedAt,
-			&author.Email,
-			&firstName,
-			&lastName,
-			&author.CreatedAt,
-		)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Scan error: " + err.Error()})
-		}
-
-		author.FirstName = firstName.String
-		author.LastName = lastName.String
-		article.Author = &author
-		fav.Article = &article
-		favorites = append(favorites, fav)
+
+	favorites, err := h.favorites.ListByUser(c.UserContext(), id, page)
+	if err != nil {
+		return apperr.Internal(err)
 	}
 
-	return c.JSON(favorites)
+	return c.JSON(newPageResponse(favorites))
 }
 
 // POST /api/favorites
-func AddFavorite(c *fiber.Ctx) error {
-	fav := new(models.Favorite)
-	if err := c.BodyParser(fav); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+func (h *Handler) AddFavorite(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
 	}
 
-	fav.ID = uuid.New().String()
+	var req favoriteRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
 
-	_, err := db.DB.Exec(`
-		INSERT INTO favorites (id, user_id, article_id)
-		VALUES ($1, $2, $3)
-	`, fav.ID, fav.ProfileID, fav.ArticleID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not add favorite: " + err.Error()})
+	if _, err := h.visibleArticle(c, req.ArticleID); err != nil {
+		return err
+	}
+
+	fav := &models.Favorite{
+		ID:        uuid.New().String(),
+		ProfileID: userID,
+		ArticleID: req.ArticleID,
+	}
+
+	err = h.favorites.Add(c.UserContext(), fav)
+	if errors.Is(err, store.ErrConflict) {
+		return apperr.Conflict("already_favorited", "Article already in favorites")
+	} else if errors.Is(err, store.ErrNotFound) {
+		return errArticleNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
 	}
 
 	return c.Status(201).JSON(fav)
 }
 
 // DELETE /api/favorites/:id
-func RemoveFavorite(c *fiber.Ctx) error {
-	id := c.Params("id")
+func (h *Handler) RemoveFavorite(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
+	}
 
-	result, err := db.DB.Exec(`
-		DELETE FROM favorites
-		WHERE id = $1
-	`, id)
+	id, err := idParam(c, "id")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not remove favorite: " + err.Error()})
+		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "Favorite not found or unauthorized"})
+	err = h.favorites.Remove(c.UserContext(), id, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.NotFound("favorite_not_found", "Favorite not found")
+	} else if err != nil {
+		return apperr.Internal(err)
 	}
 
 	return c.JSON(fiber.Map{"message": "Favorite removed successfully"})
@@ -0,0 +1,78 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestFavorites(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	reader := api.addUser(t, models.RoleAuthor)
+	article := api.createArticle(t, author, "Favorite")
+
+	r := api.do(t, http.MethodPost, "/api/favorites", reader, map[string]any{"article_id": article})
+	expect(t, r, http.StatusCreated, "")
+	favorite := r.str("id")
+	expect(t, api.do(t, http.MethodPost, "/api/favorites", reader, map[string]any{"article_id": article}), http.StatusConflict, "already_favorited")
+
+	r = api.do(t, http.MethodGet, "/api/favorites/user/"+reader, "", nil)
+	if ids, _, _ := pageIDs(t, r); !slices.Equal(ids, []string{favorite}) {
+		t.Fatalf("favorites = %v, want %v", ids, []string{favorite})
+	}
+	if a, _ := r.body["data"].([]any)[0].(map[string]any)["article"].(map[string]any); a["title"] != "Favorite" {
+		t.Fatalf("favorite article = %v", a)
+	}
+
+	expect(t, api.do(t, http.MethodDelete, "/api/favorites/"+favorite, author, nil), http.StatusNotFound, "favorite_not_found")
+	expect(t, api.do(t, http.MethodDelete, "/api/favorites/"+favorite, reader, nil), http.StatusOK, "")
+	expect(t, api.do(t, http.MethodDelete, "/api/favorites/"+favorite, reader, nil), http.StatusNotFound, "favorite_not_found")
+}
+
+func TestFavoriteErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	reader := api.addUser(t, models.RoleAuthor)
+
+	tests := []struct {
+		name         string
+		method, path string
+		userID       string
+		body         any
+		status       int
+		code         string
+	}{
+		{"add anonymously", http.MethodPost, "/api/favorites", "", map[string]any{"article_id": uuid.NewString()}, http.StatusUnauthorized, "unauthenticated"},
+		{"add an unknown article", http.MethodPost, "/api/favorites", reader, map[string]any{"article_id": uuid.NewString()}, http.StatusNotFound, "article_not_found"},
+		{"remove anonymously", http.MethodDelete, "/api/favorites/" + uuid.NewString(), "", nil, http.StatusUnauthorized, "unauthenticated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect(t, api.do(t, tt.method, tt.path, tt.userID, tt.body), tt.status, tt.code)
+		})
+	}
+}
+
+func TestFavoritePagination(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	reader := api.addUser(t, models.RoleAuthor)
+	var created []string
+	for _, title := range []string{"One", "Two", "Three"} {
+		r := api.do(t, http.MethodPost, "/api/favorites", reader, map[string]any{"article_id": api.createArticle(t, author, title)})
+		expect(t, r, http.StatusCreated, "")
+		created = append(created, r.str("id"))
+	}
+	slices.Reverse(created) // newest first
+
+	base := "/api/favorites/user/" + reader + "?limit=2"
+	first, next, _ := pageIDs(t, api.do(t, http.MethodGet, base, "", nil))
+	second, last, _ := pageIDs(t, api.do(t, http.MethodGet, base+"&after="+next, "", nil))
+	if got := slices.Concat(first, second); !slices.Equal(got, created) || last != "" {
+		t.Fatalf("pages = %v %v (next %q), want %v", first, second, last, created)
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"blog-api/apperr"
+	"blog-api/models"
+	"blog-api/store"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// feedResponse is a page of the feed with where its articles come from:
+// "following" or "trending".
+type feedResponse struct {
+	pageResponse
+	Source string `json:"source"`
+}
+
+// GET /api/feed?limit=&before=&after=&fallback=trending
+//
+// Published articles by the caller and the users they follow, newest first.
+// With fallback=trending, a caller who follows nobody gets the hot articles
+// instead.
+func (h *Handler) GetFeed(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
+	}
+	page, err := parsePage(c)
+	if err != nil {
+		return err
+	}
+
+	switch c.Query("fallback") {
+	case "":
+	case "trending":
+		following, err := h.followers.Following(c.UserContext(), userID, store.PageRequest{Limit: 1})
+		if err != nil {
+			return apperr.Internal(err)
+		}
+		if len(following.Items) == 0 {
+			articles, err := h.articles.List(c.UserContext(), store.ArticleFilter{
+				Statuses: []string{models.ArticlePublished},
+				Sort:     store.SortHot,
+			}, page)
+			if err != nil {
+				return apperr.Internal(err)
+			}
+			return c.JSON(feedResponse{newPageResponse(articles), "trending"})
+		}
+	default:
+		return apperr.BadRequest("invalid_fallback", "fallback must be trending")
+	}
+
+	articles, err := h.articles.List(c.UserContext(), store.ArticleFilter{
+		FeedOf:   userID,
+		Statuses: []string{models.ArticlePublished},
+	}, page)
+	if err != nil {
+		return apperr.Internal(err)
+	}
+	return c.JSON(feedResponse{newPageResponse(articles), "following"})
+}
@@ -0,0 +1,50 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestFeed(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	me, followed, stranger := api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor)
+
+	mine := api.createArticle(t, me, "Mine")
+	theirs := api.createArticle(t, followed, "Theirs")
+	popular := api.createArticle(t, stranger, "Popular")
+	r := api.do(t, http.MethodPost, "/api/articles", followed, map[string]any{"title": "Draft", "content": "x", "status": "draft"})
+	expect(t, r, http.StatusCreated, "")
+	expect(t, api.do(t, http.MethodPost, "/api/likes", me, map[string]any{"article_id": popular}), http.StatusOK, "")
+
+	// Following nobody: own articles, or trending with the fallback.
+	r = api.do(t, http.MethodGet, "/api/feed", me, nil)
+	if ids, _, _ := pageIDs(t, r); !slices.Equal(ids, []string{mine}) || r.str("source") != "following" {
+		t.Fatalf("feed = %v from %q, want %v from following", ids, r.str("source"), []string{mine})
+	}
+	r = api.do(t, http.MethodGet, "/api/feed?fallback=trending", me, nil)
+	if ids, _, _ := pageIDs(t, r); !slices.Equal(ids, []string{popular, theirs, mine}) || r.str("source") != "trending" {
+		t.Fatalf("trending feed = %v from %q, want %v", ids, r.str("source"), []string{popular, theirs, mine})
+	}
+
+	// Following someone adds their published articles and disables the
+	// fallback.
+	expect(t, api.do(t, http.MethodPost, "/api/followers", me, map[string]any{"following_id": followed}), http.StatusCreated, "")
+	for _, path := range []string{"/api/feed", "/api/feed?fallback=trending"} {
+		r = api.do(t, http.MethodGet, path, me, nil)
+		if ids, _, _ := pageIDs(t, r); !slices.Equal(ids, []string{theirs, mine}) || r.str("source") != "following" {
+			t.Fatalf("%s = %v from %q, want %v from following", path, ids, r.str("source"), []string{theirs, mine})
+		}
+	}
+}
+
+func TestFeedErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	me := api.addUser(t, models.RoleAuthor)
+
+	expect(t, api.do(t, http.MethodGet, "/api/feed", "", nil), http.StatusUnauthorized, "unauthenticated")
+	expect(t, api.do(t, http.MethodGet, "/api/feed?fallback=random", me, nil), http.StatusBadRequest, "invalid_fallback")
+	expect(t, api.do(t, http.MethodGet, "/api/feed?limit=0", me, nil), http.StatusBadRequest, "invalid_limit")
+}
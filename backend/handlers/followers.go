@@ -1,142 +1,110 @@
 package handlers
 
 import (
-	"blog-api/db"
+	"blog-api/apperr"
 	"blog-api/models"
-	"database/sql"
+	"blog-api/notify"
+	"blog-api/store"
+	"blog-api/validate"
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
-// GET /api/followers/:userId
-func GetUserFollowers(c *fiber.Ctx) error {
-	userID := c.Params("userId")
-
-	rows, err := db.DB.Query(`
-		SELECT f.id, f.follower_id, f.following_id, f.created_at,
-		       u.email, u.firstname, u.lastname, u.created_at
-		FROM followers f
-		LEFT JOIN users u ON f.follower_id = u.id
-		WHERE f.following_id = $1
-		ORDER BY f.created_at DESC
-	`, userID)
+// GET /api/followers/:userId?limit=&before=&after=
+func (h *Handler) GetUserFollowers(c *fiber.Ctx) error {
+	page, err := parsePage(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := idParam(c, "userId")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Database error: " + err.Error()})
+		return err
 	}
-	defer rows.Close()
-
-	var followers []models.Follower
-	for rows.Next() {
-		var follower models.Follower
-		var profile models.Profile
-		var firstName, lastName sql.NullString
-
-		err := rows.Scan(
-			&follower.ID,
-			&follower.FollowerID,
-			&follower.FollowingID,
-			&follower.CreatedAt,
-			&profile.Email,
-			&firstName,
-			&lastName,
-			&profile.CreatedAt,
-		)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Scan error: " + err.Error()})
-		}
-
-		profile.FirstName = firstName.String
-		profile.LastName = lastName.String
-		follower.Follower = &profile
-		followers = append(followers, follower)
+
+	followers, err := h.followers.Followers(c.UserContext(), userID, page)
+	if err != nil {
+		return apperr.Internal(err)
 	}
 
-	return c.JSON(followers)
+	return c.JSON(newPageResponse(followers))
 }
 
-// GET /api/following/:userId
-func GetUserFollowing(c *fiber.Ctx) error {
-	userID := c.Params("userId")
-
-	rows, err := db.DB.Query(`
-		SELECT f.id, f.follower_id, f.following_id, f.created_at,
-		       u.email, u.firstname, u.lastname, u.created_at
-		FROM followers f
-		LEFT JOIN users u ON f.following_id = u.id
-		WHERE f.follower_id = $1
-		ORDER BY f.created_at DESC
-	`, userID)
+// GET /api/followers/following/:userId?limit=&before=&after=
+func (h *Handler) GetUserFollowing(c *fiber.Ctx) error {
+	page, err := parsePage(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Database error: " + err.Error()})
+		return err
 	}
-	defer rows.Close()
-
-	var following []models.Follower
-	for rows.Next() {
-		var follow models.Follower
-		var profile models.Profile
-		var firstName, lastName sql.NullString
-
-		err := rows.Scan(
-			&follow.ID,
-			&follow.FollowerID,
-			&follow.FollowingID,
-			&follow.CreatedAt,
-			&profile.Email,
-			&firstName,
-			&lastName,
-			&profile.CreatedAt,
-		)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Scan error: " + err.Error()})
-		}
-
-		profile.FirstName = firstName.String
-		profile.LastName = lastName.String
-		follow.Following = &profile
-		following = append(following, follow)
+
+	userID, err := idParam(c, "userId")
+	if err != nil {
+		return err
+	}
+
+	following, err := h.followers.Following(c.UserContext(), userID, page)
+	if err != nil {
+		return apperr.Internal(err)
 	}
 
-	return c.JSON(following)
+	return c.JSON(newPageResponse(following))
 }
 
 // POST /api/followers
-func Follow(c *fiber.Ctx) error {
-	follower := new(models.Follower)
-	if err := c.BodyParser(follower); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+func (h *Handler) Follow(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
 	}
 
-	follower.ID = uuid.New().String()
+	req := followRequest{FollowerID: userID}
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
 
-	_, err := db.DB.Exec(`
-		INSERT INTO followers (id, follower_id, following_id)
-		VALUES ($1, $2, $3)
-	`, follower.ID, follower.FollowerID, follower.FollowingID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not follow user: " + err.Error()})
+	follower := &models.Follower{
+		ID:          uuid.New().String(),
+		FollowerID:  userID,
+		FollowingID: req.FollowingID,
 	}
 
+	err = h.followers.Follow(c.UserContext(), follower)
+	if errors.Is(err, store.ErrConflict) {
+		return apperr.Conflict("already_following", "Already following this user")
+	} else if errors.Is(err, store.ErrNotFound) {
+		return errUserNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
+	}
+
+	h.notify(c.UserContext(), notify.Event{
+		Kind:        models.NotificationFollow,
+		RecipientID: follower.FollowingID,
+		ActorID:     userID,
+	})
 	return c.Status(201).JSON(follower)
 }
 
-// DELETE /api/followers/:id
-func Unfollow(c *fiber.Ctx) error {
-	followerID := c.Query("follower_id")
-	followingID := c.Query("following_id")
-
-	result, err := db.DB.Exec(`
-		DELETE FROM followers
-		WHERE follower_id = $1 AND following_id = $2
-	`, followerID, followingID)
+// DELETE /api/followers?following_id=xxx
+func (h *Handler) Unfollow(c *fiber.Ctx) error {
+	followerID, err := requireUser(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not unfollow user: " + err.Error()})
+		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "Follow relationship not found"})
+	followingID := c.Query("following_id")
+	if err := validate.Var("following_id", followingID, "required,uuid"); err != nil {
+		return err
+	}
+
+	err = h.followers.Unfollow(c.UserContext(), followerID, followingID)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.NotFound("follow_not_found", "Follow relationship not found")
+	} else if err != nil {
+		return apperr.Internal(err)
 	}
 
 	return c.JSON(fiber.Map{"message": "Unfollowed successfully"})
-}
\ No newline at end of file
+}
@@ -0,0 +1,67 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestFollowers(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	alice := api.addUser(t, models.RoleAuthor)
+	bob := api.addUser(t, models.RoleAuthor)
+	carol := api.addUser(t, models.RoleAuthor)
+
+	expect(t, api.do(t, http.MethodPost, "/api/followers", bob, map[string]any{"following_id": alice}), http.StatusCreated, "")
+	expect(t, api.do(t, http.MethodPost, "/api/followers", carol, map[string]any{"following_id": alice}), http.StatusCreated, "")
+	expect(t, api.do(t, http.MethodPost, "/api/followers", bob, map[string]any{"following_id": alice}), http.StatusConflict, "already_following")
+	expect(t, api.do(t, http.MethodPost, "/api/followers", "", map[string]any{"following_id": alice}), http.StatusUnauthorized, "unauthenticated")
+
+	followers := func(r response) []string {
+		t.Helper()
+		expect(t, r, http.StatusOK, "")
+		var ids []string
+		for _, item := range r.body["data"].([]any) {
+			ids = append(ids, item.(map[string]any)["follower_id"].(string))
+		}
+		return ids
+	}
+	if got := followers(api.do(t, http.MethodGet, "/api/followers/"+alice, "", nil)); !slices.Equal(got, []string{carol, bob}) {
+		t.Fatalf("followers = %v, want %v newest first", got, []string{carol, bob})
+	}
+	r := api.do(t, http.MethodGet, "/api/followers/following/"+bob, "", nil)
+	if expect(t, r, http.StatusOK, ""); len(r.body["data"].([]any)) != 1 || r.body["data"].([]any)[0].(map[string]any)["following_id"] != alice {
+		t.Fatalf("following = %v, want alice", r.body)
+	}
+
+	expect(t, api.do(t, http.MethodDelete, "/api/followers?following_id="+alice, bob, nil), http.StatusOK, "")
+	expect(t, api.do(t, http.MethodDelete, "/api/followers?following_id="+alice, bob, nil), http.StatusNotFound, "follow_not_found")
+	if got := followers(api.do(t, http.MethodGet, "/api/followers/"+alice, "", nil)); !slices.Equal(got, []string{carol}) {
+		t.Fatalf("followers = %v, want %v", got, []string{carol})
+	}
+
+	// Followers come in pages too.
+	for range 2 {
+		expect(t, api.do(t, http.MethodPost, "/api/followers", api.addUser(t, models.RoleAuthor), map[string]any{"following_id": alice}), http.StatusCreated, "")
+	}
+	r = api.do(t, http.MethodGet, "/api/followers/"+alice+"?limit=2", "", nil)
+	next, _ := r.body["next_cursor"].(string)
+	if got := followers(r); len(got) != 2 || next == "" {
+		t.Fatalf("first page = %v (next %q), want 2 followers and a next cursor", got, next)
+	}
+	if got := followers(api.do(t, http.MethodGet, "/api/followers/"+alice+"?limit=2&after="+next, "", nil)); !slices.Equal(got, []string{carol}) {
+		t.Fatalf("second page = %v, want %v", got, []string{carol})
+	}
+}
+
+func TestFollowUnknownUser(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	user := api.addUser(t, models.RoleReader)
+	expect(t, api.do(t, http.MethodPost, "/api/followers", user, map[string]any{"following_id": uuid.NewString()}), http.StatusNotFound, "user_not_found")
+	// The follower needs a profile too.
+	expect(t, api.do(t, http.MethodPost, "/api/followers", uuid.NewString(), map[string]any{"following_id": user}), http.StatusNotFound, "user_not_found")
+}
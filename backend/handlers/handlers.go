@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"blog-api/idempotency"
+	"blog-api/media"
+	"blog-api/notify"
+	"blog-api/policy"
+	"blog-api/ratelimit"
+	"blog-api/realtime"
+	"blog-api/store"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultMaxCommentDepth is used when Options.MaxCommentDepth is zero.
+const DefaultMaxCommentDepth = 5
+
+// Options tunes the API behaviour.
+type Options struct {
+	// MaxCommentDepth is the deepest reply level allowed; top-level comments
+	// are at depth 0.
+	MaxCommentDepth int
+	// Hub holds the live event subscriptions of this instance, and
+	// Broadcaster publishes events to the hubs of every instance. They
+	// default to a new hub with a realtime.Local broadcaster.
+	Hub         *realtime.Hub
+	Broadcaster realtime.Broadcaster
+	// MediaStorage keeps uploaded images; uploads are refused without it.
+	// MaxUploadSize caps their size in bytes and defaults to
+	// DefaultMaxUploadSize.
+	MediaStorage  media.Storage
+	MaxUploadSize int64
+	// RateLimiter keeps the token buckets of the rate-limited routes;
+	// nothing is limited without it. RateLimits overrides entries of
+	// DefaultRateLimits.
+	RateLimiter ratelimit.Store
+	RateLimits  map[string]ratelimit.Policy
+	// Idempotency keeps the responses of create requests sent with an
+	// Idempotency-Key header, for IdempotencyExpiry (which defaults to
+	// idempotency.DefaultExpiry); the header is ignored without it.
+	Idempotency       idempotency.Store
+	IdempotencyExpiry time.Duration
+	// SiteURL is the public address of the blog, without a trailing slash.
+	// Feeds link articles to SiteURL/articles/<slug>, or to the API when it
+	// is empty.
+	SiteURL string
+}
+
+// Handler serves the API endpoints on top of the injected stores.
+type Handler struct {
+	articles  store.ArticleStore
+	comments  store.CommentStore
+	users     store.UserStore
+	favorites store.FavoriteStore
+	followers store.FollowerStore
+	likes     store.LikeStore
+	search    store.SearchStore
+	tags      store.TagStore
+	media     store.MediaStore
+
+	notifications store.NotificationStore
+	notifier      *notify.Service
+
+	hub    *realtime.Hub
+	events realtime.Broadcaster
+
+	mediaStorage  media.Storage
+	maxUploadSize int64
+
+	rateLimiter ratelimit.Store
+	rateLimits  map[string]ratelimit.Policy
+
+	// idempotent is the middleware of the create routes.
+	idempotent fiber.Handler
+
+	maxCommentDepth int
+	siteURL         string
+}
+
+func New(s store.Stores, opts Options) *Handler {
+	if opts.MaxCommentDepth == 0 {
+		opts.MaxCommentDepth = DefaultMaxCommentDepth
+	}
+	if opts.MaxUploadSize == 0 {
+		opts.MaxUploadSize = DefaultMaxUploadSize
+	}
+	if opts.Hub == nil {
+		opts.Hub = realtime.NewHub()
+	}
+	if opts.Broadcaster == nil {
+		opts.Broadcaster = realtime.NewLocal(opts.Hub)
+	}
+	idempotent := func(c *fiber.Ctx) error { return c.Next() }
+	if opts.Idempotency != nil {
+		idempotent = idempotency.New(opts.Idempotency, idempotency.Config{Expiry: opts.IdempotencyExpiry})
+	}
+	rateLimits := make(map[string]ratelimit.Policy)
+	for name, p := range DefaultRateLimits {
+		rateLimits[name] = p
+	}
+	for name, p := range opts.RateLimits {
+		rateLimits[name] = p
+	}
+	return &Handler{
+		articles:  s.Articles,
+		comments:  s.Comments,
+		users:     s.Users,
+		favorites: s.Favorites,
+		followers: s.Followers,
+		likes:     s.Likes,
+		search:    s.Search,
+		tags:      s.Tags,
+		media:     s.Media,
+
+		notifications: s.Notifications,
+		notifier:      notify.NewService(s.Notifications),
+
+		hub:    opts.Hub,
+		events: opts.Broadcaster,
+
+		mediaStorage:  opts.MediaStorage,
+		maxUploadSize: opts.MaxUploadSize,
+
+		rateLimiter: opts.RateLimiter,
+		rateLimits:  rateLimits,
+
+		idempotent: idempotent,
+
+		maxCommentDepth: opts.MaxCommentDepth,
+		siteURL:         strings.TrimSuffix(opts.SiteURL, "/"),
+	}
+}
+
+// Register mounts every API route on the given router, typically the /api group.
+func (h *Handler) Register(api fiber.Router) {
+	// Users routes
+	users := api.Group("/users")
+	users.Post("/", h.limit("users"), h.idempotent, h.CreateUser)
+	users.Get("/:id", h.GetUser)
+	users.Put("/:id", h.UpdateUser)
+
+	// Articles routes
+	articles := api.Group("/articles")
+	articles.Get("/", h.GetArticles)
+	articles.Get("/by-slug/:slug", h.GetArticleBySlug)
+	articles.Get("/:id", h.GetArticle)
+	articles.Post("/", h.limit("articles"), h.RequirePermission(policy.WriteArticles), h.idempotent, h.CreateArticle)
+	articles.Put("/:id", h.limit("articles"), h.RequirePermission(policy.WriteArticles), h.UpdateArticle)
+	articles.Delete("/:id", h.DeleteArticle)
+	articles.Post("/:id/hide", h.RequirePermission(policy.ModerateArticles), h.HideArticle)
+	articles.Post("/:id/unhide", h.RequirePermission(policy.ModerateArticles), h.UnhideArticle)
+	articles.Get("/:id/revisions", h.GetArticleRevisions)
+	articles.Get("/:id/revisions/diff", h.DiffArticleRevisions)
+	articles.Post("/:id/revisions/:rev/restore", h.limit("articles"), h.RequirePermission(policy.WriteArticles), h.idempotent, h.RestoreArticleRevision)
+
+	// Comments routes
+	comments := api.Group("/comments")
+	comments.Get("/article/:id", h.GetArticleComments)
+	comments.Get("/article/:id/tree", h.GetArticleCommentTree)
+	comments.Post("/", h.limit("comments"), h.RequirePermission(policy.WriteComments), h.idempotent, h.CreateComment)
+	comments.Put("/:id", h.limit("comments"), h.UpdateComment)
+	comments.Delete("/:id", h.limit("comments"), h.DeleteComment)
+
+	// Favorites routes
+	favorites := api.Group("/favorites")
+	favorites.Get("/user/:id", h.GetUserFavorites)
+	favorites.Post("/", h.limit("favorites"), h.idempotent, h.AddFavorite)
+	favorites.Delete("/:id", h.limit("favorites"), h.RemoveFavorite)
+
+	// Followers routes
+	followers := api.Group("/followers")
+	followers.Get("/:userId", h.GetUserFollowers)
+	followers.Get("/following/:userId", h.GetUserFollowing)
+	followers.Post("/", h.limit("follows"), h.idempotent, h.Follow)
+	followers.Delete("/", h.limit("follows"), h.Unfollow)
+
+	// Likes routes
+	likes := api.Group("/likes")
+	likes.Get("/status", h.GetLikeStatus)
+	likes.Get("/count/:id", h.GetLikesCount)
+	likes.Post("/", h.limit("likes"), h.idempotent, h.AddLike)
+	likes.Delete("/", h.limit("likes"), h.RemoveLike)
+
+	// Notifications routes
+	notifications := api.Group("/notifications")
+	notifications.Get("/", h.GetNotifications)
+	notifications.Get("/unread-count", h.GetUnreadNotificationCount)
+	notifications.Post("/read-all", h.MarkAllNotificationsRead)
+	notifications.Post("/:id/read", h.MarkNotificationRead)
+	notifications.Get("/preferences", h.GetNotificationPreferences)
+	notifications.Put("/preferences", h.UpdateNotificationPreferences)
+
+	// Live events
+	api.Get("/stream", h.Stream)
+
+	// Feed
+	api.Get("/feed", h.GetFeed)
+
+	// Tags routes
+	tags := api.Group("/tags")
+	tags.Get("/", h.GetTags)
+	tags.Get("/:name/articles", h.GetTagArticles)
+
+	// Search
+	api.Get("/search", h.limit("search"), h.Search)
+
+	// Media routes
+	mediaGroup := api.Group("/media")
+	mediaGroup.Post("/", h.limit("media"), h.idempotent, h.UploadMedia)
+	mediaGroup.Get("/:id", h.GetMedia)
+	mediaGroup.Get("/:id/:variant", h.GetMediaFile)
+	mediaGroup.Delete("/:id", h.DeleteMedia)
+
+	// Admin routes
+	admin := api.Group("/admin", h.RequirePermission(policy.ManageRoles))
+	admin.Get("/users", h.GetAdminUsers)
+	admin.Put("/users/:id/role", h.SetUserRole)
+}
+
+// RegisterFeeds mounts the public RSS, Atom and JSON feeds on the given
+// router, typically the /feeds group outside /api.
+func (h *Handler) RegisterFeeds(feeds fiber.Router) {
+	feeds.Get("/articles.:format", h.GetArticlesFeed)
+	feeds.Get("/authors/:id/articles.:format", h.GetAuthorFeed)
+	feeds.Get("/tags/:name/articles.:format", h.GetTagFeed)
+}
@@ -0,0 +1,175 @@
+package handlers_test
+
+import (
+	"blog-api/apperr"
+	"blog-api/handlers"
+	"blog-api/middleware"
+	"blog-api/models"
+	"blog-api/store"
+	"blog-api/store/memory"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var testSecret = []byte("test-secret")
+
+// testAPI serves the API over in-memory stores, as main does over
+// PostgreSQL.
+type testAPI struct {
+	app    *fiber.App
+	stores store.Stores
+}
+
+func newTestAPI(t *testing.T, opts handlers.Options) *testAPI {
+	t.Helper()
+	return newTestAPIOver(t, memory.New(), opts)
+}
+
+// newTestAPIOver is newTestAPI over stores, which tests may wrap to
+// simulate failures.
+func newTestAPIOver(t *testing.T, stores store.Stores, opts handlers.Options) *testAPI {
+	t.Helper()
+	verifier, err := middleware.NewVerifier(middleware.AuthConfig{HS256Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: apperr.Handler})
+	h := handlers.New(stores, opts)
+	h.Register(app.Group("/api", middleware.Auth(verifier)))
+	h.RegisterFeeds(app.Group("/feeds"))
+	return &testAPI{app: app, stores: stores}
+}
+
+// addUser creates a user with role and returns their ID.
+func (a *testAPI) addUser(t *testing.T, role string) string {
+	t.Helper()
+	id := uuid.New().String()
+	user := &models.User{ID: id, Email: id + "@example.com", Role: role}
+	if err := a.stores.Users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// response is a decoded JSON response: an object in body or an array in
+// list.
+type response struct {
+	status int
+	body   map[string]any
+	list   []any
+}
+
+// code returns the error code of a problem response.
+func (r response) code() string {
+	code, _ := r.body["code"].(string)
+	return code
+}
+
+// str returns the string field of the body.
+func (r response) str(field string) string {
+	s, _ := r.body[field].(string)
+	return s
+}
+
+// do sends a request as userID, anonymously when it is empty, with body
+// encoded as JSON unless nil.
+func (a *testAPI) do(t *testing.T, method, path, userID string, body any) response {
+	t.Helper()
+	r, _ := a.doWithHeader(t, method, path, userID, nil, body)
+	return r
+}
+
+// doWithHeader is do with extra request headers, returning the response
+// headers too.
+func (a *testAPI) doWithHeader(t *testing.T, method, path, userID string, header http.Header, body any) (response, http.Header) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	if userID != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token(t, userID))
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := a.app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	r := response{status: resp.StatusCode}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := any(&r.body)
+	if bytes.HasPrefix(data, []byte("[")) {
+		target = &r.list
+	}
+	if len(data) > 0 && json.Unmarshal(data, target) != nil {
+		t.Fatalf("%s %s: response is not JSON: %s", method, path, data)
+	}
+	return r, resp.Header
+}
+
+// expect fails the test unless r has status, and code when not empty.
+func expect(t *testing.T, r response, status int, code string) {
+	t.Helper()
+	if r.status != status || (code != "" && r.code() != code) {
+		t.Fatalf("got %d %q, want %d %q: %v", r.status, r.code(), status, code, r.body)
+	}
+}
+
+// createArticle creates an article of userID and returns its ID.
+func (a *testAPI) createArticle(t *testing.T, userID, title string) string {
+	t.Helper()
+	r := a.do(t, http.MethodPost, "/api/articles", userID, map[string]any{"title": title, "content": "Some content."})
+	expect(t, r, http.StatusCreated, "")
+	return r.str("id")
+}
+
+func token(t *testing.T, userID string) string {
+	t.Helper()
+	claims := jwt.MapClaims{"sub": userID, "exp": time.Now().Add(time.Hour).Unix()}
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// pageIDs returns the IDs of a page response with its cursors.
+func pageIDs(t *testing.T, r response) (ids []string, next, prev string) {
+	t.Helper()
+	expect(t, r, http.StatusOK, "")
+	items, ok := r.body["data"].([]any)
+	if !ok {
+		t.Fatalf("no data in %v", r.body)
+	}
+	for _, item := range items {
+		ids = append(ids, item.(map[string]any)["id"].(string))
+	}
+	next, _ = r.body["next_cursor"].(string)
+	prev, _ = r.body["prev_cursor"].(string)
+	return ids, next, prev
+}
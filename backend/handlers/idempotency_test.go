@@ -0,0 +1,83 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/idempotency"
+	"blog-api/models"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestIdempotentCreates(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{Idempotency: idempotency.NewMemory()})
+	author := api.addUser(t, models.RoleAuthor)
+	other := api.addUser(t, models.RoleAuthor)
+	key := http.Header{idempotency.Header: {"create-1"}}
+	body := map[string]any{"title": "Once", "content": "x"}
+
+	first, header := api.doWithHeader(t, http.MethodPost, "/api/articles", author, key, body)
+	expect(t, first, http.StatusCreated, "")
+	if header.Get(idempotency.ReplayedHeader) != "" {
+		t.Fatal("first response marked as replayed")
+	}
+	retry, header := api.doWithHeader(t, http.MethodPost, "/api/articles", author, key, body)
+	if expect(t, retry, http.StatusCreated, ""); retry.str("id") != first.str("id") || header.Get(idempotency.ReplayedHeader) != "true" {
+		t.Fatalf("retry = %v (%s), want the replayed %s", retry.body, header.Get(idempotency.ReplayedHeader), first.str("id"))
+	}
+
+	// Keys belong to their user, and the same key with another body is an
+	// error.
+	r, _ := api.doWithHeader(t, http.MethodPost, "/api/articles", other, key, body)
+	if expect(t, r, http.StatusCreated, ""); r.str("id") == first.str("id") {
+		t.Fatal("another user got the response of the key")
+	}
+	r, _ = api.doWithHeader(t, http.MethodPost, "/api/articles", author, key, map[string]any{"content": "y"})
+	expect(t, r, http.StatusUnprocessableEntity, "idempotency_key_reused")
+
+	// Without a key every request creates an article.
+	api.createArticle(t, author, "Once")
+	ids, _, _ := pageIDs(t, api.do(t, http.MethodGet, "/api/articles?author="+author, "", nil))
+	if len(ids) != 2 {
+		t.Fatalf("articles = %v, want 2", ids)
+	}
+}
+
+func TestIdempotencyOff(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	key := http.Header{idempotency.Header: {"create-1"}}
+	for range 2 {
+		r, header := api.doWithHeader(t, http.MethodPost, "/api/articles", author, key, map[string]any{"content": "x"})
+		if expect(t, r, http.StatusCreated, ""); header.Get(idempotency.ReplayedHeader) != "" {
+			t.Fatal("response replayed without an idempotency store")
+		}
+	}
+}
+
+func TestIdempotencyAfterErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{Idempotency: idempotency.NewMemory()})
+	reader := api.addUser(t, models.RoleReader)
+	admin := api.addUser(t, models.RoleAdmin)
+	key := http.Header{idempotency.Header: {"create-1"}}
+	body := map[string]any{"title": "Later", "content": "x"}
+
+	// A refused request does not take the key: once allowed, the same
+	// attempt creates the article.
+	r, _ := api.doWithHeader(t, http.MethodPost, "/api/articles", reader, key, body)
+	expect(t, r, http.StatusForbidden, "permission_denied")
+	expect(t, api.do(t, http.MethodPut, "/api/admin/users/"+reader+"/role", admin, map[string]any{"role": models.RoleAuthor}), http.StatusOK, "")
+	r, header := api.doWithHeader(t, http.MethodPost, "/api/articles", reader, key, body)
+	if expect(t, r, http.StatusCreated, ""); header.Get(idempotency.ReplayedHeader) != "" {
+		t.Fatal("created article marked as replayed")
+	}
+	article := r.str("id")
+
+	// Nor does a failed one, so the retry may fix its body.
+	key = http.Header{idempotency.Header: {"favorite-1"}}
+	r, _ = api.doWithHeader(t, http.MethodPost, "/api/favorites", reader, key, map[string]any{"article_id": uuid.NewString()})
+	expect(t, r, http.StatusNotFound, "article_not_found")
+	r, _ = api.doWithHeader(t, http.MethodPost, "/api/favorites", reader, key, map[string]any{"article_id": article})
+	expect(t, r, http.StatusCreated, "")
+}
@@ -1,157 +1,114 @@
 package handlers
 
 import (
-	"blog-api/db"
+	"blog-api/apperr"
+	"blog-api/models"
+	"blog-api/notify"
+	"blog-api/realtime"
+	"blog-api/store"
+	"context"
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 )
 
-func GetLikeStatus(c *fiber.Ctx) error {
-	articleID := c.Query("article_id")
-	userID := c.Query("user_id")
-
-	var exists bool
-	err := db.DB.QueryRow(`
-		SELECT EXISTS(
-			SELECT 1 FROM likes 
-			WHERE article_id = $1 AND user_id = $2
-		)
-	`, articleID, userID).Scan(&exists)
-
+func (h *Handler) GetLikeStatus(c *fiber.Ctx) error {
+	articleID, err := idQuery(c, "article_id")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+		return err
 	}
-
-	return c.JSON(fiber.Map{"liked": exists})
-}
-
-func GetLikesCount(c *fiber.Ctx) error {
-	articleID := c.Params("id")
-
-	var count int
-	err := db.DB.QueryRow(`
-		SELECT COUNT(*) FROM likes 
-		WHERE article_id = $1
-	`, articleID).Scan(&count)
-
+	userID, err := idQuery(c, "user_id")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+		return err
 	}
-
-	return c.JSON(fiber.Map{"count": count})
-}
-
-func AddLike(c *fiber.Ctx) error {
-	type LikeRequest struct {
-		ArticleID string `json:"article_id"`
-		UserID    string `json:"user_id"`
-	}
-
-	var req LikeRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	if articleID != "" {
+		if _, err := h.visibleArticle(c, articleID); err != nil {
+			return err
+		}
 	}
 
-	tx, err := db.DB.Begin()
+	exists, err := h.likes.Status(c.UserContext(), articleID, userID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not start transaction"})
+		return apperr.Internal(err)
 	}
-	defer tx.Rollback()
 
-	_, err = tx.Exec(`
-		INSERT INTO likes (article_id, user_id)
-		VALUES ($1, $2)
-		ON CONFLICT (user_id, article_id) DO NOTHING
-	`, req.ArticleID, req.UserID)
+	return c.JSON(fiber.Map{"liked": exists})
+}
 
+func (h *Handler) GetLikesCount(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not add like"})
+		return err
 	}
 
-	var count int
-	err = tx.QueryRow(`
-		SELECT COUNT(*) FROM likes 
-		WHERE article_id = $1
-	`, req.ArticleID).Scan(&count)
-
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not get like count"})
+	if _, err := h.visibleArticle(c, id); err != nil {
+		return err
 	}
 
-	_, err = tx.Exec(`
-		UPDATE articles 
-		SET likes = $1 
-		WHERE id = $2
-	`, count, req.ArticleID)
-
+	count, err := h.likes.Count(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not update article"})
-	}
-
-	if err = tx.Commit(); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not commit transaction"})
+		return apperr.Internal(err)
 	}
 
-	return c.JSON(fiber.Map{"likes": count})
+	return c.JSON(fiber.Map{"count": count})
 }
 
-func RemoveLike(c *fiber.Ctx) error {
-	type LikeRequest struct {
-		ArticleID string `json:"article_id"`
-		UserID    string `json:"user_id"`
-	}
-
-	var req LikeRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
-	}
-
-	tx, err := db.DB.Begin()
+func (h *Handler) AddLike(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not start transaction"})
+		return err
 	}
-	defer tx.Rollback()
 
-	result, err := tx.Exec(`
-		DELETE FROM likes 
-		WHERE article_id = $1 AND user_id = $2
-	`, req.ArticleID, req.UserID)
-
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not remove like"})
+	var req likeRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	article, err := h.visibleArticle(c, req.ArticleID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not get affected rows"})
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "Like not found"})
+	count, err := h.likes.Add(c.UserContext(), req.ArticleID, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return errArticleNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
 	}
 
-	var count int
-	err = tx.QueryRow(`
-		SELECT COUNT(*) FROM likes 
-		WHERE article_id = $1
-	`, req.ArticleID).Scan(&count)
+	h.publishLikes(c.UserContext(), req.ArticleID, count)
+	h.notify(c.UserContext(), notify.Event{
+		Kind:        models.NotificationLike,
+		RecipientID: article.UserID,
+		ActorID:     userID,
+		ArticleID:   article.ID,
+	})
+	return c.JSON(fiber.Map{"likes": count})
+}
 
+func (h *Handler) RemoveLike(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not get like count"})
+		return err
 	}
 
-	_, err = tx.Exec(`
-		UPDATE articles 
-		SET likes = $1 
-		WHERE id = $2
-	`, count, req.ArticleID)
-
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not update article"})
+	var req likeRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	if err = tx.Commit(); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Could not commit transaction"})
+	count, err := h.likes.Remove(c.UserContext(), req.ArticleID, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.NotFound("like_not_found", "Like not found")
+	} else if err != nil {
+		return apperr.Internal(err)
 	}
 
+	h.publishLikes(c.UserContext(), req.ArticleID, count)
 	return c.JSON(fiber.Map{"likes": count})
-}
\ No newline at end of file
+}
+
+// publishLikes pushes the new like count to the article's live subscribers.
+func (h *Handler) publishLikes(ctx context.Context, articleID string, count int) {
+	h.publish(ctx, realtime.ArticleChannel(articleID), "likes", fiber.Map{"article_id": articleID, "likes": count})
+}
@@ -0,0 +1,67 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestLikes(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	reader := api.addUser(t, models.RoleAuthor)
+	article := api.createArticle(t, author, "Liked")
+	body := map[string]any{"article_id": article}
+
+	// Liking twice counts once.
+	for range 2 {
+		r := api.do(t, http.MethodPost, "/api/likes", reader, body)
+		expect(t, r, http.StatusOK, "")
+		if r.body["likes"] != 1.0 {
+			t.Fatalf("likes = %v, want 1", r.body["likes"])
+		}
+	}
+	r := api.do(t, http.MethodGet, "/api/likes/status?article_id="+article+"&user_id="+reader, "", nil)
+	if expect(t, r, http.StatusOK, ""); r.body["liked"] != true {
+		t.Fatalf("status = %v, want liked", r.body)
+	}
+	r = api.do(t, http.MethodGet, "/api/likes/count/"+article, "", nil)
+	if expect(t, r, http.StatusOK, ""); r.body["count"] != 1.0 {
+		t.Fatalf("count = %v, want 1", r.body)
+	}
+	if r := api.do(t, http.MethodGet, "/api/articles/"+article, "", nil); r.body["likes"] != 1.0 {
+		t.Fatalf("article likes = %v, want 1", r.body["likes"])
+	}
+
+	r = api.do(t, http.MethodDelete, "/api/likes", reader, body)
+	if expect(t, r, http.StatusOK, ""); r.body["likes"] != 0.0 {
+		t.Fatalf("likes = %v, want 0", r.body["likes"])
+	}
+	expect(t, api.do(t, http.MethodDelete, "/api/likes", reader, body), http.StatusNotFound, "like_not_found")
+}
+
+func TestLikeErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	reader := api.addUser(t, models.RoleAuthor)
+
+	tests := []struct {
+		name         string
+		method, path string
+		userID       string
+		body         any
+		status       int
+		code         string
+	}{
+		{"like anonymously", http.MethodPost, "/api/likes", "", map[string]any{"article_id": uuid.NewString()}, http.StatusUnauthorized, "unauthenticated"},
+		{"like an unknown article", http.MethodPost, "/api/likes", reader, map[string]any{"article_id": uuid.NewString()}, http.StatusNotFound, "article_not_found"},
+		{"unlike anonymously", http.MethodDelete, "/api/likes", "", map[string]any{"article_id": uuid.NewString()}, http.StatusUnauthorized, "unauthenticated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect(t, api.do(t, tt.method, tt.path, tt.userID, tt.body), tt.status, tt.code)
+		})
+	}
+}
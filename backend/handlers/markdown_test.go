@@ -0,0 +1,53 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownContent(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+
+	r := api.do(t, http.MethodPost, "/api/articles", author, map[string]any{
+		"title":   "Formatted",
+		"content": "# Intro\n\nSome **bold** text <script>alert(1)</script> and [a link](javascript:alert(1)).",
+	})
+	expect(t, r, http.StatusCreated, "")
+	id := r.str("id")
+	html := r.str("content_html")
+	for _, want := range []string{`<h1 id="heading-intro">`, "<strong>bold</strong>", "&lt;script&gt;"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("content_html %q does not contain %q", html, want)
+		}
+	}
+	if strings.Contains(html, "<script>") || strings.Contains(html, "javascript:") {
+		t.Errorf("content_html %q is not sanitized", html)
+	}
+	if excerpt := r.str("excerpt"); strings.ContainsAny(excerpt, "#*[") || !strings.HasPrefix(excerpt, "Intro Some bold text") {
+		t.Errorf("excerpt = %q, want the text without Markdown", excerpt)
+	}
+
+	// Updates render the new content.
+	expect(t, api.do(t, http.MethodPut, "/api/articles/"+id, author, map[string]any{"title": "Formatted", "content": "_new_"}), http.StatusOK, "")
+	r = api.do(t, http.MethodGet, "/api/articles/"+id, "", nil)
+	if expect(t, r, http.StatusOK, ""); r.str("content_html") != "<p><em>new</em></p>" {
+		t.Errorf("content_html = %q after update", r.str("content_html"))
+	}
+
+	r = api.do(t, http.MethodPost, "/api/comments", author, map[string]any{"article_id": id, "content": "`code`"})
+	expect(t, r, http.StatusCreated, "")
+	comment := r.str("id")
+	if r.str("content_html") != "<p><code>code</code></p>" {
+		t.Errorf("comment content_html = %q", r.str("content_html"))
+	}
+	expect(t, api.do(t, http.MethodPut, "/api/comments/"+comment, author, map[string]any{"content": "**edited**"}), http.StatusOK, "")
+	r = api.do(t, http.MethodGet, "/api/comments/article/"+id, "", nil)
+	expect(t, r, http.StatusOK, "")
+	if got := r.body["data"].([]any)[0].(map[string]any)["content_html"]; got != "<p><strong>edited</strong></p>" {
+		t.Errorf("comment content_html = %q after update", got)
+	}
+}
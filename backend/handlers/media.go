@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"blog-api/apperr"
+	"blog-api/media"
+	"blog-api/models"
+	"blog-api/store"
+	"blog-api/validate"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// DefaultMaxUploadSize is used when Options.MaxUploadSize is zero.
+const DefaultMaxUploadSize = 10 << 20
+
+// POST /api/media (multipart/form-data with a "file" field)
+//
+// The file must be a JPEG, PNG or GIF image, whatever its declared type.
+// It is stored without metadata along with resized variants.
+func (h *Handler) UploadMedia(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
+	}
+	if h.mediaStorage == nil {
+		return apperr.New(503, "media_disabled", "Media uploads are not configured")
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return apperr.BadRequest("file_required", "A file field is required")
+	}
+	tooLarge := fmt.Sprintf("File too large (max %d bytes)", h.maxUploadSize)
+	if header.Size > h.maxUploadSize {
+		return apperr.New(413, "file_too_large", tooLarge)
+	}
+	file, err := header.Open()
+	if err != nil {
+		return apperr.BadRequest("invalid_file", "Could not read file")
+	}
+	data, err := io.ReadAll(io.LimitReader(file, h.maxUploadSize+1))
+	file.Close()
+	if err != nil {
+		return apperr.BadRequest("invalid_file", "Could not read file")
+	}
+	if int64(len(data)) > h.maxUploadSize {
+		return apperr.New(413, "file_too_large", tooLarge)
+	}
+
+	img, err := media.Process(data, 0)
+	switch {
+	case errors.Is(err, media.ErrUnsupportedType):
+		return apperr.New(415, "unsupported_media_type", "Only JPEG, PNG and GIF images are accepted")
+	case errors.Is(err, media.ErrTooManyPixels):
+		return apperr.New(413, "image_too_large", "Image dimensions too large")
+	case errors.Is(err, media.ErrInvalidImage):
+		return apperr.BadRequest("invalid_image", "Invalid image")
+	case err != nil:
+		return apperr.Internal(err)
+	}
+
+	id := uuid.New().String()
+	m := &models.Media{
+		ID:          id,
+		UserID:      userID,
+		ContentType: img.Original.ContentType,
+		Size:        int64(len(img.Original.Data)),
+		Width:       img.Original.Width,
+		Height:      img.Original.Height,
+		Key:         mediaKey(id, "original", img.Original.ContentType),
+		Variants:    []models.MediaVariant{},
+	}
+	files := []media.File{img.Original}
+	for _, spec := range media.Variants {
+		v, ok := img.Variants[spec.Name]
+		if !ok {
+			continue
+		}
+		m.Variants = append(m.Variants, models.MediaVariant{
+			Name:        spec.Name,
+			ContentType: v.ContentType,
+			Size:        int64(len(v.Data)),
+			Width:       v.Width,
+			Height:      v.Height,
+			Key:         mediaKey(id, spec.Name, v.ContentType),
+		})
+		files = append(files, v)
+	}
+
+	// mediaKeys lists the keys in the order of files.
+	keys := mediaKeys(m)
+	for i, file := range files {
+		if err = h.mediaStorage.Put(c.UserContext(), keys[i], file.ContentType, file.Data); err != nil {
+			h.deleteMediaFiles(keys[:i])
+			return apperr.Internal(err)
+		}
+	}
+	if err := h.media.Create(c.UserContext(), m); err != nil {
+		h.deleteMediaFiles(keys)
+		return apperr.Internal(err)
+	}
+
+	h.setMediaURLs(m)
+	return c.Status(201).JSON(m)
+}
+
+// GET /api/media/:id
+func (h *Handler) GetMedia(c *fiber.Ctx) error {
+	m, err := h.mediaParam(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(m)
+}
+
+// GET /api/media/:id/:variant
+//
+// Redirects to the file of a variant, or of the image itself for
+// "original", so clients can link images by ID.
+func (h *Handler) GetMediaFile(c *fiber.Ctx) error {
+	m, err := h.mediaParam(c)
+	if err != nil {
+		return err
+	}
+	name := c.Params("variant")
+	if name == "original" {
+		return c.Redirect(m.URL, fiber.StatusFound)
+	}
+	for _, v := range m.Variants {
+		if v.Name == name {
+			return c.Redirect(v.URL, fiber.StatusFound)
+		}
+	}
+	// Small images have no variant: fall back to the original.
+	for _, spec := range media.Variants {
+		if spec.Name == name {
+			return c.Redirect(m.URL, fiber.StatusFound)
+		}
+	}
+	return apperr.NotFound("variant_not_found", "Variant not found")
+}
+
+// DELETE /api/media/:id
+func (h *Handler) DeleteMedia(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	m, err := h.media.Get(c.UserContext(), id)
+	if err == nil && m.UserID != userID {
+		err = store.ErrNotFound
+	}
+	if err == nil {
+		err = h.media.Delete(c.UserContext(), m.ID, userID)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return errMediaNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
+	}
+
+	h.deleteMediaFiles(mediaKeys(m))
+	return c.JSON(fiber.Map{"message": "Media deleted successfully"})
+}
+
+// mediaParam loads the media named by the :id parameter with its URLs.
+func (h *Handler) mediaParam(c *fiber.Ctx) (*models.Media, error) {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := h.media.Get(c.UserContext(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, errMediaNotFound
+	} else if err != nil {
+		return nil, apperr.Internal(err)
+	}
+	h.setMediaURLs(m)
+	return m, nil
+}
+
+// ownMedia checks the media reference field of a request body: nil and
+// blank (which clear the reference, and is trimmed to empty in place) pass,
+// otherwise the image must have been uploaded by userID.
+func (h *Handler) ownMedia(c *fiber.Ctx, field string, id *string, userID string) error {
+	if id == nil {
+		return nil
+	}
+	if *id = strings.TrimSpace(*id); *id == "" {
+		return nil
+	}
+	if err := validate.Var(field, *id, "uuid"); err != nil {
+		return err
+	}
+	m, err := h.media.Get(c.UserContext(), *id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && m.UserID != userID) {
+		return apperr.BadRequest("unknown_media", "Unknown media")
+	} else if err != nil {
+		return apperr.Internal(err)
+	}
+	return nil
+}
+
+func (h *Handler) setMediaURLs(m *models.Media) {
+	if h.mediaStorage == nil {
+		return
+	}
+	m.URL = h.mediaStorage.URL(m.Key)
+	for i := range m.Variants {
+		m.Variants[i].URL = h.mediaStorage.URL(m.Variants[i].Key)
+	}
+}
+
+// deleteMediaFiles removes files from the storage. Failures only leave
+// orphaned files behind, so they are logged.
+func (h *Handler) deleteMediaFiles(keys []string) {
+	if h.mediaStorage == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := h.mediaStorage.Delete(context.Background(), key); err != nil {
+			log.Printf("Could not delete media file %s: %v", key, err)
+		}
+	}
+}
+
+func mediaKey(id, name, contentType string) string {
+	return "media/" + id + "/" + name + media.Extension(contentType)
+}
+
+func mediaKeys(m *models.Media) []string {
+	keys := []string{m.Key}
+	for _, v := range m.Variants {
+		keys = append(keys, v.Key)
+	}
+	return keys
+}
@@ -0,0 +1,164 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/media"
+	"blog-api/models"
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// pngImage encodes a plain width x height PNG.
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	img.Set(0, 0, color.Black)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// upload posts data as the file field of a multipart form.
+func (a *testAPI) upload(t *testing.T, userID, filename string, data []byte) response {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/media", &body)
+	req.Header.Set(fiber.HeaderContentType, form.FormDataContentType())
+	if userID != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token(t, userID))
+	}
+	resp, err := a.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	r := response{status: resp.StatusCode}
+	raw, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(raw, &r.body); err != nil {
+		t.Fatalf("upload: response is not JSON: %s", raw)
+	}
+	return r
+}
+
+func newMediaAPI(t *testing.T) (*testAPI, string) {
+	t.Helper()
+	dir := t.TempDir()
+	storage, err := media.NewLocal(dir, "/uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newTestAPI(t, handlers.Options{MediaStorage: storage, MaxUploadSize: 1 << 20}), dir
+}
+
+func TestMedia(t *testing.T) {
+	api, dir := newMediaAPI(t)
+	owner, other := api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor)
+
+	r := api.upload(t, owner, "photo.gif", pngImage(t, 600, 300))
+	expect(t, r, http.StatusCreated, "")
+	id := r.str("id")
+	if r.str("content_type") != "image/png" || r.body["width"] != 600.0 || r.str("url") != "/uploads/media/"+id+"/original.png" {
+		t.Fatalf("media = %v", r.body)
+	}
+	var variants []string
+	for _, v := range r.body["variants"].([]any) {
+		variants = append(variants, v.(map[string]any)["name"].(string))
+	}
+	if strings.Join(variants, ",") != "thumb,small" {
+		t.Fatalf("variants = %v, want thumb and small for a 600px image", variants)
+	}
+	for _, name := range []string{"original", "thumb", "small"} {
+		if _, err := os.Stat(filepath.Join(dir, "media", id, name+".png")); err != nil {
+			t.Fatalf("%s file: %v", name, err)
+		}
+	}
+
+	r = api.do(t, http.MethodGet, "/api/media/"+id, "", nil)
+	if expect(t, r, http.StatusOK, ""); r.str("url") == "" {
+		t.Fatalf("media = %v, want its URL", r.body)
+	}
+	for variant, location := range map[string]string{
+		"thumb":  "/uploads/media/" + id + "/thumb.png",
+		"large":  "/uploads/media/" + id + "/original.png",
+		"banner": "",
+	} {
+		resp, _ := api.get(t, "/api/media/"+id+"/"+variant, nil)
+		if location == "" && resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s = %d, want 404", variant, resp.StatusCode)
+		} else if location != "" && (resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != location) {
+			t.Errorf("%s = %d to %q, want a redirect to %q", variant, resp.StatusCode, resp.Header.Get("Location"), location)
+		}
+	}
+
+	// Only the uploader can reference or delete the image.
+	article := map[string]any{"title": "Covered", "content": "x", "cover_media_id": id}
+	expect(t, api.do(t, http.MethodPost, "/api/articles", other, article), http.StatusBadRequest, "unknown_media")
+	r = api.do(t, http.MethodPost, "/api/articles", owner, article)
+	if expect(t, r, http.StatusCreated, ""); r.str("cover_media_id") != id {
+		t.Fatalf("cover_media_id = %q, want %q", r.str("cover_media_id"), id)
+	}
+	expect(t, api.do(t, http.MethodPut, "/api/users/"+other, other, map[string]any{"email": other + "@example.com", "avatar_media_id": id}), http.StatusBadRequest, "unknown_media")
+	expect(t, api.do(t, http.MethodPut, "/api/users/"+owner, owner, map[string]any{"email": owner + "@example.com", "avatar_media_id": id}), http.StatusOK, "")
+
+	// A blank reference removes the image.
+	r = api.do(t, http.MethodPut, "/api/articles/"+r.str("id"), owner, map[string]any{"title": "Covered", "content": "x", "cover_media_id": " "})
+	if expect(t, r, http.StatusOK, ""); r.body["cover_media_id"] != nil {
+		t.Fatalf("cover_media_id = %v, want null", r.body["cover_media_id"])
+	}
+	r = api.do(t, http.MethodPut, "/api/users/"+owner, owner, map[string]any{"avatar_media_id": "  "})
+	if expect(t, r, http.StatusOK, ""); r.body["avatar_media_id"] != nil {
+		t.Fatalf("avatar_media_id = %v, want null", r.body["avatar_media_id"])
+	}
+
+	expect(t, api.do(t, http.MethodDelete, "/api/media/"+id, other, nil), http.StatusNotFound, "media_not_found")
+	expect(t, api.do(t, http.MethodDelete, "/api/media/"+id, owner, nil), http.StatusOK, "")
+	expect(t, api.do(t, http.MethodGet, "/api/media/"+id, "", nil), http.StatusNotFound, "media_not_found")
+	if _, err := os.Stat(filepath.Join(dir, "media", id, "original.png")); !os.IsNotExist(err) {
+		t.Fatalf("original file after delete: %v", err)
+	}
+}
+
+func TestMediaErrors(t *testing.T) {
+	api, _ := newMediaAPI(t)
+	user := api.addUser(t, models.RoleAuthor)
+
+	expect(t, api.upload(t, "", "a.png", pngImage(t, 10, 10)), http.StatusUnauthorized, "unauthenticated")
+	expect(t, api.upload(t, user, "a.png", pngImage(t, 10, 10)[:40]), http.StatusBadRequest, "invalid_image")
+	expect(t, api.upload(t, user, "a.txt", []byte("plain text, not an image")), http.StatusUnsupportedMediaType, "unsupported_media_type")
+	expect(t, api.upload(t, user, "a.png", make([]byte, 1<<20+1)), http.StatusRequestEntityTooLarge, "file_too_large")
+	expect(t, api.do(t, http.MethodPost, "/api/media", user, map[string]any{}), http.StatusBadRequest, "file_required")
+	expect(t, api.do(t, http.MethodGet, "/api/media/"+uuid.NewString(), "", nil), http.StatusNotFound, "media_not_found")
+	expect(t, api.do(t, http.MethodGet, "/api/media/nope", "", nil), http.StatusUnprocessableEntity, "validation_failed")
+
+	// Without a storage, uploads are refused.
+	api = newTestAPI(t, handlers.Options{})
+	user = api.addUser(t, models.RoleAuthor)
+	expect(t, api.upload(t, user, "a.png", pngImage(t, 10, 10)), http.StatusServiceUnavailable, "media_disabled")
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"blog-api/apperr"
+	"blog-api/models"
+	"blog-api/notify"
+	"blog-api/realtime"
+	"blog-api/store"
+	"blog-api/validate"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// notificationPage is a page of notifications with the caller's unread count.
+type notificationPage struct {
+	pageResponse
+	UnreadCount int `json:"unread_count"`
+}
+
+type notificationPreferences struct {
+	Muted []string `json:"muted"`
+}
+
+func (p *notificationPreferences) Check(errs *validate.Errors) {
+	for _, kind := range p.Muted {
+		if !notify.ValidKind(kind) {
+			errs.Add("muted", "oneof", "muted must only contain: "+strings.Join(notify.Kinds, ", "))
+			return
+		}
+	}
+}
+
+// GET /api/notifications?unread=true&limit=&before=&after=
+//
+// The caller's notifications, most recently active first.
+func (h *Handler) GetNotifications(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
+	}
+	page, err := parsePage(c)
+	if err != nil {
+		return err
+	}
+
+	notifications, err := h.notifications.List(c.UserContext(), userID, c.QueryBool("unread"), page)
+	if err != nil {
+		return apperr.Internal(err)
+	}
+	for i := range notifications.Items {
+		notifications.Items[i].Message = notify.Message(notifications.Items[i])
+	}
+	unread, err := h.notifications.UnreadCount(c.UserContext(), userID)
+	if err != nil {
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(notificationPage{newPageResponse(notifications), unread})
+}
+
+// GET /api/notifications/unread-count
+func (h *Handler) GetUnreadNotificationCount(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
+	}
+
+	unread, err := h.notifications.UnreadCount(c.UserContext(), userID)
+	if err != nil {
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(fiber.Map{"unread_count": unread})
+}
+
+// POST /api/notifications/:id/read
+func (h *Handler) MarkNotificationRead(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	err = h.notifications.MarkRead(c.UserContext(), id, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.NotFound("notification_not_found", "Notification not found")
+	} else if err != nil {
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(fiber.Map{"message": "Notification marked as read"})
+}
+
+// POST /api/notifications/read-all
+func (h *Handler) MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
+	}
+
+	count, err := h.notifications.MarkAllRead(c.UserContext(), userID)
+	if err != nil {
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(fiber.Map{"marked": count})
+}
+
+// GET /api/notifications/preferences
+func (h *Handler) GetNotificationPreferences(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
+	}
+
+	muted, err := h.notifications.Muted(c.UserContext(), userID)
+	if err != nil {
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(notificationPreferences{Muted: muted})
+}
+
+// PUT /api/notifications/preferences
+//
+// Replaces the list of muted notification kinds.
+func (h *Handler) UpdateNotificationPreferences(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
+	}
+
+	var prefs notificationPreferences
+	if err := parseBody(c, &prefs); err != nil {
+		return err
+	}
+	muted := []string{}
+	for _, kind := range notify.Kinds {
+		if slices.Contains(prefs.Muted, kind) {
+			muted = append(muted, kind)
+		}
+	}
+
+	err = h.notifications.SetMuted(c.UserContext(), userID, muted)
+	if errors.Is(err, store.ErrNotFound) {
+		return errUserNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(notificationPreferences{Muted: muted})
+}
+
+// notify records a notification for an action that already succeeded and
+// pushes it to the recipient's live stream. A failure only loses the
+// notification, so it is logged rather than returned.
+func (h *Handler) notify(ctx context.Context, e notify.Event) {
+	n, err := h.notifier.Emit(ctx, e)
+	if err != nil {
+		log.Printf("notify: %v", err)
+	}
+	if n == nil {
+		return
+	}
+
+	if actor, err := h.users.Get(ctx, e.ActorID); err == nil {
+		n.Actors = []models.Profile{{
+			ID:        actor.ID,
+			Email:     actor.Email,
+			FirstName: actor.FirstName,
+			LastName:  actor.LastName,
+			CreatedAt: actor.CreatedAt,
+		}}
+	}
+	n.Message = notify.Message(*n)
+	unread, err := h.notifications.UnreadCount(ctx, n.UserID)
+	if err != nil {
+		log.Printf("notify: %v", err)
+		return
+	}
+	h.publish(ctx, realtime.UserChannel(n.UserID), "notification", fiber.Map{"notification": n, "unread_count": unread})
+}
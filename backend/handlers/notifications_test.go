@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// notifications returns the first page of userID's notifications with their
+// unread count.
+func (a *testAPI) notifications(t *testing.T, userID string) (list []map[string]any, unread float64) {
+	t.Helper()
+	r := a.do(t, http.MethodGet, "/api/notifications", userID, nil)
+	expect(t, r, http.StatusOK, "")
+	for _, n := range r.body["data"].([]any) {
+		list = append(list, n.(map[string]any))
+	}
+	return list, r.body["unread_count"].(float64)
+}
+
+func TestNotifications(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author, ann, bob := api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor)
+	article := api.createArticle(t, author, "Noticed")
+
+	// Own actions do not notify.
+	expect(t, api.do(t, http.MethodPost, "/api/likes", author, map[string]any{"article_id": article}), http.StatusOK, "")
+	if list, unread := api.notifications(t, author); len(list) != 0 || unread != 0 {
+		t.Fatalf("notifications = %v, want none for own likes", list)
+	}
+
+	// Likes on the same article are merged into one notification.
+	for _, userID := range []string{ann, bob} {
+		expect(t, api.do(t, http.MethodPost, "/api/likes", userID, map[string]any{"article_id": article}), http.StatusOK, "")
+	}
+	expect(t, api.do(t, http.MethodPost, "/api/followers", ann, map[string]any{"following_id": author}), http.StatusCreated, "")
+	r := api.do(t, http.MethodPost, "/api/comments", ann, map[string]any{"article_id": article, "content": "Hi"})
+	expect(t, r, http.StatusCreated, "")
+	comment := r.str("id")
+
+	list, unread := api.notifications(t, author)
+	if len(list) != 3 || unread != 3 {
+		t.Fatalf("notifications = %v (%v unread), want 3 unread", list, unread)
+	}
+	if n := list[0]; n["kind"] != "comment" || n["actor_count"] != 1.0 || n["article_title"] != "Noticed" {
+		t.Fatalf("newest notification = %v, want ann's comment", n)
+	}
+	like := list[2]
+	if like["kind"] != "like" || like["actor_count"] != 2.0 || len(like["actors"].([]any)) != 2 {
+		t.Fatalf("like notification = %v, want two actors", like)
+	}
+	if want := bob + "@example.com and " + ann + `@example.com liked your article "Noticed"`; like["message"] != want {
+		t.Fatalf("message = %q, want %q", like["message"], want)
+	}
+
+	// A reply notifies the parent's author, and the article author only when
+	// they are someone else.
+	expect(t, api.do(t, http.MethodPost, "/api/comments", author, map[string]any{"article_id": article, "parent_id": comment, "content": "Thanks"}), http.StatusCreated, "")
+	expect(t, api.do(t, http.MethodPost, "/api/comments", bob, map[string]any{"article_id": article, "parent_id": comment, "content": "Me too"}), http.StatusCreated, "")
+	list, _ = api.notifications(t, ann)
+	if len(list) != 1 || list[0]["kind"] != "reply" || list[0]["actor_count"] != 2.0 || list[0]["comment_id"] != comment {
+		t.Fatalf("ann's notifications = %v, want one reply from two users", list)
+	}
+	if list, _ = api.notifications(t, author); list[0]["kind"] != "comment" || list[0]["actor_count"] != 2.0 {
+		t.Fatalf("author's newest notification = %v, want comments by ann and bob", list[0])
+	}
+
+	// Reading.
+	r = api.do(t, http.MethodGet, "/api/notifications/unread-count", author, nil)
+	if expect(t, r, http.StatusOK, ""); r.body["unread_count"] != 3.0 {
+		t.Fatalf("unread count = %v, want 3", r.body["unread_count"])
+	}
+	expect(t, api.do(t, http.MethodPost, "/api/notifications/"+like["id"].(string)+"/read", ann, nil), http.StatusNotFound, "notification_not_found")
+	expect(t, api.do(t, http.MethodPost, "/api/notifications/"+like["id"].(string)+"/read", author, nil), http.StatusOK, "")
+	r = api.do(t, http.MethodGet, "/api/notifications?unread=true", author, nil)
+	if ids, _, _ := pageIDs(t, r); len(ids) != 2 || r.body["unread_count"] != 2.0 {
+		t.Fatalf("unread notifications = %v, want 2", ids)
+	}
+	r = api.do(t, http.MethodPost, "/api/notifications/read-all", author, nil)
+	if expect(t, r, http.StatusOK, ""); r.body["marked"] != 2.0 {
+		t.Fatalf("marked = %v, want 2", r.body["marked"])
+	}
+
+	// A read notification is not reused: a new like starts a new one.
+	carol := api.addUser(t, models.RoleAuthor)
+	expect(t, api.do(t, http.MethodPost, "/api/likes", carol, map[string]any{"article_id": article}), http.StatusOK, "")
+	if list, unread := api.notifications(t, author); len(list) != 4 || unread != 1 || list[0]["actor_count"] != 1.0 {
+		t.Fatalf("notifications = %v (%v unread), want a new like notification", list, unread)
+	}
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author, reader := api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor)
+	article := api.createArticle(t, author, "Quiet")
+
+	r := api.do(t, http.MethodGet, "/api/notifications/preferences", author, nil)
+	if expect(t, r, http.StatusOK, ""); len(r.body["muted"].([]any)) != 0 {
+		t.Fatalf("muted = %v, want none", r.body["muted"])
+	}
+	r = api.do(t, http.MethodPut, "/api/notifications/preferences", author, map[string]any{"muted": []string{"like", "follow", "like"}})
+	if expect(t, r, http.StatusOK, ""); len(r.body["muted"].([]any)) != 2 || r.body["muted"].([]any)[0] != "follow" {
+		t.Fatalf("muted = %v, want [follow like]", r.body["muted"])
+	}
+
+	expect(t, api.do(t, http.MethodPost, "/api/likes", reader, map[string]any{"article_id": article}), http.StatusOK, "")
+	expect(t, api.do(t, http.MethodPost, "/api/followers", reader, map[string]any{"following_id": author}), http.StatusCreated, "")
+	expect(t, api.do(t, http.MethodPost, "/api/comments", reader, map[string]any{"article_id": article, "content": "Hi"}), http.StatusCreated, "")
+	if list, _ := api.notifications(t, author); len(list) != 1 || list[0]["kind"] != "comment" {
+		t.Fatalf("notifications = %v, want only the comment", list)
+	}
+}
+
+func TestNotificationErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	user := api.addUser(t, models.RoleAuthor)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		userID string
+		body   any
+		status int
+		code   string
+	}{
+		{"list anonymously", http.MethodGet, "/api/notifications", "", nil, http.StatusUnauthorized, "unauthenticated"},
+		{"count anonymously", http.MethodGet, "/api/notifications/unread-count", "", nil, http.StatusUnauthorized, "unauthenticated"},
+		{"read anonymously", http.MethodPost, "/api/notifications/read-all", "", nil, http.StatusUnauthorized, "unauthenticated"},
+		{"read an unknown notification", http.MethodPost, "/api/notifications/" + uuid.NewString() + "/read", user, nil, http.StatusNotFound, "notification_not_found"},
+		{"read an invalid ID", http.MethodPost, "/api/notifications/nope/read", user, nil, http.StatusUnprocessableEntity, "validation_failed"},
+		{"mute an unknown kind", http.MethodPut, "/api/notifications/preferences", user, map[string]any{"muted": []string{"mention"}}, http.StatusUnprocessableEntity, "validation_failed"},
+		{"bad page", http.MethodGet, "/api/notifications?limit=0", user, nil, http.StatusBadRequest, "invalid_limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect(t, api.do(t, tt.method, tt.path, tt.userID, tt.body), tt.status, tt.code)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"blog-api/apperr"
+	"blog-api/store"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageResponse is the envelope returned by every paginated endpoint.
+type pageResponse struct {
+	Data       interface{} `json:"data"`
+	NextCursor *string     `json:"next_cursor"`
+	PrevCursor *string     `json:"prev_cursor"`
+}
+
+// parsePage reads ?limit=, ?before= and ?after= into a store.PageRequest,
+// returning a 400 error when they are invalid.
+func parsePage(c *fiber.Ctx) (store.PageRequest, error) {
+	page := store.PageRequest{Limit: defaultPageLimit}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return page, apperr.BadRequest("invalid_limit", "limit must be a positive integer")
+		}
+		page.Limit = min(limit, maxPageLimit)
+	}
+
+	before, after := c.Query("before"), c.Query("after")
+	if before != "" && after != "" {
+		return page, apperr.BadRequest("invalid_cursor", "Use either before or after, not both")
+	}
+	if before != "" {
+		cursor, err := store.DecodeCursor(before)
+		if err != nil {
+			return page, apperr.BadRequest("invalid_cursor", "Invalid cursor")
+		}
+		page.Before = &cursor
+	}
+	if after != "" {
+		cursor, err := store.DecodeCursor(after)
+		if err != nil {
+			return page, apperr.BadRequest("invalid_cursor", "Invalid cursor")
+		}
+		page.After = &cursor
+	}
+
+	return page, nil
+}
+
+func newPageResponse[T any](page store.Page[T]) pageResponse {
+	return pageResponse{
+		Data:       page.Items,
+		NextCursor: encodeCursor(page.NextCursor),
+		PrevCursor: encodeCursor(page.PrevCursor),
+	}
+}
+
+func encodeCursor(cursor *store.Cursor) *string {
+	if cursor == nil {
+		return nil
+	}
+	s := cursor.Encode()
+	return &s
+}
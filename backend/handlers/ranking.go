@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"blog-api/apperr"
+	"blog-api/store"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// topWindows maps ?window= to how far back sort=top looks. "all" has no limit.
+var topWindows = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"all":   0,
+}
+
+// sortFilter reads ?sort=new|hot|top and ?window=day|week|month|all into the
+// filter. It returns a 400 error when they are invalid.
+func sortFilter(c *fiber.Ctx, filter *store.ArticleFilter) error {
+	filter.Sort = c.Query("sort", store.SortNew)
+	switch filter.Sort {
+	case store.SortNew, store.SortHot:
+		if c.Query("window") != "" {
+			return apperr.BadRequest("invalid_window", "window only applies to sort=top")
+		}
+	case store.SortTop:
+		window, ok := topWindows[c.Query("window", "all")]
+		if !ok {
+			return apperr.BadRequest("invalid_window", "window must be day, week, month or all")
+		}
+		if window > 0 {
+			since := time.Now().Add(-window)
+			filter.PublishedSince = &since
+		}
+	default:
+		return apperr.BadRequest("invalid_sort", "sort must be new, hot or top")
+	}
+	return nil
+}
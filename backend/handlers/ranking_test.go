@@ -0,0 +1,69 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestArticleRanking(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author, reader, other := api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor)
+
+	// An old article with a lot of engagement and a new one with a little.
+	r := api.do(t, http.MethodPost, "/api/articles", author, map[string]any{
+		"title": "Old", "content": "x", "status": "published",
+		"publish_at": time.Now().AddDate(0, 0, -10).UTC().Format(time.RFC3339),
+	})
+	expect(t, r, http.StatusCreated, "")
+	old := r.str("id")
+	fresh := api.createArticle(t, author, "Fresh")
+	quiet := api.createArticle(t, author, "Quiet")
+	for _, userID := range []string{reader, other} {
+		expect(t, api.do(t, http.MethodPost, "/api/favorites", userID, map[string]any{"article_id": old}), http.StatusCreated, "")
+	}
+	expect(t, api.do(t, http.MethodPost, "/api/comments", reader, map[string]any{"article_id": fresh, "content": "Nice"}), http.StatusCreated, "")
+
+	if _, err := api.stores.Articles.RefreshScores(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{quiet, fresh, old}},
+		{"?sort=new", []string{quiet, fresh, old}},
+		{"?sort=hot", []string{fresh, quiet, old}},
+		{"?sort=top", []string{old, fresh, quiet}},
+		{"?sort=top&window=all", []string{old, fresh, quiet}},
+		{"?sort=top&window=week", []string{fresh, quiet}},
+	}
+	for _, tt := range tests {
+		got, _, _ := pageIDs(t, api.do(t, http.MethodGet, "/api/articles"+tt.query, "", nil))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	r = api.do(t, http.MethodGet, "/api/articles/"+old, "", nil)
+	if expect(t, r, http.StatusOK, ""); r.body["engagement"] != float64(2*3) {
+		t.Fatalf("engagement = %v, want 6 for two favorites", r.body["engagement"])
+	}
+}
+
+func TestArticleRankingErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	for query, code := range map[string]string{
+		"?sort=best":            "invalid_sort",
+		"?sort=hot&window=day":  "invalid_window",
+		"?window=day":           "invalid_window",
+		"?sort=top&window=year": "invalid_window",
+	} {
+		expect(t, api.do(t, http.MethodGet, "/api/articles"+query, "", nil), http.StatusBadRequest, code)
+	}
+}
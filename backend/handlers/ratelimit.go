@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"blog-api/ratelimit"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultRateLimits are the policies of the rate-limited routes, by name.
+// Each client has its own bucket per name.
+var DefaultRateLimits = map[string]ratelimit.Policy{
+	// POST /api/users
+	"users": {Requests: 5, Per: time.Hour},
+	// POST and PUT /api/articles, revision restores
+	"articles": {Requests: 30, Per: time.Hour, Burst: 10},
+	// POST, PUT and DELETE /api/comments
+	"comments": {Requests: 10, Per: time.Minute, Burst: 5},
+	// POST and DELETE /api/likes
+	"likes": {Requests: 60, Per: time.Minute, Burst: 20},
+	// POST and DELETE /api/followers
+	"follows": {Requests: 30, Per: time.Minute, Burst: 10},
+	// POST and DELETE /api/favorites
+	"favorites": {Requests: 60, Per: time.Minute, Burst: 20},
+	// POST /api/media
+	"media": {Requests: 20, Per: time.Hour, Burst: 5},
+	// GET /api/search
+	"search": {Requests: 60, Per: time.Minute, Burst: 20},
+}
+
+// limit returns the rate limit middleware of the named policy, or one that
+// lets everything through when rate limiting is off or the policy allows
+// zero requests.
+func (h *Handler) limit(name string) fiber.Handler {
+	p, ok := h.rateLimits[name]
+	if h.rateLimiter == nil || !ok || p.Requests == 0 {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+	return ratelimit.Limit(h.rateLimiter, name, p)
+}
@@ -0,0 +1,45 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"blog-api/ratelimit"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimits(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{
+		RateLimiter: ratelimit.NewMemory(),
+		RateLimits: map[string]ratelimit.Policy{
+			"comments": {Requests: 2, Per: time.Hour},
+			"likes":    {Requests: 0, Per: time.Hour},
+		},
+	})
+	author := api.addUser(t, models.RoleAuthor)
+	reader := api.addUser(t, models.RoleReader)
+	article := api.createArticle(t, author, "Busy")
+	comment := map[string]any{"article_id": article, "content": "Hi"}
+
+	for range 2 {
+		expect(t, api.do(t, http.MethodPost, "/api/comments", reader, comment), http.StatusCreated, "")
+	}
+	expect(t, api.do(t, http.MethodPost, "/api/comments", reader, comment), http.StatusTooManyRequests, "rate_limited")
+	// Each user has their own bucket, and reads are not limited.
+	expect(t, api.do(t, http.MethodPost, "/api/comments", author, comment), http.StatusCreated, "")
+	expect(t, api.do(t, http.MethodGet, "/api/comments/article/"+article, reader, nil), http.StatusOK, "")
+	// A policy of zero requests turns the limit off.
+	for range 3 {
+		expect(t, api.do(t, http.MethodPost, "/api/likes", reader, map[string]any{"article_id": article}), http.StatusOK, "")
+	}
+}
+
+func TestRateLimitsOff(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{RateLimits: map[string]ratelimit.Policy{"comments": {Requests: 1, Per: time.Hour}}})
+	author := api.addUser(t, models.RoleAuthor)
+	article := api.createArticle(t, author, "Quiet")
+	for range 3 {
+		expect(t, api.do(t, http.MethodPost, "/api/comments", author, map[string]any{"article_id": article, "content": "Hi"}), http.StatusCreated, "")
+	}
+}
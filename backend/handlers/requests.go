@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"blog-api/models"
+	"blog-api/validate"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Request bodies. They hold the fields clients may write, with the rules
+// of package validate; handlers copy them into the models.
+
+type createUserRequest struct {
+	Email     string `json:"email" validate:"required,email,max=254"`
+	FirstName string `json:"firstname" validate:"max=100"`
+	LastName  string `json:"lastname" validate:"max=100"`
+}
+
+type updateUserRequest struct {
+	FirstName string `json:"firstname" validate:"max=100"`
+	LastName  string `json:"lastname" validate:"max=100"`
+	// AvatarMediaID keeps the avatar when nil and removes it when empty.
+	AvatarMediaID *string `json:"avatar_media_id" validate:"uuid"`
+}
+
+type articleRequest struct {
+	Title     string     `json:"title" validate:"max=200"`
+	Excerpt   string     `json:"excerpt" validate:"max=500"`
+	Content   string     `json:"content" validate:"required,max=100000"`
+	Status    string     `json:"status" validate:"oneof=draft scheduled published archived"`
+	PublishAt *time.Time `json:"publish_at"`
+	// Tags keeps the tags of an updated article when nil.
+	Tags []string `json:"tags"`
+	// CoverMediaID keeps the cover when nil and removes it when empty.
+	CoverMediaID *string `json:"cover_media_id" validate:"uuid"`
+}
+
+func (r *articleRequest) article() *models.Article {
+	return &models.Article{
+		Title:        r.Title,
+		Excerpt:      r.Excerpt,
+		Content:      r.Content,
+		Status:       r.Status,
+		PublishAt:    r.PublishAt,
+		Tags:         r.Tags,
+		CoverMediaID: r.CoverMediaID,
+	}
+}
+
+type createCommentRequest struct {
+	ArticleID string  `json:"article_id" validate:"required,uuid"`
+	ParentID  *string `json:"parent_id" validate:"uuid"`
+	Content   string  `json:"content" validate:"required,max=10000"`
+}
+
+type updateCommentRequest struct {
+	Content string `json:"content" validate:"required,max=10000"`
+}
+
+type favoriteRequest struct {
+	ArticleID string `json:"article_id" validate:"required,uuid"`
+}
+
+type followRequest struct {
+	// FollowerID is the authenticated user, not part of the body.
+	FollowerID  string `json:"-"`
+	FollowingID string `json:"following_id" validate:"required,uuid"`
+}
+
+func (r *followRequest) Check(errs *validate.Errors) {
+	if r.FollowingID != "" && r.FollowingID == r.FollowerID {
+		errs.Add("following_id", "not_self", "following_id must be another user")
+	}
+}
+
+type likeRequest struct {
+	ArticleID string `json:"article_id" validate:"required,uuid"`
+}
+
+// parseBody decodes the JSON body into req, a pointer to a request type,
+// and validates it.
+func parseBody(c *fiber.Ctx, req any) error {
+	if err := c.BodyParser(req); err != nil {
+		return errInvalidBody
+	}
+	return validate.Struct(req)
+}
+
+// idParam returns the route parameter name, which must be a UUID.
+func idParam(c *fiber.Ctx, name string) (string, error) {
+	id := c.Params(name)
+	if err := validate.Var(name, id, "required,uuid"); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// idQuery returns the query parameter name, which must be empty or a UUID.
+func idQuery(c *fiber.Ctx, name string) (string, error) {
+	id := c.Query(name)
+	if err := validate.Var(name, id, "uuid"); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+type roleRequest struct {
+	Role string `json:"role" validate:"required,oneof=reader author moderator admin"`
+}
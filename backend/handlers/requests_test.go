@@ -0,0 +1,65 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// invalidFields returns field:rule for each invalid field of a 422
+// response.
+func invalidFields(t *testing.T, r response) []string {
+	t.Helper()
+	expect(t, r, http.StatusUnprocessableEntity, "validation_failed")
+	var fields []string
+	for _, e := range r.body["errors"].([]any) {
+		e := e.(map[string]any)
+		fields = append(fields, e["field"].(string)+":"+e["rule"].(string))
+	}
+	return fields
+}
+
+func TestRequestValidation(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	user := api.addUser(t, models.RoleAuthor)
+	article := api.createArticle(t, user, "Valid")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   map[string]any
+		want   []string
+	}{
+		{"user without email", http.MethodPost, "/api/users", map[string]any{"firstname": "Ann"}, []string{"email:required"}},
+		{"user with invalid email", http.MethodPost, "/api/users", map[string]any{"email": "ann"}, []string{"email:email"}},
+		{"user with a long name", http.MethodPost, "/api/users", map[string]any{"email": "ann@example.com", "lastname": strings.Repeat("x", 101)}, []string{"lastname:max"}},
+		{"article with several invalid fields", http.MethodPost, "/api/articles", map[string]any{"title": strings.Repeat("x", 201), "status": "gone"}, []string{"title:max", "content:required", "status:oneof"}},
+		{"article with an invalid cover", http.MethodPost, "/api/articles", map[string]any{"content": "x", "cover_media_id": "nope"}, []string{"cover_media_id:uuid"}},
+		{"update with blank content", http.MethodPut, "/api/articles/" + article, map[string]any{"title": "x", "content": " "}, []string{"content:required"}},
+		{"comment without article", http.MethodPost, "/api/comments", map[string]any{"content": "x"}, []string{"article_id:required"}},
+		{"comment with an invalid parent", http.MethodPost, "/api/comments", map[string]any{"article_id": article, "parent_id": "nope", "content": "x"}, []string{"parent_id:uuid"}},
+		{"favorite of an invalid article", http.MethodPost, "/api/favorites", map[string]any{"article_id": "nope"}, []string{"article_id:uuid"}},
+		{"like without article", http.MethodPost, "/api/likes", map[string]any{}, []string{"article_id:required"}},
+		{"follow yourself", http.MethodPost, "/api/followers", map[string]any{"following_id": user}, []string{"following_id:not_self"}},
+		{"invalid path ID", http.MethodGet, "/api/comments/article/nope", nil, []string{"id:uuid"}},
+		{"invalid query ID", http.MethodGet, "/api/likes/status?article_id=nope&user_id=" + user, nil, []string{"article_id:uuid"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body any
+			if tt.body != nil {
+				body = tt.body
+			}
+			if got := invalidFields(t, api.do(t, tt.method, tt.path, user, body)); !slices.Equal(got, tt.want) {
+				t.Fatalf("invalid fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	r := api.do(t, http.MethodPost, "/api/users", user, "not an object")
+	expect(t, r, http.StatusBadRequest, "invalid_body")
+}
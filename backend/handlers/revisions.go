@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"blog-api/apperr"
+	"blog-api/diff"
+	"blog-api/models"
+	"blog-api/store"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GET /api/articles/:id/revisions
+func (h *Handler) GetArticleRevisions(c *fiber.Ctx) error {
+	article, err := h.ownArticle(c)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := h.articles.Revisions(c.UserContext(), article.ID)
+	if err != nil {
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(revisions)
+}
+
+// GET /api/articles/:id/revisions/diff?from=&to=&mode=line|word
+func (h *Handler) DiffArticleRevisions(c *fiber.Ctx) error {
+	article, err := h.ownArticle(c)
+	if err != nil {
+		return err
+	}
+
+	mode := c.Query("mode", "line")
+	if mode != "line" && mode != "word" {
+		return apperr.BadRequest("invalid_diff_mode", "mode must be line or word")
+	}
+
+	from, err := h.revisionParam(c, article.ID, c.Query("from"))
+	if err != nil {
+		return err
+	}
+	to, err := h.revisionParam(c, article.ID, c.Query("to"))
+	if err != nil {
+		return err
+	}
+
+	compare := diff.Lines
+	if mode == "word" {
+		compare = diff.Words
+	}
+	content := compare(from.Content, to.Content)
+	insertions, deletions := diff.Stats(content)
+
+	return c.JSON(fiber.Map{
+		"from":       from.Number,
+		"to":         to.Number,
+		"mode":       mode,
+		"title":      diff.Words(from.Title, to.Title),
+		"content":    content,
+		"insertions": insertions,
+		"deletions":  deletions,
+	})
+}
+
+// POST /api/articles/:id/revisions/:rev/restore
+func (h *Handler) RestoreArticleRevision(c *fiber.Ctx) error {
+	article, err := h.ownArticle(c)
+	if err != nil {
+		return err
+	}
+
+	rev, err := h.revisionParam(c, article.ID, c.Params("rev"))
+	if err != nil {
+		return err
+	}
+
+	article.Title = rev.Title
+	article.Content = rev.Content
+	article.Excerpt = ""
+	if err := prepareArticle(article, time.Now()); err != nil {
+		return err
+	}
+	err = h.articles.Restore(c.UserContext(), article, rev.Number)
+	if errors.Is(err, store.ErrConflict) {
+		return errSlugConflict
+	} else if err != nil {
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(article)
+}
+
+// ownArticle loads the :id article and checks the caller wrote it.
+func (h *Handler) ownArticle(c *fiber.Ctx) (*models.Article, error) {
+	userID, err := requireUser(c)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := idParam(c, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	article, err := h.articles.Get(c.UserContext(), id)
+	if err == nil && article.UserID != userID {
+		err = store.ErrNotFound
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, errArticleNotFound
+	} else if err != nil {
+		return nil, apperr.Internal(err)
+	}
+	return article, nil
+}
+
+// revisionParam loads the revision whose number is raw.
+func (h *Handler) revisionParam(c *fiber.Ctx, articleID, raw string) (*models.Revision, error) {
+	number, err := strconv.Atoi(raw)
+	if err != nil || number < 1 {
+		return nil, apperr.BadRequest("invalid_revision", "Invalid revision number")
+	}
+
+	rev, err := h.articles.Revision(c.UserContext(), articleID, number)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.NotFound("revision_not_found", "Revision not found")
+	} else if err != nil {
+		return nil, apperr.Internal(err)
+	}
+	return rev, nil
+}
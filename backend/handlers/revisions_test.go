@@ -0,0 +1,91 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRevisions(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	id := api.createArticle(t, author, "First")
+	path := "/api/articles/" + id + "/revisions"
+
+	update := func(title, content string) {
+		t.Helper()
+		expect(t, api.do(t, http.MethodPut, "/api/articles/"+id, author, map[string]any{"title": title, "content": content}), http.StatusOK, "")
+	}
+	update("Second", "Some content.\nMore.")
+	update("Second", "Some content.\nMore.") // unchanged: no revision
+	update("Third", "Other content.\nMore.")
+
+	r := api.do(t, http.MethodGet, path, author, nil)
+	expect(t, r, http.StatusOK, "")
+	var titles []string
+	for _, rev := range r.list {
+		titles = append(titles, rev.(map[string]any)["title"].(string))
+	}
+	if len(titles) != 3 || titles[0] != "Third" || titles[2] != "First" {
+		t.Fatalf("revisions = %v, want Third, Second, First", titles)
+	}
+
+	r = api.do(t, http.MethodGet, path+"/diff?from=1&to=3", author, nil)
+	expect(t, r, http.StatusOK, "")
+	content := r.body["content"].([]any)
+	if len(content) != 2 || r.body["insertions"] != float64(len("Other content.\nMore.")) || r.body["deletions"] != float64(len("Some content.")) {
+		t.Fatalf("line diff = %v", r.body)
+	}
+	r = api.do(t, http.MethodGet, path+"/diff?from=1&to=2&mode=word", author, nil)
+	expect(t, r, http.StatusOK, "")
+	if r.body["mode"] != "word" || r.body["insertions"] != float64(len("\nMore.")) {
+		t.Fatalf("word diff = %v", r.body)
+	}
+
+	// Restoring brings back the old title and slug and records a revision.
+	r = api.do(t, http.MethodPost, path+"/1/restore", author, nil)
+	expect(t, r, http.StatusOK, "")
+	if r.str("title") != "First" || r.str("content") != "Some content." || r.str("slug") != "first" {
+		t.Fatalf("restored article = %v", r.body)
+	}
+	r = api.do(t, http.MethodGet, path, author, nil)
+	if expect(t, r, http.StatusOK, ""); len(r.list) != 4 || r.list[0].(map[string]any)["restored_from"] != float64(1) {
+		t.Fatalf("revisions after restore = %v", r.list)
+	}
+}
+
+func TestRevisionErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author, other := api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor)
+	id := api.createArticle(t, author, "First")
+	path := "/api/articles/" + id + "/revisions"
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		userID string
+		status int
+		code   string
+	}{
+		{"anonymous", http.MethodGet, path, "", http.StatusUnauthorized, "unauthenticated"},
+		{"not the author", http.MethodGet, path, other, http.StatusNotFound, "article_not_found"},
+		{"diff by another user", http.MethodGet, path + "/diff?from=1&to=1", other, http.StatusNotFound, "article_not_found"},
+		{"restore by another user", http.MethodPost, path + "/1/restore", other, http.StatusNotFound, "article_not_found"},
+		{"unknown article", http.MethodGet, "/api/articles/" + uuid.NewString() + "/revisions", author, http.StatusNotFound, "article_not_found"},
+		{"invalid article ID", http.MethodGet, "/api/articles/nope/revisions", author, http.StatusUnprocessableEntity, "validation_failed"},
+		{"bad mode", http.MethodGet, path + "/diff?from=1&to=1&mode=char", author, http.StatusBadRequest, "invalid_diff_mode"},
+		{"missing from", http.MethodGet, path + "/diff?to=1", author, http.StatusBadRequest, "invalid_revision"},
+		{"zero revision", http.MethodGet, path + "/diff?from=0&to=1", author, http.StatusBadRequest, "invalid_revision"},
+		{"unknown revision", http.MethodGet, path + "/diff?from=1&to=9", author, http.StatusNotFound, "revision_not_found"},
+		{"restore unknown revision", http.MethodPost, path + "/9/restore", author, http.StatusNotFound, "revision_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect(t, api.do(t, tt.method, tt.path, tt.userID, nil), tt.status, tt.code)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"blog-api/worker"
+	"context"
+	"log"
+	"time"
+)
+
+// PublishScheduledJob promotes scheduled articles once their publish date
+// has passed.
+func (h *Handler) PublishScheduledJob(interval time.Duration) worker.Job {
+	return worker.Job{
+		Name:     "publish-scheduled",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			published, err := h.articles.PublishDue(ctx, time.Now())
+			if err != nil {
+				return err
+			}
+			for _, article := range published {
+				log.Printf("Published scheduled article %s", article.ID)
+			}
+			return nil
+		},
+	}
+}
+
+// RefreshScoresJob recomputes the engagement and hot scores behind
+// sort=hot and sort=top.
+func (h *Handler) RefreshScoresJob(interval time.Duration) worker.Job {
+	return worker.Job{
+		Name:     "refresh-scores",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			_, err := h.articles.RefreshScores(ctx, time.Now())
+			return err
+		},
+	}
+}
+
+// renderBatch is the number of rows RenderContentJob renders per query.
+const renderBatch = 100
+
+// RenderContentJob renders again the articles and comments whose stored
+// HTML comes from an older markdown.Version, until none are left.
+func (h *Handler) RenderContentJob(interval time.Duration) worker.Job {
+	return worker.Job{
+		Name:     "render-content",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			for _, renderStale := range []func(context.Context, int) (int, error){
+				h.articles.RenderStale,
+				h.comments.RenderStale,
+			} {
+				for {
+					n, err := renderStale(ctx, renderBatch)
+					if err != nil {
+						return err
+					}
+					if n < renderBatch {
+						break
+					}
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"blog-api/apperr"
+	"blog-api/store"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GET /api/search?q=&type=&author=&from=&to=&limit=&before=&after=
+//
+// q supports "quoted phrases" and prefix* terms; from and to accept a date
+// (2006-01-02) or an RFC 3339 timestamp, to being exclusive.
+func (h *Handler) Search(c *fiber.Ctx) error {
+	page, err := parsePage(c)
+	if err != nil {
+		return err
+	}
+
+	authorID, err := idQuery(c, "author")
+	if err != nil {
+		return err
+	}
+
+	query := store.SearchQuery{
+		Terms:    store.ParseSearchTerms(c.Query("q")),
+		Type:     c.Query("type"),
+		AuthorID: authorID,
+	}
+	if len(query.Terms) == 0 {
+		return apperr.BadRequest("query_required", "Search query is required")
+	}
+	if query.Type != "" && query.Type != "article" && query.Type != "comment" {
+		return apperr.BadRequest("invalid_type", "type must be article or comment")
+	}
+
+	if query.From, err = queryDate(c, "from"); err != nil {
+		return err
+	}
+	if query.To, err = queryDate(c, "to"); err != nil {
+		return err
+	}
+
+	results, err := h.search.Search(c.UserContext(), query, page)
+	if err != nil {
+		return apperr.Internal(err)
+	}
+
+	return c.JSON(newPageResponse(results))
+}
+
+// queryDate parses an optional date query parameter, returning a 400 error
+// when it is malformed.
+func queryDate(c *fiber.Ctx, param string) (*time.Time, error) {
+	raw := c.Query(param)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, raw)
+	}
+	if err != nil {
+		return nil, apperr.BadRequest("invalid_date", "Invalid "+param+" date")
+	}
+	return &t, nil
+}
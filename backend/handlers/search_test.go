@@ -0,0 +1,102 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// search returns the type:id of each result of a search, and their
+// snippets.
+func (a *testAPI) search(t *testing.T, query string) (results, snippets []string) {
+	t.Helper()
+	r := a.do(t, http.MethodGet, "/api/search?"+query, "", nil)
+	expect(t, r, http.StatusOK, "")
+	for _, item := range r.body["data"].([]any) {
+		result := item.(map[string]any)
+		results = append(results, result["type"].(string)+":"+result["id"].(string))
+		snippets = append(snippets, result["snippet"].(string))
+	}
+	return results, snippets
+}
+
+func TestSearch(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	other := api.addUser(t, models.RoleAuthor)
+	r := api.do(t, http.MethodPost, "/api/articles", author, map[string]any{"title": "Burrows", "content": "All about gophers."})
+	expect(t, r, http.StatusCreated, "")
+	article := r.str("id")
+	r = api.do(t, http.MethodPost, "/api/comments", other, map[string]any{"article_id": article, "content": "Gophers rock"})
+	expect(t, r, http.StatusCreated, "")
+	comment := r.str("id")
+	api.createArticle(t, author, "Nothing to see here")
+	// Hidden articles and their comments are left out.
+	r = api.do(t, http.MethodPost, "/api/articles", author, map[string]any{"title": "Hidden", "content": "More gophers."})
+	expect(t, r, http.StatusCreated, "")
+	hidden := r.str("id")
+	expect(t, api.do(t, http.MethodPost, "/api/comments", other, map[string]any{"article_id": hidden, "content": "Gophers again"}), http.StatusCreated, "")
+	expect(t, api.do(t, http.MethodPost, "/api/articles/"+hidden+"/hide", api.addUser(t, models.RoleModerator), nil), http.StatusOK, "")
+
+	results, _ := api.search(t, "q=gophers")
+	slices.Sort(results)
+	want := []string{"article:" + article, "comment:" + comment}
+	if !slices.Equal(results, want) {
+		t.Fatalf("results = %v, want %v", results, want)
+	}
+	if results, _ := api.search(t, "q=gophers&type=comment"); !slices.Equal(results, want[1:]) {
+		t.Fatalf("comment results = %v, want %v", results, want[1:])
+	}
+	if results, _ := api.search(t, "q=gophers&author="+author); !slices.Equal(results, want[:1]) {
+		t.Fatalf("results of the author = %v, want %v", results, want[:1])
+	}
+}
+
+func TestSearchEscapesHighlights(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	api.do(t, http.MethodPost, "/api/articles", author, map[string]any{
+		"title":   "XSS",
+		"content": `Beware of <script>alert("x")</script> & <img src=x onerror=alert(1)> tags.`,
+	})
+
+	for _, q := range []string{"script", `<script>alert("x")</script>`, "onerror", `"img src"`, "al*"} {
+		_, snippets := api.search(t, "q="+url.QueryEscape(q))
+		if len(snippets) != 1 {
+			t.Fatalf("q=%s: got %d results, want 1", q, len(snippets))
+		}
+		s := strings.NewReplacer("<mark>", "", "</mark>", "").Replace(snippets[0])
+		if strings.ContainsAny(s, "<>") || !strings.Contains(snippets[0], "<mark>") {
+			t.Errorf("q=%s: snippet %q is not escaped and highlighted", q, snippets[0])
+		}
+	}
+	_, snippets := api.search(t, "q=script")
+	if want := "&lt;<mark>script</mark>&gt;"; !strings.Contains(snippets[0], want) {
+		t.Errorf("snippet %q does not contain %q", snippets[0], want)
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	for query, code := range map[string]string{
+		"":                                "query_required",
+		"q=" + url.QueryEscape(`"" * <>`): "query_required",
+		"q=go&type=user":                  "invalid_type",
+		"q=go&from=yesterday":             "invalid_date",
+		"q=go&to=2024-13-01":              "invalid_date",
+		"q=go&limit=x":                    "invalid_limit",
+	} {
+		t.Run(query, func(t *testing.T) {
+			expect(t, api.do(t, http.MethodGet, "/api/search?"+query, "", nil), http.StatusBadRequest, code)
+		})
+	}
+	if results, _ := api.search(t, "q=go&author="+uuid.NewString()+"&from=2024-01-01&to=2024-02-01T00:00:00Z"); len(results) != 0 {
+		t.Fatalf("results = %v, want none", results)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"blog-api/apperr"
+	"blog-api/middleware"
+	"blog-api/realtime"
+	"blog-api/validate"
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// maxStreamArticles bounds the article channels of one stream.
+	maxStreamArticles = 50
+	// streamKeepAlive is how often an idle stream sends a comment line, which
+	// keeps proxies from closing it and detects clients that went away.
+	streamKeepAlive = 25 * time.Second
+)
+
+// GET /api/stream?articles=id1,id2
+//
+// A server-sent event stream of the new comments ("comment") and like counts
+// ("likes") of the listed articles, plus the caller's notifications
+// ("notification") when authenticated. Articles the caller cannot read, such
+// as others' drafts, are not found. EventSource cannot send headers, so the
+// token may be passed as ?access_token=.
+func (h *Handler) Stream(c *fiber.Ctx) error {
+	var articles []string
+	if raw := c.Query("articles"); raw != "" {
+		for _, id := range strings.Split(raw, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				if err := validate.Var("articles", id, "uuid"); err != nil {
+					return err
+				}
+				articles = append(articles, id)
+			}
+		}
+	}
+	if len(articles) > maxStreamArticles {
+		return apperr.BadRequest("too_many_articles", fmt.Sprintf("A stream can follow at most %d articles", maxStreamArticles))
+	}
+	var channels []string
+	for _, id := range articles {
+		if _, err := h.visibleArticle(c, id); err != nil {
+			return err
+		}
+		channels = append(channels, realtime.ArticleChannel(id))
+	}
+	if userID := middleware.UserID(c); userID != "" {
+		channels = append(channels, realtime.UserChannel(userID))
+	}
+	if len(channels) == 0 {
+		return apperr.BadRequest("nothing_to_stream", "Nothing to stream: pass articles or authenticate")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	// The server's write timeout would cut the stream: each write gets its
+	// own deadline instead. Hub.Close ends the stream on shutdown.
+	conn := c.Context().Conn()
+	writeTimeout := c.App().Config().WriteTimeout
+	sub := h.hub.Subscribe(channels...)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		fmt.Fprint(w, "retry: 3000\n\n")
+		for {
+			if writeTimeout > 0 {
+				conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+			select {
+			case msg, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				data := msg.Data
+				if len(data) == 0 {
+					data = []byte("null")
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Event, data)
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+		}
+	})
+	return nil
+}
+
+// publish broadcasts a live event. Like notifications, a failure only loses
+// the event, so it is logged rather than returned.
+func (h *Handler) publish(ctx context.Context, channel, event string, data interface{}) {
+	msg, err := realtime.NewMessage(channel, event, data)
+	if err == nil {
+		err = h.events.Publish(ctx, msg)
+	}
+	if err != nil {
+		log.Printf("realtime: publishing %s on %s: %v", event, channel, err)
+	}
+}
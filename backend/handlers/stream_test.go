@@ -0,0 +1,72 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"blog-api/realtime"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// next waits for the next message of sub.
+func next(t *testing.T, sub *realtime.Subscription) realtime.Message {
+	t.Helper()
+	select {
+	case msg := <-sub.C:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message")
+		return realtime.Message{}
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	hub := realtime.NewHub()
+	api := newTestAPI(t, handlers.Options{Hub: hub})
+	author, reader := api.addUser(t, models.RoleAuthor), api.addUser(t, models.RoleAuthor)
+	article := api.createArticle(t, author, "Live")
+
+	articleSub := hub.Subscribe(realtime.ArticleChannel(article))
+	defer articleSub.Close()
+	userSub := hub.Subscribe(realtime.UserChannel(author))
+	defer userSub.Close()
+
+	expect(t, api.do(t, http.MethodPost, "/api/likes", reader, map[string]any{"article_id": article}), http.StatusOK, "")
+	msg := next(t, articleSub)
+	var likes map[string]any
+	if err := json.Unmarshal(msg.Data, &likes); err != nil || msg.Event != "likes" || likes["likes"] != 1.0 {
+		t.Fatalf("message = %s %s, want a like count of 1", msg.Event, msg.Data)
+	}
+	msg = next(t, userSub)
+	var notification map[string]any
+	if err := json.Unmarshal(msg.Data, &notification); err != nil || msg.Event != "notification" || notification["unread_count"] != 1.0 {
+		t.Fatalf("message = %s %s, want a notification", msg.Event, msg.Data)
+	}
+
+	r := api.do(t, http.MethodPost, "/api/comments", reader, map[string]any{"article_id": article, "content": "Hi"})
+	expect(t, r, http.StatusCreated, "")
+	if msg = next(t, articleSub); msg.Event != "comment" {
+		t.Fatalf("event = %q, want comment", msg.Event)
+	}
+
+	expect(t, api.do(t, http.MethodDelete, "/api/likes", reader, map[string]any{"article_id": article}), http.StatusOK, "")
+	if msg = next(t, articleSub); msg.Event != "likes" {
+		t.Fatalf("event = %q, want likes", msg.Event)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	many := uuid.NewString()
+	for range 50 {
+		many += "," + uuid.NewString()
+	}
+	expect(t, api.do(t, http.MethodGet, "/api/stream", "", nil), http.StatusBadRequest, "nothing_to_stream")
+	expect(t, api.do(t, http.MethodGet, "/api/stream?articles=,", "", nil), http.StatusBadRequest, "nothing_to_stream")
+	expect(t, api.do(t, http.MethodGet, "/api/stream?articles="+many, "", nil), http.StatusBadRequest, "too_many_articles")
+	expect(t, api.do(t, http.MethodGet, "/api/stream?articles=nope", "", nil), http.StatusUnprocessableEntity, "validation_failed")
+}
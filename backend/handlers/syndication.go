@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"blog-api/apperr"
+	"blog-api/models"
+	"blog-api/store"
+	"blog-api/syndication"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// syndicationSize is the number of articles in every feed.
+const syndicationSize = 50
+
+// feedContentTypes maps the feed extensions to their media types.
+var feedContentTypes = map[string]string{
+	"rss":  "application/rss+xml; charset=utf-8",
+	"atom": "application/atom+xml; charset=utf-8",
+	"json": "application/feed+json; charset=utf-8",
+}
+
+// GET /feeds/articles.{rss,atom,json}?tag=&tag_match=
+//
+// The latest published articles, filtered by tag like GET /api/articles.
+func (h *Handler) GetArticlesFeed(c *fiber.Ctx) error {
+	filter := store.ArticleFilter{Statuses: []string{models.ArticlePublished}}
+	if err := tagFilter(c, &filter); err != nil {
+		return err
+	}
+	return h.sendFeed(c, filter, syndication.Feed{
+		Title:       "Blog",
+		Description: "Latest articles",
+		Link:        h.siteLink(c, "/", "/api/articles"),
+	})
+}
+
+// GET /feeds/authors/:id/articles.{rss,atom,json}
+func (h *Handler) GetAuthorFeed(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.users.Get(c.UserContext(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return errUserNotFound
+	} else if err != nil {
+		return apperr.Internal(err)
+	}
+
+	name := authorName(user.FirstName, user.LastName)
+	if name == "" {
+		name = "Anonymous"
+	}
+	return h.sendFeed(c, store.ArticleFilter{
+		AuthorID: user.ID,
+		Statuses: []string{models.ArticlePublished},
+	}, syndication.Feed{
+		Title:       "Blog - " + name,
+		Description: "Latest articles by " + name,
+		Link:        h.siteLink(c, "/authors/"+url.PathEscape(user.ID), "/api/articles?author="+url.QueryEscape(user.ID)),
+	})
+}
+
+// GET /feeds/tags/:name/articles.{rss,atom,json}
+func (h *Handler) GetTagFeed(c *fiber.Ctx) error {
+	name := normalizeTag(c.Params("name"))
+	if name == "" {
+		return errInvalidTag
+	}
+	return h.sendFeed(c, store.ArticleFilter{
+		Statuses: []string{models.ArticlePublished},
+		Tags:     []string{name},
+	}, syndication.Feed{
+		Title:       "Blog - #" + name,
+		Description: "Latest articles tagged " + name,
+		Link:        h.siteLink(c, "/tags/"+name, "/api/tags/"+name+"/articles"),
+	})
+}
+
+// sendFeed lists the newest articles matching filter into feed and writes it
+// in the format named by the :format parameter. The response carries an ETag
+// of its body and a Last-Modified of its newest article, and conditional
+// requests that match them get a 304 without a body.
+func (h *Handler) sendFeed(c *fiber.Ctx, filter store.ArticleFilter, feed syndication.Feed) error {
+	format := c.Params("format")
+	contentType, ok := feedContentTypes[format]
+	if !ok {
+		return apperr.NotFound("unknown_feed_format", "Unknown feed format")
+	}
+
+	articles, err := h.articles.List(c.UserContext(), filter, store.PageRequest{Limit: syndicationSize})
+	if err != nil {
+		return apperr.Internal(err)
+	}
+
+	feed.FeedURL = c.BaseURL() + c.OriginalURL()
+	for _, a := range articles.Items {
+		published := store.PublishedAt(a)
+		if published.After(feed.Updated) {
+			feed.Updated = published
+		}
+		item := syndication.Item{
+			ID:        a.ID,
+			Title:     a.Title,
+			Link:      h.siteLink(c, "/articles/"+url.PathEscape(a.Slug), "/api/articles/by-slug/"+url.PathEscape(a.Slug)),
+			Summary:   a.Excerpt,
+			Content:   a.ContentHTML,
+			HTML:      true,
+			Tags:      a.Tags,
+			Published: published,
+			Updated:   published,
+		}
+		if a.Author != nil {
+			item.Author = authorName(a.Author.FirstName, a.Author.LastName)
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	var body []byte
+	switch format {
+	case "rss":
+		body, err = feed.RSS()
+	case "atom":
+		body, err = feed.Atom()
+	default:
+		body, err = feed.JSON()
+	}
+	if err != nil {
+		return apperr.Internal(err)
+	}
+
+	// Edits do not move the publication date, so the ETag is what tells
+	// readers that an article changed.
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Set(fiber.HeaderETag, etag)
+	if !feed.Updated.IsZero() {
+		c.Set(fiber.HeaderLastModified, feed.Updated.UTC().Format(http.TimeFormat))
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	if notModified(c, etag, feed.Updated) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Send(body)
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since as RFC 7232
+// does: when the client sends an entity tag, the date is ignored.
+// fiber.Ctx.Fresh answers 304 to any If-Modified-Since, so it is not used.
+func notModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		for _, tag := range strings.Split(noneMatch, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// siteLink returns sitePath on Options.SiteURL, or apiPath on this server
+// when no site URL is configured.
+func (h *Handler) siteLink(c *fiber.Ctx, sitePath, apiPath string) string {
+	if h.siteURL != "" {
+		return h.siteURL + sitePath
+	}
+	return c.BaseURL() + apiPath
+}
+
+// authorName is the public name of an author. Feeds never expose emails.
+func authorName(firstName, lastName string) string {
+	return strings.TrimSpace(firstName + " " + lastName)
+}
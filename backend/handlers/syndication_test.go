@@ -0,0 +1,151 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// get fetches path with the given request headers and returns the response
+// with its body.
+func (a *testAPI) get(t *testing.T, path string, header map[string]string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := a.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestSyndicationFeeds(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{SiteURL: "https://blog.example/"})
+	author := api.addUser(t, models.RoleAuthor)
+	r := api.do(t, http.MethodPost, "/api/articles", author, map[string]any{"title": "Tagged Post", "content": "Body", "tags": []string{"go"}})
+	expect(t, r, http.StatusCreated, "")
+	api.createArticle(t, author, "Untagged")
+	expect(t, api.do(t, http.MethodPost, "/api/articles", author, map[string]any{"title": "Secret", "content": "x", "status": "draft", "tags": []string{"go"}}), http.StatusCreated, "")
+
+	tests := []struct {
+		path        string
+		contentType string
+		contains    []string
+		excludes    []string
+	}{
+		{"/feeds/articles.rss", "application/rss+xml; charset=utf-8", []string{"<rss", "<title>Tagged Post</title>", "<title>Untagged</title>", "https://blog.example/articles/tagged-post"}, []string{"Secret"}},
+		{"/feeds/articles.atom", "application/atom+xml; charset=utf-8", []string{"<feed", "Tagged Post", "Untagged"}, []string{"Secret"}},
+		{"/feeds/articles.json?tag=go", "application/feed+json; charset=utf-8", []string{"jsonfeed.org", "Tagged Post"}, []string{"Untagged", "Secret"}},
+		{"/feeds/tags/go/articles.rss", "application/rss+xml; charset=utf-8", []string{"Tagged Post", "#go"}, []string{"Untagged", "Secret"}},
+		{"/feeds/authors/" + author + "/articles.atom", "application/atom+xml; charset=utf-8", []string{"Tagged Post", "Untagged", "https://blog.example/authors/" + author}, []string{"Secret", "@example.com"}},
+	}
+	for _, tt := range tests {
+		resp, body := api.get(t, tt.path, nil)
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != tt.contentType {
+			t.Errorf("%s = %d %q, want 200 %q", tt.path, resp.StatusCode, resp.Header.Get("Content-Type"), tt.contentType)
+			continue
+		}
+		for _, s := range tt.contains {
+			if !strings.Contains(body, s) {
+				t.Errorf("%s does not contain %q:\n%s", tt.path, s, body)
+			}
+		}
+		for _, s := range tt.excludes {
+			if strings.Contains(body, s) {
+				t.Errorf("%s contains %q:\n%s", tt.path, s, body)
+			}
+		}
+	}
+
+	// Without a site URL, links point at the API.
+	api = newTestAPI(t, handlers.Options{})
+	author = api.addUser(t, models.RoleAuthor)
+	api.createArticle(t, author, "Linked")
+	_, body := api.get(t, "/feeds/articles.json", nil)
+	var feed struct {
+		Items []struct {
+			URL string `json:"url"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(body), &feed); err != nil || len(feed.Items) != 1 || !strings.HasSuffix(feed.Items[0].URL, "/api/articles/by-slug/linked") {
+		t.Fatalf("feed = %s", body)
+	}
+}
+
+func TestSyndicationConditionalGet(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	api.createArticle(t, author, "First")
+
+	resp, _ := api.get(t, "/feeds/articles.rss", nil)
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatalf("headers = %v, want ETag and Last-Modified", resp.Header)
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		status int
+	}{
+		{"matching etag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"weak matching etag in a list", map[string]string{"If-None-Match": `"other", W/` + etag}, http.StatusNotModified},
+		{"star", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"other etag", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"etag wins over date", map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": lastModified}, http.StatusOK},
+		{"same date", map[string]string{"If-Modified-Since": lastModified}, http.StatusNotModified},
+		{"older date", map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, http.StatusOK},
+		{"bad date", map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := api.get(t, "/feeds/articles.rss", tt.header)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status == http.StatusNotModified && body != "" {
+				t.Fatalf("304 with a body: %q", body)
+			}
+		})
+	}
+
+	// Any change to the feed changes its ETag.
+	api.createArticle(t, author, "Second")
+	if resp, _ := api.get(t, "/feeds/articles.rss", map[string]string{"If-None-Match": etag}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d after a new article, want 200", resp.StatusCode)
+	}
+}
+
+func TestSyndicationErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	for path, status := range map[string]int{
+		"/feeds/articles.xml": http.StatusNotFound,
+		"/feeds/authors/" + uuid.NewString() + "/articles.rss": http.StatusNotFound,
+		"/feeds/authors/nope/articles.rss":                     http.StatusUnprocessableEntity,
+		"/feeds/tags/---/articles.rss":                         http.StatusBadRequest,
+		"/feeds/articles.rss?tag_match=some":                   http.StatusBadRequest,
+	} {
+		if resp, _ := api.get(t, path, nil); resp.StatusCode != status {
+			t.Errorf("%s = %d, want %d", path, resp.StatusCode, status)
+		}
+	}
+}
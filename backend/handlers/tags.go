@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"blog-api/apperr"
+	"blog-api/models"
+	"blog-api/slug"
+	"blog-api/store"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// maxTags is the number of tags an article may carry.
+	maxTags = 10
+	// maxTagLength bounds a normalized tag name.
+	maxTagLength = 40
+)
+
+// GET /api/tags
+//
+// Lists the tags of published articles with their usage counts, most used
+// first.
+func (h *Handler) GetTags(c *fiber.Ctx) error {
+	tags, err := h.tags.List(c.UserContext())
+	if err != nil {
+		return apperr.Internal(err)
+	}
+	return c.JSON(tags)
+}
+
+// GET /api/tags/:name/articles?limit=&before=&after=
+func (h *Handler) GetTagArticles(c *fiber.Ctx) error {
+	page, err := parsePage(c)
+	if err != nil {
+		return err
+	}
+
+	name := normalizeTag(c.Params("name"))
+	if name == "" {
+		return errInvalidTag
+	}
+
+	articles, err := h.articles.List(c.UserContext(), store.ArticleFilter{
+		Statuses: []string{models.ArticlePublished},
+		Tags:     []string{name},
+	}, page)
+	if err != nil {
+		return apperr.Internal(err)
+	}
+	return c.JSON(newPageResponse(articles))
+}
+
+// tagFilter reads ?tag=a,b&tag_match=any|all into the filter. It returns a
+// 400 error when the parameters are invalid.
+func tagFilter(c *fiber.Ctx, filter *store.ArticleFilter) error {
+	if raw := c.Query("tag"); raw != "" {
+		tags, err := normalizeTags(strings.Split(raw, ","))
+		if err != nil || len(tags) == 0 {
+			return errInvalidTag
+		}
+		filter.Tags = tags
+	}
+
+	switch c.Query("tag_match", "any") {
+	case "any":
+	case "all":
+		filter.AllTags = true
+	default:
+		return apperr.BadRequest("invalid_tag_match", "tag_match must be any or all")
+	}
+	return nil
+}
+
+// normalizeTags normalizes, deduplicates and sorts tag names. A nil slice
+// stays nil so updates without tags leave them unchanged.
+func normalizeTags(raw []string) ([]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	tags := []string{}
+	seen := make(map[string]bool)
+	for _, r := range raw {
+		name := normalizeTag(r)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		tags = append(tags, name)
+	}
+	if len(tags) > maxTags {
+		return nil, apperr.BadRequest("too_many_tags", fmt.Sprintf("An article can have at most %d tags", maxTags))
+	}
+	slices.Sort(tags)
+	return tags, nil
+}
+
+// normalizeTag lowercases a tag, strips accents and joins its words with
+// dashes, so "Go Lang" and "go-lang" are the same tag.
+func normalizeTag(name string) string {
+	tag := slug.Make(name)
+	if len(tag) > maxTagLength {
+		tag = strings.TrimRight(tag[:maxTagLength], "-")
+	}
+	return tag
+}
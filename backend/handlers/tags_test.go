@@ -0,0 +1,98 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	create := func(title string, tags []string, status string) string {
+		t.Helper()
+		body := map[string]any{"title": title, "content": "x", "tags": tags}
+		if status != "" {
+			body["status"] = status
+		}
+		r := api.do(t, http.MethodPost, "/api/articles", author, body)
+		expect(t, r, http.StatusCreated, "")
+		return r.str("id")
+	}
+	goSQL := create("Go and SQL", []string{"SQL", "Go Lang", "go-lang", " "}, "")
+	goOnly := create("Go", []string{"go_lang"}, "")
+	untagged := create("Untagged", nil, "")
+	create("Draft", []string{"go-lang", "draft-only"}, "draft")
+
+	r := api.do(t, http.MethodGet, "/api/articles/"+goSQL, "", nil)
+	expect(t, r, http.StatusOK, "")
+	if tags := r.body["tags"].([]any); len(tags) != 2 || tags[0] != "go-lang" || tags[1] != "sql" {
+		t.Fatalf("tags = %v, want [go-lang sql]", tags)
+	}
+	r = api.do(t, http.MethodGet, "/api/articles/"+untagged, "", nil)
+	if tags, ok := r.body["tags"].([]any); !ok || len(tags) != 0 {
+		t.Fatalf("tags = %v, want []", r.body["tags"])
+	}
+
+	// Counts only cover published articles.
+	r = api.do(t, http.MethodGet, "/api/tags", "", nil)
+	expect(t, r, http.StatusOK, "")
+	var counts []string
+	for _, tag := range r.list {
+		tag := tag.(map[string]any)
+		counts = append(counts, tag["name"].(string)+":"+strings.Repeat("+", int(tag["articles"].(float64))))
+	}
+	if want := []string{"go-lang:++", "sql:+"}; !slices.Equal(counts, want) {
+		t.Fatalf("tags = %v, want %v", counts, want)
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/api/tags/GO_LANG/articles", []string{goOnly, goSQL}},
+		{"/api/tags/sql/articles", []string{goSQL}},
+		{"/api/tags/draft-only/articles", nil},
+		{"/api/articles?tag=sql,go-lang", []string{goOnly, goSQL}},
+		{"/api/articles?tag=sql,go-lang&tag_match=all", []string{goSQL}},
+		{"/api/articles?tag=sql,unknown&tag_match=all", nil},
+	}
+	for _, tt := range tests {
+		got, _, _ := pageIDs(t, api.do(t, http.MethodGet, tt.path, "", nil))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	// An update without tags keeps them; an empty list removes them.
+	r = api.do(t, http.MethodPut, "/api/articles/"+goSQL, author, map[string]any{"title": "Go and SQL", "content": "y"})
+	if expect(t, r, http.StatusOK, ""); len(r.body["tags"].([]any)) != 2 {
+		t.Fatalf("tags after update = %v, want them kept", r.body["tags"])
+	}
+	r = api.do(t, http.MethodPut, "/api/articles/"+goSQL, author, map[string]any{"title": "Go and SQL", "content": "y", "tags": []string{}})
+	if expect(t, r, http.StatusOK, ""); len(r.body["tags"].([]any)) != 0 {
+		t.Fatalf("tags after update = %v, want none", r.body["tags"])
+	}
+}
+
+func TestTagErrors(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	author := api.addUser(t, models.RoleAuthor)
+	tooMany := make([]string, 11)
+	for i := range tooMany {
+		tooMany[i] = string(rune('a' + i))
+	}
+
+	r := api.do(t, http.MethodPost, "/api/articles", author, map[string]any{"title": "x", "content": "x", "tags": tooMany})
+	expect(t, r, http.StatusBadRequest, "too_many_tags")
+	for path, code := range map[string]string{
+		"/api/tags/---/articles":              "invalid_tag",
+		"/api/articles?tag=,":                 "invalid_tag",
+		"/api/articles?tag=go&tag_match=some": "invalid_tag_match",
+	} {
+		expect(t, api.do(t, http.MethodGet, path, "", nil), http.StatusBadRequest, code)
+	}
+}
@@ -1,127 +1,95 @@
 package handlers
 
 import (
-	"blog-api/db"
+	"blog-api/apperr"
 	"blog-api/models"
-	"database/sql"
+	"blog-api/store"
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
-func CreateUser(c *fiber.Ctx) error {
-	user := new(models.User)
-	if err := c.BodyParser(user); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-
-	if user.Email == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Email is required",
-		})
-	}
-
-	user.ID = uuid.New().String()
-
-	tx, err := db.DB.Begin()
+func (h *Handler) CreateUser(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Could not start transaction",
-		})
-	}
-	defer tx.Rollback()
-
-	var existingID string
-	err = tx.QueryRow("SELECT id FROM users WHERE email = $1", user.Email).Scan(&existingID)
-	if err != nil && err != sql.ErrNoRows {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Database error",
-		})
-	}
-	if existingID != "" {
-		return c.Status(409).JSON(fiber.Map{
-			"error": "Email already exists",
-		})
+		return err
 	}
 
-	_, err = tx.Exec(`
-		INSERT INTO users (id, email, firstname, lastname)
-		VALUES ($1, $2, $3, $4)
-	`, user.ID, user.Email, user.FirstName, user.LastName)
+	var req createUserRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Could not create user",
-		})
+	user := &models.User{
+		ID:        userID,
+		Email:     req.Email,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
 	}
 
-	if err = tx.Commit(); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Could not commit transaction",
-		})
+	err = h.users.Create(c.UserContext(), user)
+	if errors.Is(err, store.ErrConflict) {
+		return apperr.Conflict("email_taken", "Email already exists")
+	} else if err != nil {
+		return apperr.Internal(err)
 	}
 
 	return c.Status(201).JSON(user)
 }
 
-func GetUser(c *fiber.Ctx) error {
-	id := c.Params("id")
-	user := new(models.User)
-
-	err := db.DB.QueryRow(`
-		SELECT id, email, firstname, lastname, created_at
-		FROM users
-		WHERE id = $1
-	`, id).Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.CreatedAt)
+func (h *Handler) GetUser(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
 
-	if err == sql.ErrNoRows {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "User not found",
-		})
+	user, err := h.users.Get(c.UserContext(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return errUserNotFound
 	}
 
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Database error",
-		})
+		return apperr.Internal(err)
 	}
 
 	return c.JSON(user)
 }
 
-func UpdateUser(c *fiber.Ctx) error {
-	id := c.Params("id")
-	user := new(models.User)
-	if err := c.BodyParser(user); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+func (h *Handler) UpdateUser(c *fiber.Ctx) error {
+	userID, err := requireUser(c)
+	if err != nil {
+		return err
 	}
 
-	result, err := db.DB.Exec(`
-		UPDATE users
-		SET firstname = $1, lastname = $2
-		WHERE id = $3
-	`, user.FirstName, user.LastName, id)
-
+	id, err := idParam(c, "id")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Could not update user",
-		})
+		return err
+	}
+	if id != userID {
+		return apperr.Forbidden("forbidden", "You can only update your own profile")
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Could not get affected rows",
-		})
+	var req updateUserRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	user := &models.User{
+		ID:            id,
+		FirstName:     req.FirstName,
+		LastName:      req.LastName,
+		AvatarMediaID: req.AvatarMediaID,
+	}
+	if err := h.ownMedia(c, "avatar_media_id", user.AvatarMediaID, userID); err != nil {
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "User not found",
-		})
+	err = h.users.Update(c.UserContext(), user)
+	if errors.Is(err, store.ErrNotFound) {
+		return errUserNotFound
+	}
+
+	if err != nil {
+		return apperr.Internal(err)
 	}
 
 	return c.JSON(fiber.Map{
@@ -0,0 +1,35 @@
+package handlers_test
+
+import (
+	"blog-api/handlers"
+	"blog-api/models"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUsers(t *testing.T) {
+	api := newTestAPI(t, handlers.Options{})
+	id := uuid.NewString()
+	other := api.addUser(t, models.RoleAuthor)
+
+	// The ID comes from the token, not the body.
+	r := api.do(t, http.MethodPost, "/api/users", id, map[string]any{"id": other, "email": "me@example.com"})
+	expect(t, r, http.StatusCreated, "")
+	if r.str("id") != id {
+		t.Fatalf("created user ID = %q, want the token subject %q", r.str("id"), id)
+	}
+	expect(t, api.do(t, http.MethodPost, "/api/users", uuid.NewString(), map[string]any{"email": "me@example.com"}), http.StatusConflict, "email_taken")
+	expect(t, api.do(t, http.MethodPost, "/api/users", uuid.NewString(), map[string]any{}), http.StatusUnprocessableEntity, "validation_failed")
+	expect(t, api.do(t, http.MethodPost, "/api/users", "", map[string]any{"email": "anon@example.com"}), http.StatusUnauthorized, "unauthenticated")
+
+	update := map[string]any{"firstname": "Ada", "lastname": "Lovelace"}
+	expect(t, api.do(t, http.MethodPut, "/api/users/"+id, other, update), http.StatusForbidden, "forbidden")
+	expect(t, api.do(t, http.MethodPut, "/api/users/"+id, id, update), http.StatusOK, "")
+	r = api.do(t, http.MethodGet, "/api/users/"+id, "", nil)
+	if expect(t, r, http.StatusOK, ""); r.str("firstname") != "Ada" || r.str("email") != "me@example.com" {
+		t.Fatalf("user = %v", r.body)
+	}
+	expect(t, api.do(t, http.MethodGet, "/api/users/"+uuid.NewString(), "", nil), http.StatusNotFound, "user_not_found")
+}
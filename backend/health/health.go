@@ -0,0 +1,147 @@
+// Package health tells an orchestrator whether the server is alive, whether
+// it is ready to serve, and which build it runs.
+//
+// Liveness only says that the process answers. Readiness runs checks, such
+// as pinging the database, each bounded by a timeout, and fails from the
+// shutdown signal on so that load balancers stop sending requests.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultTimeout bounds a check when Checker.Timeout is zero.
+const DefaultTimeout = 2 * time.Second
+
+// Status values of reports and check results.
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Check is one condition of readiness.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration_ms"`
+}
+
+// Report is the body of /readyz.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Checker runs the readiness checks.
+type Checker struct {
+	// Timeout bounds each check. It defaults to DefaultTimeout.
+	Timeout time.Duration
+
+	checks   []Check
+	shutdown <-chan struct{}
+}
+
+// New returns a checker running checks until shutdown is closed, after
+// which the server is never ready again.
+func New(shutdown <-chan struct{}, checks ...Check) *Checker {
+	return &Checker{checks: checks, shutdown: shutdown}
+}
+
+// Run runs every check concurrently and reports their results.
+func (ch *Checker) Run(ctx context.Context) Report {
+	select {
+	case <-ch.shutdown:
+		return Report{Status: StatusShuttingDown}
+	default:
+	}
+
+	timeout := ch.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	results := make([]Result, len(ch.checks))
+	var wg sync.WaitGroup
+	for i, check := range ch.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = run(ctx, check, timeout)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(ch.checks))}
+	for i, check := range ch.checks {
+		report.Checks[check.Name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// run runs check within timeout. A check that ignores its context is
+// reported as failed when the timeout expires, and left to finish on its
+// own.
+func run(ctx context.Context, check Check, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check.Run(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := Result{Status: StatusOK, Duration: time.Since(start).Milliseconds()}
+	if err != nil {
+		res.Status, res.Error = StatusFail, err.Error()
+	}
+	return res
+}
+
+// Register mounts /healthz, /readyz and /version on router. database is
+// queried for its schema version.
+func (ch *Checker) Register(router fiber.Router, database *sql.DB) {
+	router.Get("/healthz", Live)
+	router.Get("/readyz", ch.Ready)
+	router.Get("/version", Version(database))
+}
+
+// GET /healthz
+//
+// Liveness: 200 as long as the process serves requests.
+func Live(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(Report{Status: StatusOK})
+}
+
+// GET /readyz
+//
+// Readiness: 200 when every check passes, 503 otherwise or once shutting
+// down, with the result of each check.
+func (ch *Checker) Ready(c *fiber.Ctx) error {
+	report := ch.Run(c.UserContext())
+	status := fiber.StatusOK
+	if report.Status != StatusOK {
+		status = fiber.StatusServiceUnavailable
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).JSON(report)
+}
@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ok(ctx context.Context) error { return nil }
+
+func TestRun(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	tests := []struct {
+		name   string
+		checks []Check
+		status string
+		failed map[string]string
+	}{
+		{"no checks", nil, StatusOK, nil},
+		{"passing", []Check{{"db", ok}, {"cache", ok}}, StatusOK, nil},
+		{"failing", []Check{{"db", ok}, {"cache", func(ctx context.Context) error { return errors.New("down") }}}, StatusFail, map[string]string{"cache": "down"}},
+		{"timing out", []Check{{"db", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}}, StatusFail, map[string]string{"db": context.DeadlineExceeded.Error()}},
+		{"ignoring its context", []Check{{"db", func(ctx context.Context) error {
+			<-block
+			return nil
+		}}}, StatusFail, map[string]string{"db": context.DeadlineExceeded.Error()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := New(nil, tt.checks...)
+			ch.Timeout = 20 * time.Millisecond
+			report := ch.Run(context.Background())
+			if report.Status != tt.status || len(report.Checks) != len(tt.checks) {
+				t.Fatalf("report = %+v, want %s for %d checks", report, tt.status, len(tt.checks))
+			}
+			for name, res := range report.Checks {
+				if want, failed := tt.failed[name]; failed != (res.Status == StatusFail) || res.Error != want {
+					t.Errorf("%s = %+v, want error %q", name, res, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunShuttingDown(t *testing.T) {
+	shutdown := make(chan struct{})
+	ch := New(shutdown, Check{"db", ok})
+	if r := ch.Run(context.Background()); r.Status != StatusOK {
+		t.Fatalf("before shutdown = %+v", r)
+	}
+	close(shutdown)
+	if r := ch.Run(context.Background()); r.Status != StatusShuttingDown || r.Checks != nil {
+		t.Fatalf("after shutdown = %+v", r)
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	healthy := true
+	ch := New(nil, Check{"db", func(ctx context.Context) error {
+		if !healthy {
+			return errors.New("down")
+		}
+		return nil
+	}})
+	app := fiber.New()
+	app.Get("/healthz", Live)
+	app.Get("/readyz", ch.Ready)
+
+	get := func(path string) (int, Report) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.Header.Get(fiber.HeaderCacheControl) != "no-store" {
+			t.Errorf("%s is cacheable", path)
+		}
+		var report Report
+		if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, report
+	}
+
+	if status, r := get("/readyz"); status != http.StatusOK || r.Checks["db"].Status != StatusOK {
+		t.Fatalf("ready = %d %+v", status, r)
+	}
+	healthy = false
+	if status, r := get("/readyz"); status != http.StatusServiceUnavailable || r.Checks["db"].Error != "down" {
+		t.Fatalf("not ready = %d %+v", status, r)
+	}
+	// Liveness does not depend on the checks.
+	if status, r := get("/healthz"); status != http.StatusOK || r.Status != StatusOK {
+		t.Fatalf("live = %d %+v", status, r)
+	}
+}
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"blog-api/db"
+	"context"
+	"database/sql"
+	"log"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Commit and BuildTime describe the build. They may be set with
+//
+//	go build -ldflags "-X blog-api/health.Commit=$(git rev-parse HEAD) -X blog-api/health.BuildTime=$(date -u +%FT%TZ)"
+//
+// Commit otherwise defaults to the version control information recorded by
+// go build. BuildTime has no such default: go build only records the time of
+// the commit, reported as the commit time.
+var (
+	Commit    string
+	BuildTime string
+)
+
+// schemaTimeout bounds the query of the schema version.
+const schemaTimeout = 2 * time.Second
+
+// VersionInfo is the body of /version. BuildTime is empty unless set with
+// -ldflags, and SchemaVersion is null when the database could not be queried.
+type VersionInfo struct {
+	Commit              string `json:"commit"`
+	CommitTime          string `json:"commit_time"`
+	BuildTime           string `json:"build_time"`
+	GoVersion           string `json:"go_version"`
+	SchemaVersion       *int   `json:"schema_version"`
+	LatestSchemaVersion int    `json:"latest_schema_version"`
+}
+
+// build reads Commit, falling back to the build information, which also
+// gives the commit time unless Commit is set. A commit built with uncommitted
+// changes ends with "-dirty".
+func build() (commit, commitTime string) {
+	commit = Commit
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return commit, ""
+	}
+	var modified bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if commit == "" {
+				commit = s.Value
+			}
+		case "vcs.time":
+			if Commit == "" {
+				commitTime = s.Value
+			}
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if modified && Commit == "" && commit != "" {
+		commit += "-dirty"
+	}
+	return commit, commitTime
+}
+
+// GET /version
+//
+// The commit, commit time, build time and Go version of the binary, with the schema
+// version of the database and the latest one the binary embeds.
+func Version(database *sql.DB) fiber.Handler {
+	commit, commitTime := build()
+	latest, err := db.LatestVersion()
+	if err != nil {
+		log.Printf("version: reading embedded migrations: %v", err)
+	}
+	return func(c *fiber.Ctx) error {
+		info := VersionInfo{
+			Commit:              commit,
+			CommitTime:          commitTime,
+			BuildTime:           BuildTime,
+			GoVersion:           runtime.Version(),
+			LatestSchemaVersion: latest,
+		}
+		ctx, cancel := context.WithTimeout(c.UserContext(), schemaTimeout)
+		defer cancel()
+		if v, err := db.SchemaVersion(ctx, database); err != nil {
+			log.Printf("version: reading schema version: %v", err)
+		} else {
+			info.SchemaVersion = &v
+		}
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return c.JSON(info)
+	}
+}
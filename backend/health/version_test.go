@@ -0,0 +1,47 @@
+package health
+
+import (
+	"blog-api/db"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	_ "github.com/lib/pq"
+)
+
+func TestVersion(t *testing.T) {
+	// Nothing listens on port 1: the schema version cannot be read.
+	database, err := sql.Open("postgres", "host=127.0.0.1 port=1 user=blog dbname=blog sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	defer func(commit, buildTime string) { Commit, BuildTime = commit, buildTime }(Commit, BuildTime)
+	Commit, BuildTime = "abc123", "2026-10-18T08:00:00Z"
+	app := fiber.New()
+	app.Get("/version", Version(database))
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/version", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var info VersionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	latest, err := db.LatestVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A commit set with -ldflags comes without a commit time.
+	want := VersionInfo{Commit: "abc123", BuildTime: "2026-10-18T08:00:00Z", GoVersion: runtime.Version(), LatestSchemaVersion: latest}
+	if resp.StatusCode != http.StatusOK || info != want || latest == 0 {
+		t.Fatalf("version = %d %+v, want %+v", resp.StatusCode, info, want)
+	}
+}
@@ -0,0 +1,215 @@
+// Package idempotency makes retried create requests safe.
+//
+// A client sends the same Idempotency-Key header with every attempt of a
+// request. The first attempt runs and its response is stored under the key
+// and the user; later attempts get that response back instead of creating
+// the resource again. Records live in a Store: Memory for a single
+// instance, Postgres to share them between instances.
+package idempotency
+
+import (
+	"blog-api/apperr"
+	"blog-api/middleware"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Header is the request header carrying the key, and ReplayedHeader the
+// response header set on replayed responses.
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxKeyLength bounds the keys clients may send; UUIDs are typical.
+const maxKeyLength = 255
+
+// Default settings used for the zero fields of Config.
+const (
+	DefaultExpiry      = 24 * time.Hour
+	DefaultWait        = 10 * time.Second
+	DefaultLockTimeout = time.Minute
+)
+
+// pollInterval is how often a duplicate request checks whether the first
+// one has finished.
+const pollInterval = 50 * time.Millisecond
+
+// Record is a key and the response of the request that first used it.
+type Record struct {
+	Key string
+	// Fingerprint identifies the method, path and body of the request.
+	Fingerprint string
+	// Status is zero while the first request is in progress.
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Done reports whether the response of the record is stored.
+func (r *Record) Done() bool {
+	return r.Status != 0
+}
+
+// Store keeps the records.
+type Store interface {
+	// Claim stores rec, in progress, unless a record with the same key
+	// exists that has not expired by rec.CreatedAt and, when still in
+	// progress, was created after staleBefore. It returns that record
+	// instead, or nil when rec was stored.
+	Claim(ctx context.Context, rec Record, staleBefore time.Time) (*Record, error)
+	// Complete stores the response of the record claimed for rec.Key.
+	Complete(ctx context.Context, rec Record) error
+	// Release deletes the record claimed for key, so that the request can be
+	// tried again.
+	Release(ctx context.Context, key string) error
+}
+
+// Config tunes the middleware.
+type Config struct {
+	// Expiry is how long a key is remembered. It defaults to DefaultExpiry.
+	Expiry time.Duration
+	// Wait is how long a duplicate of a request in progress waits for its
+	// response before getting a 409. It defaults to DefaultWait.
+	Wait time.Duration
+	// LockTimeout is how long a request may stay in progress before its key
+	// is considered abandoned, say by a crashed instance, and claimed again.
+	// It defaults to DefaultLockTimeout.
+	LockTimeout time.Duration
+}
+
+var (
+	errInvalidKey = apperr.BadRequest("invalid_idempotency_key", "Idempotency-Key must be 1 to 255 printable characters")
+	errKeyReused  = apperr.New(fiber.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key was already used for a different request")
+	errKeyInUse   = apperr.Conflict("idempotency_key_in_use", "A request with this Idempotency-Key is still in progress")
+)
+
+// New returns a middleware honouring the Idempotency-Key header on the
+// requests it handles. Requests without the header, or from anonymous
+// clients, go through untouched. Only responses with a 2xx status are
+// stored: after an error, the request can be retried with the same key.
+// Mount it after the middlewares that may refuse the request, such as
+// permission checks.
+func New(store Store, cfg Config) fiber.Handler {
+	if cfg.Expiry == 0 {
+		cfg.Expiry = DefaultExpiry
+	}
+	if cfg.Wait == 0 {
+		cfg.Wait = DefaultWait
+	}
+	if cfg.LockTimeout == 0 {
+		cfg.LockTimeout = DefaultLockTimeout
+	}
+
+	return func(c *fiber.Ctx) error {
+		header := c.Get(Header)
+		userID := middleware.UserID(c)
+		if header == "" || userID == "" {
+			return c.Next()
+		}
+		if !validKey(header) {
+			return errInvalidKey
+		}
+
+		rec := Record{
+			Key:         userID + ":" + header,
+			Fingerprint: fingerprint(c),
+		}
+		deadline := time.Now().Add(cfg.Wait)
+		for {
+			now := time.Now()
+			rec.CreatedAt, rec.ExpiresAt = now, now.Add(cfg.Expiry)
+			existing, err := store.Claim(c.UserContext(), rec, now.Add(-cfg.LockTimeout))
+			if err != nil {
+				return apperr.Internal(err)
+			}
+			if existing == nil {
+				return run(c, store, rec)
+			}
+			if existing.Fingerprint != rec.Fingerprint {
+				return errKeyReused
+			}
+			if existing.Done() {
+				c.Set(ReplayedHeader, "true")
+				c.Set(fiber.HeaderContentType, existing.ContentType)
+				return c.Status(existing.Status).Send(existing.Body)
+			}
+			if now.After(deadline) {
+				return errKeyInUse
+			}
+			select {
+			case <-c.UserContext().Done():
+				return errKeyInUse
+			case <-time.After(pollInterval):
+			}
+		}
+	}
+}
+
+// run handles the request that claimed rec and stores its response, unless
+// it failed.
+func run(c *fiber.Ctx, store Store, rec Record) error {
+	// context.WithoutCancel keeps the record consistent when the client
+	// goes away before the handler is done.
+	ctx := context.WithoutCancel(c.UserContext())
+	completed := false
+	defer func() {
+		if !completed {
+			store.Release(ctx, rec.Key)
+		}
+	}()
+
+	if err := c.Next(); err != nil {
+		return err
+	}
+
+	status := c.Response().StatusCode()
+	if status < fiber.StatusOK || status >= fiber.StatusMultipleChoices {
+		return nil
+	}
+	rec.Status = status
+	rec.ContentType = string(c.Response().Header.ContentType())
+	rec.Body = append([]byte(nil), c.Response().Body()...)
+	if err := store.Complete(ctx, rec); err != nil {
+		// The response is sent anyway; releasing the key lets a retry run
+		// the request again, as without the header.
+		log.Printf("[%s] idempotency: %v", apperr.RequestID(c), err)
+		return nil
+	}
+	completed = true
+	return nil
+}
+
+// fingerprint hashes the method, path and body of the request. Multipart
+// bodies are left out: clients pick a new boundary on every attempt.
+func fingerprint(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(c.Path()))
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		h.Write([]byte{0})
+		h.Write(c.Body())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func validKey(key string) bool {
+	if len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,288 @@
+package idempotency_test
+
+import (
+	"blog-api/apperr"
+	"blog-api/idempotency"
+	"blog-api/middleware"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var secret = []byte("test-secret")
+
+// testApp serves POST /items behind the middleware, answering with the
+// number of times the handler ran.
+type testApp struct {
+	app   *fiber.App
+	calls atomic.Int32
+	// handle, when set, runs instead of the default 201.
+	handle func(c *fiber.Ctx) error
+}
+
+func newTestApp(t *testing.T, store idempotency.Store, cfg idempotency.Config) *testApp {
+	t.Helper()
+	verifier, err := middleware.NewVerifier(middleware.AuthConfig{HS256Secret: secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &testApp{app: fiber.New(fiber.Config{ErrorHandler: apperr.Handler})}
+	a.app.Post("/items", middleware.Auth(verifier), idempotency.New(store, cfg), func(c *fiber.Ctx) error {
+		n := a.calls.Add(1)
+		if a.handle != nil {
+			return a.handle(c)
+		}
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"call": n})
+	})
+	return a
+}
+
+type response struct {
+	status   int
+	replayed string
+	body     string
+}
+
+// post sends body to /items as userID, anonymously when it is empty, with
+// key as the Idempotency-Key unless empty.
+func (a *testApp) post(t *testing.T, userID, key, body string) response {
+	t.Helper()
+	r, err := a.send(userID, key, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// send is post for other goroutines than the test's.
+func (a *testApp) send(userID, key, body string) (response, error) {
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(idempotency.Header, key)
+	}
+	if userID != "" {
+		claims := jwt.MapClaims{"sub": userID, "exp": time.Now().Add(time.Hour).Unix()}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		if err != nil {
+			return response{}, err
+		}
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	resp, err := a.app.Test(req, -1)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response{}, err
+	}
+	return response{status: resp.StatusCode, replayed: resp.Header.Get(idempotency.ReplayedHeader), body: string(data)}, nil
+}
+
+func code(t *testing.T, r response) string {
+	t.Helper()
+	var problem struct{ Code string }
+	if err := json.Unmarshal([]byte(r.body), &problem); err != nil {
+		t.Fatalf("response is not a problem: %s", r.body)
+	}
+	return problem.Code
+}
+
+func TestReplay(t *testing.T) {
+	a := newTestApp(t, idempotency.NewMemory(), idempotency.Config{})
+
+	first := a.post(t, "alice", "key-1", `{"name":"x"}`)
+	if first.status != fiber.StatusCreated || first.replayed != "" || first.body != `{"call":1}` {
+		t.Fatalf("first = %+v, want a fresh 201", first)
+	}
+	again := a.post(t, "alice", "key-1", `{"name":"x"}`)
+	if again.status != fiber.StatusCreated || again.replayed != "true" || again.body != first.body {
+		t.Fatalf("retry = %+v, want the first response replayed", again)
+	}
+	if n := a.calls.Load(); n != 1 {
+		t.Fatalf("handler ran %d times, want once", n)
+	}
+
+	// Keys are per user, and only apply with the header.
+	if r := a.post(t, "bob", "key-1", `{"name":"x"}`); r.replayed != "" || r.body != `{"call":2}` {
+		t.Fatalf("other user = %+v, want a fresh response", r)
+	}
+	if r := a.post(t, "alice", "", `{"name":"x"}`); r.replayed != "" || r.body != `{"call":3}` {
+		t.Fatalf("without a key = %+v, want a fresh response", r)
+	}
+	if r := a.post(t, "", "key-1", `{"name":"x"}`); r.replayed != "" || r.body != `{"call":4}` {
+		t.Fatalf("anonymous = %+v, want a fresh response", r)
+	}
+}
+
+func TestKeyReusedWithAnotherBody(t *testing.T) {
+	a := newTestApp(t, idempotency.NewMemory(), idempotency.Config{})
+	a.post(t, "alice", "key-1", `{"name":"x"}`)
+
+	r := a.post(t, "alice", "key-1", `{"name":"y"}`)
+	if r.status != fiber.StatusUnprocessableEntity || code(t, r) != "idempotency_key_reused" {
+		t.Fatalf("reuse = %+v, want a 422 idempotency_key_reused", r)
+	}
+	if n := a.calls.Load(); n != 1 {
+		t.Fatalf("handler ran %d times, want once", n)
+	}
+}
+
+func TestInvalidKey(t *testing.T) {
+	a := newTestApp(t, idempotency.NewMemory(), idempotency.Config{})
+	for _, key := range []string{strings.Repeat("k", 256), "key\x01", "clé"} {
+		r := a.post(t, "alice", key, `{}`)
+		if r.status != fiber.StatusBadRequest || code(t, r) != "invalid_idempotency_key" {
+			t.Errorf("key %q = %+v, want a 400 invalid_idempotency_key", key, r)
+		}
+	}
+	if n := a.calls.Load(); n != 0 {
+		t.Fatalf("handler ran %d times, want never", n)
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	a := newTestApp(t, idempotency.NewMemory(), idempotency.Config{Wait: 100 * time.Millisecond})
+	started, release := make(chan struct{}), make(chan struct{})
+	a.handle = func(c *fiber.Ctx) error {
+		close(started)
+		<-release
+		return c.Status(fiber.StatusCreated).SendString("created")
+	}
+	done := make(chan response)
+	go func() {
+		r, _ := a.send("alice", "key-1", `{}`)
+		done <- r
+	}()
+	<-started
+
+	r := a.post(t, "alice", "key-1", `{}`)
+	if r.status != fiber.StatusConflict || code(t, r) != "idempotency_key_in_use" {
+		t.Fatalf("duplicate = %+v, want a 409 idempotency_key_in_use", r)
+	}
+
+	close(release)
+	if first := <-done; first.status != fiber.StatusCreated || first.replayed != "" {
+		t.Fatalf("first = %+v, want a fresh 201", first)
+	}
+	if r := a.post(t, "alice", "key-1", `{}`); r.status != fiber.StatusCreated || r.replayed != "true" || r.body != "created" {
+		t.Fatalf("retry = %+v, want the first response replayed", r)
+	}
+}
+
+func TestInFlightWaits(t *testing.T) {
+	a := newTestApp(t, idempotency.NewMemory(), idempotency.Config{Wait: 5 * time.Second})
+	started, release := make(chan struct{}), make(chan struct{})
+	a.handle = func(c *fiber.Ctx) error {
+		close(started)
+		<-release
+		return c.Status(fiber.StatusCreated).SendString("created")
+	}
+	go a.send("alice", "key-1", `{}`)
+	<-started
+
+	// The duplicate polls until the first request completes.
+	time.AfterFunc(150*time.Millisecond, func() { close(release) })
+	r := a.post(t, "alice", "key-1", `{}`)
+	if r.status != fiber.StatusCreated || r.replayed != "true" || r.body != "created" {
+		t.Fatalf("duplicate = %+v, want the first response replayed", r)
+	}
+	if n := a.calls.Load(); n != 1 {
+		t.Fatalf("handler ran %d times, want once", n)
+	}
+}
+
+func TestErrorsAreNotStored(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle func(c *fiber.Ctx) error
+		status int
+	}{
+		{"server error", func(c *fiber.Ctx) error { return apperr.Internal(io.ErrUnexpectedEOF) }, fiber.StatusInternalServerError},
+		{"rate limited", func(c *fiber.Ctx) error { return apperr.New(fiber.StatusTooManyRequests, "rate_limited", "Slow down") }, fiber.StatusTooManyRequests},
+		{"client error", func(c *fiber.Ctx) error { return apperr.BadRequest("invalid_body", "Invalid request body") }, fiber.StatusBadRequest},
+		{"forbidden", func(c *fiber.Ctx) error { return apperr.Forbidden("forbidden", "Not allowed") }, fiber.StatusForbidden},
+		{"error status", func(c *fiber.Ctx) error { return c.Status(fiber.StatusConflict).SendString("taken") }, fiber.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t, idempotency.NewMemory(), idempotency.Config{})
+			a.handle = tt.handle
+
+			// Every attempt runs the request again.
+			for range 2 {
+				if r := a.post(t, "alice", "key-1", `{}`); r.status != tt.status || r.replayed != "" {
+					t.Fatalf("attempt = %+v, want a %d not replayed", r, tt.status)
+				}
+			}
+			if a.calls.Load() != 2 {
+				t.Fatalf("handler ran %d times, want twice", a.calls.Load())
+			}
+		})
+	}
+}
+
+func TestMemory(t *testing.T) {
+	m := idempotency.NewMemory()
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rec := idempotency.Record{Key: "alice:k", Fingerprint: "f", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	claim := func(at time.Time, lockTimeout time.Duration) *idempotency.Record {
+		t.Helper()
+		r := rec
+		r.CreatedAt, r.ExpiresAt = at, at.Add(time.Hour)
+		existing, err := m.Claim(ctx, r, at.Add(-lockTimeout))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return existing
+	}
+
+	if existing := claim(now, time.Minute); existing != nil {
+		t.Fatalf("first claim = %+v, want nil", existing)
+	}
+	if existing := claim(now.Add(time.Second), time.Minute); existing == nil || existing.Done() {
+		t.Fatalf("second claim = %+v, want the record in progress", existing)
+	}
+
+	// Release frees a key in progress.
+	if err := m.Release(ctx, rec.Key); err != nil {
+		t.Fatal(err)
+	}
+	if existing := claim(now.Add(2*time.Second), time.Minute); existing != nil {
+		t.Fatalf("claim after release = %+v, want nil", existing)
+	}
+
+	// A claim older than the lock timeout is taken over.
+	if existing := claim(now.Add(2*time.Minute), time.Minute); existing != nil {
+		t.Fatalf("claim of a stale record = %+v, want nil", existing)
+	}
+
+	done := rec
+	done.Status, done.ContentType, done.Body = fiber.StatusCreated, "text/plain", []byte("ok")
+	if err := m.Complete(ctx, done); err != nil {
+		t.Fatal(err)
+	}
+	// Release leaves completed records alone, whatever their age.
+	m.Release(ctx, rec.Key)
+	existing := claim(now.Add(30*time.Minute), time.Minute)
+	if existing == nil || existing.Status != fiber.StatusCreated || string(existing.Body) != "ok" {
+		t.Fatalf("claim of a completed record = %+v, want the response", existing)
+	}
+
+	// Records are forgotten once they expire.
+	if existing := claim(now.Add(3*time.Hour), time.Minute); existing != nil {
+		t.Fatalf("claim after expiry = %+v, want nil", existing)
+	}
+}
@@ -0,0 +1,65 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Memory forgets the expired records.
+const sweepInterval = time.Minute
+
+// Memory keeps the records of a single instance.
+type Memory struct {
+	mu        sync.Mutex
+	records   map[string]Record
+	lastSweep time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{records: make(map[string]Record)}
+}
+
+func (m *Memory) Claim(ctx context.Context, rec Record, staleBefore time.Time) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := rec.CreatedAt
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		for key, r := range m.records {
+			if !now.Before(r.ExpiresAt) {
+				delete(m.records, key)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	if existing, ok := m.records[rec.Key]; ok && now.Before(existing.ExpiresAt) &&
+		(existing.Done() || existing.CreatedAt.After(staleBefore)) {
+		return &existing, nil
+	}
+	rec.Status, rec.ContentType, rec.Body = 0, "", nil
+	m.records[rec.Key] = rec
+	return nil, nil
+}
+
+func (m *Memory) Complete(ctx context.Context, rec Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.records[rec.Key]; ok && existing.Fingerprint == rec.Fingerprint {
+		existing.Status, existing.ContentType, existing.Body = rec.Status, rec.ContentType, rec.Body
+		m.records[rec.Key] = existing
+	}
+	return nil
+}
+
+func (m *Memory) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.records[key]; ok && !existing.Done() {
+		delete(m.records, key)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package idempotency
+
+import (
+	"blog-api/worker"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// claimAttempts bounds the retries of Claim when the record it conflicts
+// with disappears before it can be read.
+const claimAttempts = 3
+
+// Postgres keeps the records in the idempotency_keys table, shared by every
+// instance using the same database.
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (s *Postgres) Claim(ctx context.Context, rec Record, staleBefore time.Time) (*Record, error) {
+	for attempt := 0; ; attempt++ {
+		var key string
+		err := s.db.QueryRowContext(ctx, `
+			INSERT INTO idempotency_keys AS k (key, fingerprint, created_at, expires_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (key) DO UPDATE
+			SET fingerprint = EXCLUDED.fingerprint, status = NULL, content_type = NULL, body = NULL,
+			    created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+			WHERE k.expires_at <= EXCLUDED.created_at OR (k.status IS NULL AND k.created_at <= $5)
+			RETURNING key
+		`, rec.Key, rec.Fingerprint, rec.CreatedAt, rec.ExpiresAt, staleBefore).Scan(&key)
+		if err == nil {
+			return nil, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		existing := Record{Key: rec.Key}
+		var status sql.NullInt64
+		var contentType sql.NullString
+		err = s.db.QueryRowContext(ctx, `
+			SELECT fingerprint, status, content_type, body, created_at, expires_at
+			FROM idempotency_keys
+			WHERE key = $1
+		`, rec.Key).Scan(&existing.Fingerprint, &status, &contentType, &existing.Body, &existing.CreatedAt, &existing.ExpiresAt)
+		if err == sql.ErrNoRows && attempt+1 < claimAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		existing.Status = int(status.Int64)
+		existing.ContentType = contentType.String
+		return &existing, nil
+	}
+}
+
+func (s *Postgres) Complete(ctx context.Context, rec Record) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status = $2, content_type = $3, body = $4
+		WHERE key = $1 AND fingerprint = $5
+	`, rec.Key, rec.Status, rec.ContentType, rec.Body, rec.Fingerprint)
+	return err
+}
+
+func (s *Postgres) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND status IS NULL`, key)
+	return err
+}
+
+// Prune deletes the records expired as of now and returns how many it
+// deleted.
+func (s *Postgres) Prune(ctx context.Context, now time.Time) (int, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// PruneJob runs Prune periodically.
+func (s *Postgres) PruneJob(interval time.Duration) worker.Job {
+	return worker.Job{
+		Name:     "prune-idempotency-keys",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			_, err := s.Prune(ctx, time.Now())
+			return err
+		},
+	}
+}
@@ -1,78 +1,189 @@
 package main
 
 import (
+	"blog-api/apperr"
+	"blog-api/config"
 	"blog-api/db"
 	"blog-api/handlers"
+	"blog-api/health"
+	"blog-api/idempotency"
+	"blog-api/media"
+	"blog-api/middleware"
+	"blog-api/ratelimit"
+	"blog-api/realtime"
+	"blog-api/server"
+	"blog-api/store/postgres"
+	"blog-api/worker"
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/joho/godotenv"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	db.Init()
-
-	app := fiber.New()
-
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept",
-	}))
+	if len(args) > 0 && args[0] == "migrate" {
+		runMigrate(cfg, args[1:])
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
 
-	api := app.Group("/api")
+	db.Init(cfg.Database)
+	defer db.DB.Close()
 
-	// Users routes
-	users := api.Group("/users")
-	users.Post("/", handlers.CreateUser)
-	users.Get("/:id", handlers.GetUser)
-	users.Put("/:id", handlers.UpdateUser)
+	verifier, err := middleware.NewVerifier(middleware.AuthConfig{
+		HS256Secret: []byte(cfg.Auth.JWTSecret),
+		JWKSFile:    cfg.Auth.JWKSFile,
+		Audience:    cfg.Auth.Audience,
+	})
+	if err != nil {
+		log.Fatal("Auth configuration error: ", err)
+	}
 
+	opts := handlers.Options{
+		MaxCommentDepth:   cfg.CommentMaxDepth,
+		MaxUploadSize:     cfg.Media.MaxUploadSize,
+		SiteURL:           cfg.SiteURL,
+		IdempotencyExpiry: cfg.IdempotencyExpiry,
+	}
 
-	// Articles routes
-	articles := api.Group("/articles")
-	articles.Get("/", handlers.GetArticles)
-	articles.Get("/:id", handlers.GetArticle)
-	articles.Post("/", handlers.CreateArticle)
-	articles.Put("/:id", handlers.UpdateArticle)
-	articles.Delete("/:id", handlers.DeleteArticle)
+	app := fiber.New(fiber.Config{
+		// fasthttp reads bodies up to this limit, refusing larger ones before
+		// buffering them; it leaves room for the multipart envelope of an
+		// upload. server.BodyLimit then applies HTTP_BODY_LIMIT to the other
+		// requests, once their body is read.
+		BodyLimit:    max(int(opts.MaxUploadSize)+1<<20, cfg.Server.BodyLimit),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+		ErrorHandler: apperr.Handler,
+		// Behind a reverse proxy, rate limits key anonymous clients on the
+		// address it forwards, such as X-Forwarded-For, but only when the
+		// request comes from the proxy: anyone else could send the header.
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: cfg.ProxyHeader != "",
+		TrustedProxies:          cfg.TrustedProxies,
+	})
+
+	app.Use(requestid.New())
+	app.Use(recover.New())
+	app.Use(server.BodyLimit(cfg.Server.BodyLimit, "POST /api/media"))
 
-	// Comments routes
-	comments := api.Group("/comments")
-	comments.Get("/article/:id", handlers.GetArticleComments)
-	comments.Post("/", handlers.CreateComment)
-	comments.Put("/:id", handlers.UpdateComment)
-	comments.Delete("/:id", handlers.DeleteComment)
+	app.Use(cors.New(cors.Config{
+		AllowOrigins:  strings.Join(cfg.CORSAllowOrigins, ","),
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, Idempotency-Key",
+		ExposeHeaders: "X-Request-ID, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, Idempotent-Replayed",
+	}))
 
-	// Favorites routes
-	favorites := api.Group("/favorites")
-	favorites.Get("/user/:id", handlers.GetUserFavorites)
-	favorites.Post("/", handlers.AddFavorite)
-	favorites.Delete("/:id", handlers.RemoveFavorite)
+	api := app.Group("/api", middleware.Auth(verifier))
 
-	// Followers routes
-	followers := api.Group("/followers")
-	followers.Get("/:userId", handlers.GetUserFollowers)
-	followers.Get("/following/:userId", handlers.GetUserFollowing)
-	followers.Post("/", handlers.Follow)
-	followers.Delete("/", handlers.Unfollow)
+	opts.Hub = realtime.NewHub()
+	if cfg.RealtimeBroadcaster == "postgres" {
+		broadcaster, err := realtime.NewPostgres(db.DB, cfg.Database.DSN(), opts.Hub)
+		if err != nil {
+			log.Fatal("Realtime broadcaster error: ", err)
+		}
+		defer broadcaster.Close()
+		opts.Broadcaster = broadcaster
+	}
+	switch cfg.Media.Storage {
+	case "local":
+		baseURL := cfg.Media.URL
+		if baseURL == "" {
+			baseURL = "/uploads"
+			app.Static("/uploads", cfg.Media.Dir, fiber.Static{MaxAge: 365 * 24 * 3600})
+		}
+		if opts.MediaStorage, err = media.NewLocal(cfg.Media.Dir, baseURL); err != nil {
+			log.Fatal("Media storage error: ", err)
+		}
+	case "s3":
+		if opts.MediaStorage, err = media.NewS3(media.S3Config{
+			Endpoint:        cfg.Media.S3Endpoint,
+			Region:          cfg.Media.S3Region,
+			Bucket:          cfg.Media.S3Bucket,
+			AccessKeyID:     cfg.Media.S3AccessKeyID,
+			SecretAccessKey: cfg.Media.S3SecretAccessKey,
+			PublicURL:       cfg.Media.S3PublicURL,
+		}); err != nil {
+			log.Fatal("Media storage error: ", err)
+		}
+	}
+	var rateLimitPruner *ratelimit.Postgres
+	switch cfg.RateLimitStore {
+	case "memory":
+		opts.RateLimiter = ratelimit.NewMemory()
+	case "postgres":
+		rateLimitPruner = ratelimit.NewPostgres(db.DB)
+		opts.RateLimiter = rateLimitPruner
+	}
+	// Checked by Config.Validate.
+	opts.RateLimits, _ = ratelimit.ParsePolicies(cfg.RateLimits)
+	var idempotencyPruner *idempotency.Postgres
+	switch cfg.IdempotencyStore {
+	case "memory":
+		opts.Idempotency = idempotency.NewMemory()
+	case "postgres":
+		idempotencyPruner = idempotency.NewPostgres(db.DB)
+		opts.Idempotency = idempotencyPruner
+	}
+	h := handlers.New(postgres.New(db.DB), opts)
+	h.Register(api)
+	if cfg.Features.Feeds {
+		h.RegisterFeeds(app.Group("/feeds"))
+	}
 
-	// Likes routes
-	likes := api.Group("/likes")
-	likes.Get("/status", handlers.GetLikeStatus)
-	likes.Get("/count/:id", handlers.GetLikesCount)
-	likes.Post("/", handlers.AddLike)
-	likes.Delete("/", handlers.RemoveLike)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "4000"
+	checks := []health.Check{
+		{Name: "database", Run: db.DB.PingContext},
+		{Name: "migrations", Run: func(ctx context.Context) error { return db.CheckSchema(ctx, db.DB) }},
 	}
-
-	log.Fatal(app.Listen(":" + port))
-}
\ No newline at end of file
+	if cfg.Features.Workers {
+		jobs := []worker.Job{
+			h.PublishScheduledJob(cfg.SchedulerInterval),
+			h.RefreshScoresJob(cfg.ScoreInterval),
+			h.RenderContentJob(cfg.RenderInterval),
+		}
+		if rateLimitPruner != nil {
+			jobs = append(jobs, rateLimitPruner.PruneJob(10*time.Minute))
+		}
+		if idempotencyPruner != nil {
+			jobs = append(jobs, idempotencyPruner.PruneJob(time.Hour))
+		}
+		workers := worker.NewRunner(jobs...)
+		workers.Start(ctx)
+		defer workers.Stop()
+		checks = append(checks, health.Check{Name: "workers", Run: workers.Check})
+	}
+	// Readiness fails from the shutdown signal on.
+	checker := health.New(ctx.Done(), checks...)
+	checker.Timeout = cfg.Server.HealthCheckTimeout
+	checker.Register(app, db.DB)
+
+	// Ending the event streams lets the shutdown complete; the deferred
+	// calls then stop the workers and close the database.
+	if err := server.Run(ctx, app, ":"+strconv.Itoa(cfg.Port), cfg.Server, opts.Hub.Close); err != nil {
+		log.Fatal(err)
+	}
+}
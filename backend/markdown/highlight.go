@@ -0,0 +1,208 @@
+package markdown
+
+import (
+	"strings"
+)
+
+// language describes enough of a programming language's lexical syntax to
+// highlight comments, strings, numbers and keywords.
+type language struct {
+	lineComments []string
+	blockComment [2]string
+	quotes       string
+	keywords     map[string]bool
+}
+
+func words(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+var (
+	goLang = &language{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+		keywords: words(`break case chan const continue default defer else fallthrough for func go goto
+			if import interface map package range return select struct switch type var
+			true false nil iota`),
+	}
+	jsLang = &language{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+		keywords: words(`async await break case catch class const continue debugger default delete do
+			else export extends finally for from function if import in instanceof interface let new
+			of return static super switch this throw try type typeof var void while yield
+			true false null undefined`),
+	}
+	pythonLang = &language{
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+		keywords: words(`and as assert async await break class continue def del elif else except
+			finally for from global if import in is lambda nonlocal not or pass raise return try
+			while with yield True False None`),
+	}
+	sqlLang = &language{
+		lineComments: []string{"--"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "'\"",
+		keywords: words(`select from where and or not insert into values update set delete create
+			table index drop alter add column primary key foreign references join left right inner
+			outer on group by order having limit offset as distinct union all returning with case
+			when then else end is null true false in exists begin commit rollback
+			SELECT FROM WHERE AND OR NOT INSERT INTO VALUES UPDATE SET DELETE CREATE TABLE INDEX
+			DROP ALTER ADD COLUMN PRIMARY KEY FOREIGN REFERENCES JOIN LEFT RIGHT INNER OUTER ON
+			GROUP BY ORDER HAVING LIMIT OFFSET AS DISTINCT UNION ALL RETURNING WITH CASE WHEN THEN
+			ELSE END IS NULL TRUE FALSE IN EXISTS BEGIN COMMIT ROLLBACK`),
+	}
+	shellLang = &language{
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+		keywords: words(`if then else elif fi for while until do done case esac function in return
+			export local echo exit`),
+	}
+	jsonLang = &language{
+		quotes:   "\"",
+		keywords: words(`true false null`),
+	}
+	rustLang = &language{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"",
+		keywords: words(`as async await break const continue crate else enum extern fn for if impl
+			in let loop match mod move mut pub ref return self Self static struct super trait type
+			unsafe use where while true false`),
+	}
+	cLang = &language{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+		keywords: words(`abstract auto bool break case catch char class const continue default do
+			double else enum extends extern final finally float for if implements import int
+			interface long namespace new package private protected public return short signed
+			sizeof static struct switch template this throw throws try typedef union unsigned
+			using var virtual void volatile while true false null nullptr`),
+	}
+)
+
+// languages maps the names used after a code fence to their syntax. Code in
+// other languages is escaped without highlighting.
+var languages = map[string]*language{
+	"go": goLang, "golang": goLang,
+	"js": jsLang, "javascript": jsLang, "jsx": jsLang,
+	"ts": jsLang, "typescript": jsLang, "tsx": jsLang,
+	"py": pythonLang, "python": pythonLang,
+	"sql": sqlLang, "postgres": sqlLang, "postgresql": sqlLang,
+	"sh": shellLang, "bash": shellLang, "shell": shellLang, "zsh": shellLang,
+	"json": jsonLang,
+	"rs":   rustLang, "rust": rustLang,
+	"c": cLang, "cpp": cLang, "c++": cLang, "java": cLang, "cs": cLang, "csharp": cLang,
+}
+
+// Highlighted tokens are wrapped in spans with these classes.
+const (
+	classComment = "hl-comment"
+	classString  = "hl-string"
+	classNumber  = "hl-number"
+	classKeyword = "hl-keyword"
+)
+
+// cleanLanguage lowercases a fence info word and drops it unless it is a
+// plausible language name.
+func cleanLanguage(lang string) string {
+	lang = strings.ToLower(lang)
+	if len(lang) > 20 {
+		return ""
+	}
+	for i := 0; i < len(lang); i++ {
+		c := lang[i]
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '+' && c != '-' && c != '#' && c != '_' {
+			return ""
+		}
+	}
+	return lang
+}
+
+// highlight writes code, escaped, with its tokens wrapped in spans when the
+// language is known.
+func highlight(w *writer, lang, code string) {
+	syntax, ok := languages[lang]
+	if !ok {
+		w.text(code)
+		return
+	}
+
+	plain := 0
+	span := func(start, end int, class string) {
+		w.text(code[plain:start])
+		w.open("span", "class", class)
+		w.text(code[start:end])
+		w.close("span")
+		plain = end
+	}
+
+	for i := 0; i < len(code); {
+		if end := syntax.comment(code, i); end > i {
+			span(i, end, classComment)
+			i = end
+			continue
+		}
+
+		c := code[i]
+		switch {
+		case strings.IndexByte(syntax.quotes, c) >= 0:
+			end := i + 1
+			for end < len(code) && code[end] != c && (code[end] != '\n' || c == '`') {
+				if code[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(code))
+			span(i, end, classString)
+			i = end
+		case c >= '0' && c <= '9' && (i == 0 || !isWordByte(code[i-1])):
+			end := i
+			for end < len(code) && (isWordByte(code[end]) || code[end] == '.') {
+				end++
+			}
+			span(i, end, classNumber)
+			i = end
+		case isWordByte(c) || c == '$':
+			end := i
+			for end < len(code) && (isWordByte(code[end]) || code[end] == '$') {
+				end++
+			}
+			if syntax.keywords[code[i:end]] {
+				span(i, end, classKeyword)
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	w.text(code[plain:])
+}
+
+// comment returns the end of the comment starting at i, or i.
+func (l *language) comment(code string, i int) int {
+	for _, prefix := range l.lineComments {
+		if strings.HasPrefix(code[i:], prefix) {
+			if end := strings.IndexByte(code[i:], '\n'); end >= 0 {
+				return i + end
+			}
+			return len(code)
+		}
+	}
+	if open := l.blockComment[0]; open != "" && strings.HasPrefix(code[i:], open) {
+		if end := strings.Index(code[i+len(open):], l.blockComment[1]); end >= 0 {
+			return i + len(open) + end + len(l.blockComment[1])
+		}
+		return len(code)
+	}
+	return i
+}
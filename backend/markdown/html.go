@@ -0,0 +1,106 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"strings"
+)
+
+// allowedTags lists every element the renderer may write with the attributes
+// it may carry. Anything else is dropped by writer.open.
+var allowedTags = map[string][]string{
+	"p": nil, "br": nil, "hr": nil, "blockquote": nil,
+	"h1": {"id"}, "h2": {"id"}, "h3": {"id"}, "h4": {"id"}, "h5": {"id"}, "h6": {"id"},
+	"ul": nil, "ol": {"start"}, "li": nil,
+	"em": nil, "strong": nil, "del": nil,
+	"pre": nil, "code": {"class"}, "span": {"class"},
+	"a":   {"href", "title", "rel", "class"},
+	"img": {"src", "alt", "title"},
+}
+
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+// linkRel is set on every link written from user content.
+const linkRel = "nofollow ugc"
+
+// writer builds the HTML output. Text always goes through text, which
+// escapes it, so source HTML never reaches the output as markup.
+type writer struct {
+	bytes.Buffer
+}
+
+// open writes a start tag with attributes given as name/value pairs.
+func (w *writer) open(tag string, attrs ...string) {
+	allowed, ok := allowedTags[tag]
+	if !ok {
+		return
+	}
+	w.WriteString("<" + tag)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if contains(allowed, attrs[i]) {
+			w.WriteString(" " + attrs[i] + `="` + html.EscapeString(attrs[i+1]) + `"`)
+		}
+	}
+	w.WriteString(">")
+}
+
+func (w *writer) close(tag string) {
+	if _, ok := allowedTags[tag]; ok && !voidTags[tag] {
+		w.WriteString("</" + tag + ">")
+	}
+}
+
+func (w *writer) text(s string) {
+	w.WriteString(html.EscapeString(s))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// safeURL returns the URL to write for a link or image destination, and
+// false when its scheme is not allowed. Relative URLs are always allowed;
+// images are limited to http and https.
+func safeURL(raw string, image bool) (string, bool) {
+	u := strings.TrimSpace(unescape(raw))
+	if u == "" {
+		return "", false
+	}
+	if end := strings.IndexAny(u, "/?#"); end != 0 {
+		colon := strings.IndexByte(u, ':')
+		if colon >= 0 && (end < 0 || colon < end) {
+			switch strings.ToLower(u[:colon]) {
+			case "http", "https":
+			case "mailto":
+				if image {
+					return "", false
+				}
+			default:
+				return "", false
+			}
+		}
+	}
+	return strings.ReplaceAll(u, " ", "%20"), true
+}
+
+// plainText strips the tags of rendered inline HTML, for heading anchors.
+func plainText(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return html.UnescapeString(b.String())
+}
@@ -0,0 +1,435 @@
+package markdown
+
+import (
+	"html"
+	"slices"
+	"strings"
+)
+
+func (r *renderer) inline(text string) {
+	(&inlineParser{out: &r.out}).render(text)
+}
+
+// inlineParser renders the inline content of a block.
+type inlineParser struct {
+	out *writer
+	// inLink disables links inside link text.
+	inLink bool
+	// delims indexes the text being rendered, once an opener needs it.
+	delims *delimiters
+}
+
+func (p *inlineParser) render(s string) {
+	defer func(delims *delimiters) { p.delims = delims }(p.delims)
+	p.delims = nil
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			p.out.text(s[i+1 : i+2])
+			i += 2
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			p.out.open("br")
+			p.out.WriteString("\n")
+			i += 2
+		case c == '\n':
+			p.lineBreak()
+			i++
+		case c == '&':
+			i = p.entity(s, i)
+		case c == '`':
+			i = p.codeSpan(s, i)
+		case c == '*' || c == '_':
+			i = p.emphasis(s, i)
+		case c == '~' && strings.HasPrefix(s[i:], "~~"):
+			i = p.strikethrough(s, i)
+		case c == '!' && strings.HasPrefix(s[i:], "![") && !p.inLink:
+			i = p.link(s, i, true)
+		case c == '[' && !p.inLink:
+			i = p.link(s, i, false)
+		case c == '<' && !p.inLink:
+			i = p.autolink(s, i)
+		case (c == 'h' || c == 'w') && !p.inLink && (i == 0 || strings.IndexByte(" \t\n(*_~", s[i-1]) >= 0):
+			i = p.bareURL(s, i)
+		default:
+			p.out.text(s[i : i+1])
+			i++
+		}
+	}
+}
+
+// lineBreak writes a soft line break, or a hard one after two spaces.
+func (p *inlineParser) lineBreak() {
+	b := p.out.Bytes()
+	n := len(b)
+	for n > 0 && b[n-1] == ' ' {
+		n--
+	}
+	hard := len(b)-n >= 2
+	p.out.Truncate(n)
+	if hard {
+		p.out.open("br")
+	}
+	p.out.WriteString("\n")
+}
+
+// entity decodes a character reference such as &eacute; so that it is
+// escaped once rather than twice. Unknown references are text.
+func (p *inlineParser) entity(s string, i int) int {
+	if end := strings.IndexByte(s[i:], ';'); end > 1 && end <= 32 {
+		ref := s[i : i+end+1]
+		if strings.Trim(ref[1:end], "#0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			p.out.text("&")
+			return i + 1
+		}
+		if decoded := html.UnescapeString(ref); decoded != ref {
+			p.out.text(decoded)
+			return i + end + 1
+		}
+	}
+	p.out.text("&")
+	return i + 1
+}
+
+func (p *inlineParser) codeSpan(s string, i int) int {
+	n := runLength(s, i, '`')
+	end := closingBackticks(s, i+n, n)
+	if end < 0 {
+		p.out.text(s[i : i+n])
+		return i + n
+	}
+	code := strings.ReplaceAll(s[i+n:end], "\n", " ")
+	if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+		code = code[1 : len(code)-1]
+	}
+	p.out.open("code")
+	p.out.text(code)
+	p.out.close("code")
+	return end + n
+}
+
+// closingBackticks returns the index of the next run of exactly n backticks
+// from i, or -1.
+func closingBackticks(s string, i, n int) int {
+	for i < len(s) {
+		j := strings.IndexByte(s[i:], '`')
+		if j < 0 {
+			return -1
+		}
+		i += j
+		m := runLength(s, i, '`')
+		if m == n {
+			return i
+		}
+		i += m
+	}
+	return -1
+}
+
+// emphasis handles a run of one to three * or _: <em>, <strong> or both.
+// Runs without a matching closer are written as they are.
+func (p *inlineParser) emphasis(s string, i int) int {
+	c := s[i]
+	n := runLength(s, i, c)
+	canOpen := n <= 3 && i+n < len(s) && !isSpace(s[i+n]) &&
+		(c == '*' || i == 0 || !isWordByte(s[i-1]))
+	if canOpen {
+		if end := p.closingDelimiter(s, i+n, c, n); end >= 0 {
+			tags := [][]string{nil, {"em"}, {"strong"}, {"em", "strong"}}[n]
+			for _, tag := range tags {
+				p.out.open(tag)
+			}
+			p.render(s[i+n : end])
+			for k := len(tags) - 1; k >= 0; k-- {
+				p.out.close(tags[k])
+			}
+			return end + n
+		}
+	}
+	p.out.text(s[i : i+n])
+	return i + n
+}
+
+// closingDelimiter finds a run of exactly n c that can close emphasis
+// opened before from, or -1.
+func (p *inlineParser) closingDelimiter(s string, from int, c byte, n int) int {
+	closers := p.index(s).closers[delimiterRun{c, n}]
+	if k, _ := slices.BinarySearch(closers, from+1); k < len(closers) {
+		return closers[k]
+	}
+	return -1
+}
+
+func (p *inlineParser) strikethrough(s string, i int) int {
+	if i+2 < len(s) && !isSpace(s[i+2]) {
+		if end := p.closingDelimiter(s, i+2, '~', 2); end >= 0 {
+			p.out.open("del")
+			p.render(s[i+2 : end])
+			p.out.close("del")
+			return end + 2
+		}
+	}
+	n := runLength(s, i, '~')
+	p.out.text(s[i : i+n])
+	return i + n
+}
+
+// link handles [text](url "title") and, when image is set, ![alt](src).
+// Links to disallowed URLs keep their text; such images their alt text.
+func (p *inlineParser) link(s string, i int, image bool) int {
+	open := i
+	if image {
+		open++
+	}
+	bracket, ok := p.index(s).brackets[open]
+	if !ok || bracket+1 >= len(s) || s[bracket+1] != '(' {
+		p.out.text(s[i : open+1])
+		return open + 1
+	}
+	dest, title, end, ok := linkDestination(s, bracket+2)
+	if !ok {
+		p.out.text(s[i : open+1])
+		return open + 1
+	}
+	text := s[open+1 : bracket]
+
+	if image {
+		alt := plainText(renderInline(text))
+		if src, ok := safeURL(dest, true); ok {
+			attrs := []string{"src", src, "alt", alt}
+			if title != "" {
+				attrs = append(attrs, "title", title)
+			}
+			p.out.open("img", attrs...)
+		} else {
+			p.out.text(alt)
+		}
+		return end
+	}
+
+	href, ok := safeURL(dest, false)
+	if ok {
+		attrs := []string{"href", href, "rel", linkRel}
+		if title != "" {
+			attrs = append(attrs, "title", title)
+		}
+		p.out.open("a", attrs...)
+	}
+	inner := &inlineParser{out: p.out, inLink: true}
+	inner.render(text)
+	if ok {
+		p.out.close("a")
+	}
+	return end
+}
+
+func renderInline(s string) string {
+	var w writer
+	(&inlineParser{out: &w, inLink: true}).render(s)
+	return w.String()
+}
+
+// delimiters holds the closers of a text. Searching the rest of the text
+// for the closer of each opener would take quadratic time on openers
+// without one, such as a long run of [.
+type delimiters struct {
+	// closers lists in order the runs that can close emphasis.
+	closers map[delimiterRun][]int
+	// brackets maps each [ to its ]; unmatched ones are missing.
+	brackets map[int]int
+}
+
+// delimiterRun is a run of n c.
+type delimiterRun struct {
+	c byte
+	n int
+}
+
+// index returns the delimiters of s, the text being rendered, finding them
+// in one pass that skips escapes and code spans.
+func (p *inlineParser) index(s string) *delimiters {
+	if p.delims != nil {
+		return p.delims
+	}
+	d := &delimiters{closers: make(map[delimiterRun][]int), brackets: make(map[int]int)}
+	var open []int
+	for j := 0; j < len(s); {
+		switch c := s[j]; c {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			m := runLength(s, j, '`')
+			if end := closingBackticks(s, j+m, m); end >= 0 {
+				j = end + m
+			} else {
+				j += m
+			}
+			continue
+		case '*', '_', '~':
+			m := runLength(s, j, c)
+			after := j + m
+			if j > 0 && !isSpace(s[j-1]) && (c == '*' || after == len(s) || !isWordByte(s[after])) {
+				run := delimiterRun{c, m}
+				d.closers[run] = append(d.closers[run], j)
+			}
+			j = after
+			continue
+		case '[':
+			open = append(open, j)
+		case ']':
+			if len(open) > 0 {
+				d.brackets[open[len(open)-1]] = j
+				open = open[:len(open)-1]
+			}
+		}
+		j++
+	}
+	p.delims = d
+	return d
+}
+
+// linkDestination parses `url "title")` starting at i, just after the
+// opening parenthesis, and returns the index after the closing one.
+func linkDestination(s string, i int) (dest, title string, end int, ok bool) {
+	i = skipSpaces(s, i)
+	if i < len(s) && s[i] == '<' {
+		j := strings.IndexAny(s[i+1:], ">\n")
+		if j < 0 || s[i+1+j] != '>' {
+			return "", "", 0, false
+		}
+		dest, i = s[i+1:i+1+j], i+j+2
+	} else {
+		start, depth := i, 0
+		for ; i < len(s) && !isSpace(s[i]); i++ {
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == '(' {
+				depth++
+			} else if s[i] == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+		}
+		if i > len(s) {
+			i = len(s)
+		}
+		dest = s[start:i]
+	}
+
+	i = skipSpaces(s, i)
+	if i < len(s) && (s[i] == '"' || s[i] == '\'' || s[i] == '(') {
+		closer := s[i]
+		if closer == '(' {
+			closer = ')'
+		}
+		j := strings.IndexByte(s[i+1:], closer)
+		if j < 0 {
+			return "", "", 0, false
+		}
+		title, i = unescape(s[i+1:i+1+j]), i+j+2
+		i = skipSpaces(s, i)
+	}
+	if i >= len(s) || s[i] != ')' {
+		return "", "", 0, false
+	}
+	return dest, title, i + 1, true
+}
+
+// autolink handles <https://example.com> and <someone@example.com>. Any
+// other < is text.
+func (p *inlineParser) autolink(s string, i int) int {
+	end := strings.IndexAny(s[i+1:], "<> \t\n")
+	if end > 0 && s[i+1+end] == '>' {
+		target := s[i+1 : i+1+end]
+		href := target
+		if !strings.Contains(target, ":") && strings.Contains(target, "@") {
+			href = "mailto:" + target
+		}
+		if strings.Contains(href, ":") {
+			if href, ok := safeURL(href, false); ok {
+				p.out.open("a", "href", href, "rel", linkRel)
+				p.out.text(target)
+				p.out.close("a")
+				return i + end + 2
+			}
+		}
+	}
+	p.out.text("<")
+	return i + 1
+}
+
+// bareURL links http://, https:// and www. addresses written as plain text.
+func (p *inlineParser) bareURL(s string, i int) int {
+	rest := s[i:]
+	if !strings.HasPrefix(rest, "http://") && !strings.HasPrefix(rest, "https://") && !strings.HasPrefix(rest, "www.") {
+		p.out.text(s[i : i+1])
+		return i + 1
+	}
+
+	end := strings.IndexAny(rest, " \t\n<")
+	if end < 0 {
+		end = len(rest)
+	}
+	for end > 0 {
+		last := rest[end-1]
+		if strings.IndexByte(".,:;!?\"'*_~", last) >= 0 ||
+			(last == ')' && strings.Count(rest[:end], ")") > strings.Count(rest[:end], "(")) {
+			end--
+			continue
+		}
+		break
+	}
+	target := rest[:end]
+	href := target
+	if strings.HasPrefix(href, "www.") {
+		href = "http://" + href
+	}
+	if len(strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(target, "https://"), "http://"), "www.")) == 0 {
+		p.out.text(s[i : i+1])
+		return i + 1
+	}
+
+	p.out.open("a", "href", href, "rel", linkRel)
+	p.out.text(target)
+	p.out.close("a")
+	return i + end
+}
+
+// unescape removes the backslashes of escaped punctuation.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && isSpace(s[i]) {
+		i++
+	}
+	return i
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// isWordByte reports whether c belongs to a word; bytes of non-ASCII
+// characters count as letters.
+func isWordByte(c byte) bool {
+	return c >= 0x80 || c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
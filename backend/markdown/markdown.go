@@ -0,0 +1,483 @@
+// Package markdown renders the Markdown of articles and comments to HTML
+// that is safe to insert into a page.
+//
+// It supports the common CommonMark blocks (paragraphs, ATX and setext
+// headings, block quotes, lists, fenced and indented code, thematic breaks)
+// and inlines (emphasis, strikethrough, code spans, links, images,
+// autolinks, hard breaks). Raw HTML in the source is escaped rather than
+// interpreted, so the output only contains the tags of allowedTags. Link
+// destinations are limited to http, https, mailto and relative URLs and
+// carry rel="nofollow ugc", headings get anchors, and fenced code blocks with
+// a known language are highlighted.
+package markdown
+
+import (
+	"blog-api/slug"
+	"strconv"
+	"strings"
+)
+
+// Version identifies the output of Render. Bump it whenever a change to the
+// renderer changes its output, so that stored HTML is rendered again.
+const Version = 1
+
+// HeadingIDPrefix starts the id of every heading, keeping user content from
+// clobbering the ids of the page around it.
+const HeadingIDPrefix = "heading-"
+
+// Render converts Markdown source to sanitized HTML.
+func Render(src string) string {
+	return render(src, true)
+}
+
+// Text returns the text of Markdown source without its markup, for excerpts
+// and previews.
+func Text(src string) string {
+	return plainText(render(src, false))
+}
+
+func render(src string, anchors bool) string {
+	src = strings.NewReplacer("\r\n", "\n", "\r", "\n", "\x00", "\uFFFD").Replace(src)
+	r := &renderer{ids: map[string]int{}, anchors: anchors}
+	r.blocks(strings.Split(src, "\n"), false)
+	return strings.TrimSuffix(r.out.String(), "\n")
+}
+
+type renderer struct {
+	out writer
+	// ids counts the heading anchors in use, to make them unique.
+	ids map[string]int
+	// anchors adds a link to its own anchor in every heading.
+	anchors bool
+}
+
+// blocks renders a sequence of lines. In tight list items, paragraphs are
+// written without <p>.
+func (r *renderer) blocks(lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if isBlank(line) {
+			i++
+			continue
+		}
+		cols, rest := indent(line)
+
+		switch {
+		case cols >= 4:
+			i = r.indentedCode(lines, i)
+		case isFence(rest):
+			i = r.fencedCode(lines, i)
+		case atxLevel(rest) > 0:
+			r.atxHeading(rest)
+			i++
+		case isThematicBreak(rest):
+			r.out.open("hr")
+			r.out.WriteString("\n")
+			i++
+		case rest[0] == '>':
+			i = r.blockquote(lines, i)
+		default:
+			if m, ok := parseListMarker(line); ok {
+				i = r.list(lines, i, m)
+			} else {
+				i = r.paragraph(lines, i, tight)
+			}
+		}
+	}
+}
+
+func (r *renderer) paragraph(lines []string, i int, tight bool) int {
+	var text []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) {
+			break
+		}
+		if len(text) > 0 {
+			if level := setextLevel(line); level > 0 {
+				r.heading(level, strings.Join(text, "\n"))
+				return i + 1
+			}
+			if startsBlock(line, true) {
+				break
+			}
+		}
+		text = append(text, strings.TrimLeft(line, " \t"))
+	}
+
+	if !tight {
+		r.out.open("p")
+	}
+	r.inline(strings.TrimRight(strings.Join(text, "\n"), " \t"))
+	if !tight {
+		r.out.close("p")
+	}
+	r.out.WriteString("\n")
+	return i
+}
+
+func (r *renderer) atxHeading(rest string) {
+	level := atxLevel(rest)
+	text := strings.TrimSpace(rest[level:])
+	// A closing sequence of #s is not part of the heading.
+	if trimmed := strings.TrimRight(text, "#"); trimmed != text {
+		if trimmed == "" || trimmed[len(trimmed)-1] == ' ' || trimmed[len(trimmed)-1] == '\t' {
+			text = strings.TrimSpace(trimmed)
+		}
+	}
+	r.heading(level, text)
+}
+
+// heading writes a heading with an id derived from its text and a link to
+// that anchor.
+func (r *renderer) heading(level int, text string) {
+	var content writer
+	(&inlineParser{out: &content}).render(strings.TrimSpace(text))
+
+	id := slug.Make(plainText(content.String()))
+	if id == "" {
+		id = "section"
+	}
+	if n := r.ids[id]; n > 0 {
+		r.ids[id] = n + 1
+		id += "-" + strconv.Itoa(n)
+	} else {
+		r.ids[id] = 1
+	}
+	id = HeadingIDPrefix + id
+
+	tag := "h" + strconv.Itoa(level)
+	r.out.open(tag, "id", id)
+	if r.anchors {
+		r.out.open("a", "href", "#"+id, "class", "anchor")
+		r.out.text("#")
+		r.out.close("a")
+		r.out.WriteString(" ")
+	}
+	r.out.Write(content.Bytes())
+	r.out.close(tag)
+	r.out.WriteString("\n")
+}
+
+func (r *renderer) blockquote(lines []string, i int) int {
+	var inner []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) {
+			break
+		}
+		cols, rest := indent(line)
+		if cols < 4 && rest[0] == '>' {
+			rest = rest[1:]
+			if strings.HasPrefix(rest, " ") {
+				rest = rest[1:]
+			}
+			inner = append(inner, rest)
+		} else if !startsBlock(line, true) {
+			// Lazy continuation of a quoted paragraph.
+			inner = append(inner, line)
+		} else {
+			break
+		}
+	}
+
+	r.out.open("blockquote")
+	r.out.WriteString("\n")
+	r.blocks(inner, false)
+	r.out.close("blockquote")
+	r.out.WriteString("\n")
+	return i
+}
+
+func (r *renderer) indentedCode(lines []string, i int) int {
+	var code []string
+	for ; i < len(lines); i++ {
+		if cols, _ := indent(lines[i]); cols < 4 && !isBlank(lines[i]) {
+			break
+		}
+		code = append(code, stripIndent(lines[i], 4))
+	}
+	for len(code) > 0 && isBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+	}
+	r.code("", strings.Join(code, "\n"))
+	return i
+}
+
+func (r *renderer) fencedCode(lines []string, i int) int {
+	cols, rest := indent(lines[i])
+	fence := rest[0]
+	size := runLength(rest, 0, fence)
+	info := strings.Fields(rest[size:])
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		c, closing := indent(lines[i])
+		if c < 4 && runLength(closing, 0, fence) >= size && isBlank(closing[runLength(closing, 0, fence):]) {
+			i++
+			break
+		}
+		code = append(code, stripIndent(lines[i], cols))
+	}
+
+	lang := ""
+	if len(info) > 0 {
+		lang = info[0]
+	}
+	r.code(lang, strings.Join(code, "\n"))
+	return i
+}
+
+func (r *renderer) code(lang, code string) {
+	lang = cleanLanguage(lang)
+	r.out.open("pre")
+	if lang != "" {
+		r.out.open("code", "class", "language-"+lang)
+	} else {
+		r.out.open("code")
+	}
+	if code != "" {
+		highlight(&r.out, lang, code+"\n")
+	}
+	r.out.close("code")
+	r.out.close("pre")
+	r.out.WriteString("\n")
+}
+
+// listMarker describes the marker starting a list item.
+type listMarker struct {
+	ordered bool
+	// delim is the bullet character, or "." or ")" after an ordered number.
+	delim byte
+	start int
+	// offset is the column where the item content starts.
+	offset int
+	rest   string
+}
+
+func (r *renderer) list(lines []string, i int, first listMarker) int {
+	var items [][]string
+	item := []string{first.rest}
+	offset := first.offset
+	loose, blank := false, false
+
+	for i++; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) {
+			item = append(item, "")
+			blank = true
+			continue
+		}
+		if cols, _ := indent(line); cols >= offset {
+			if blank {
+				loose = true
+			}
+			item = append(item, stripIndent(line, offset))
+			blank = false
+			continue
+		}
+		if m, ok := parseListMarker(line); ok && m.ordered == first.ordered && m.delim == first.delim {
+			if blank {
+				loose = true
+			}
+			items = append(items, item)
+			item = []string{m.rest}
+			offset = m.offset
+			blank = false
+			continue
+		}
+		if !blank && !startsBlock(line, true) {
+			// Lazy continuation of the item's last paragraph.
+			item = append(item, line)
+			continue
+		}
+		break
+	}
+	items = append(items, item)
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	if first.ordered && first.start != 1 {
+		r.out.open(tag, "start", strconv.Itoa(first.start))
+	} else {
+		r.out.open(tag)
+	}
+	r.out.WriteString("\n")
+	for _, item := range items {
+		r.out.open("li")
+		if loose {
+			r.out.WriteString("\n")
+		}
+		r.blocks(item, !loose)
+		if !loose {
+			// Tight paragraphs end with a newline that doesn't belong in the item.
+			r.out.Truncate(len(bytesTrimNewline(r.out.Bytes())))
+		}
+		r.out.close("li")
+		r.out.WriteString("\n")
+	}
+	r.out.close(tag)
+	r.out.WriteString("\n")
+	return i
+}
+
+func bytesTrimNewline(b []byte) []byte {
+	if len(b) > 0 && b[len(b)-1] == '\n' {
+		return b[:len(b)-1]
+	}
+	return b
+}
+
+func parseListMarker(line string) (listMarker, bool) {
+	cols, rest := indent(line)
+	if cols >= 4 || rest == "" {
+		return listMarker{}, false
+	}
+
+	m := listMarker{}
+	width := 0
+	switch {
+	case rest[0] == '-' || rest[0] == '*' || rest[0] == '+':
+		m.delim, width = rest[0], 1
+	default:
+		digits := 0
+		for digits < len(rest) && digits < 9 && rest[digits] >= '0' && rest[digits] <= '9' {
+			digits++
+		}
+		if digits == 0 || digits >= len(rest) || (rest[digits] != '.' && rest[digits] != ')') {
+			return listMarker{}, false
+		}
+		m.ordered, m.delim, width = true, rest[digits], digits+1
+		m.start, _ = strconv.Atoi(rest[:digits])
+	}
+
+	after := rest[width:]
+	if after != "" && after[0] != ' ' && after[0] != '\t' {
+		return listMarker{}, false
+	}
+	spaces := len(after) - len(strings.TrimLeft(after, " "))
+	if spaces == 0 || spaces > 4 || isBlank(after) {
+		spaces = 1
+	}
+	m.offset = cols + width + spaces
+	if spaces <= len(after) {
+		m.rest = after[spaces:]
+	}
+	return m, true
+}
+
+// startsBlock reports whether line opens a block that ends a paragraph.
+// Inside a paragraph only non-empty lists starting at 1 count.
+func startsBlock(line string, inParagraph bool) bool {
+	cols, rest := indent(line)
+	if cols >= 4 || rest == "" {
+		return false
+	}
+	if isFence(rest) || atxLevel(rest) > 0 || isThematicBreak(rest) || rest[0] == '>' {
+		return true
+	}
+	m, ok := parseListMarker(line)
+	if !ok {
+		return false
+	}
+	return !inParagraph || (!isBlank(m.rest) && (!m.ordered || m.start == 1))
+}
+
+func isFence(rest string) bool {
+	if rest == "" || (rest[0] != '`' && rest[0] != '~') {
+		return false
+	}
+	n := runLength(rest, 0, rest[0])
+	return n >= 3 && (rest[0] == '~' || !strings.Contains(rest[n:], "`"))
+}
+
+// atxLevel returns the level of an ATX heading line, or 0.
+func atxLevel(rest string) int {
+	n := runLength(rest, 0, '#')
+	if n == 0 || n > 6 || (n < len(rest) && rest[n] != ' ' && rest[n] != '\t') {
+		return 0
+	}
+	return n
+}
+
+func setextLevel(line string) int {
+	cols, rest := indent(line)
+	if cols >= 4 || rest == "" || (rest[0] != '=' && rest[0] != '-') {
+		return 0
+	}
+	n := runLength(rest, 0, rest[0])
+	if !isBlank(rest[n:]) {
+		return 0
+	}
+	if rest[0] == '=' {
+		return 1
+	}
+	return 2
+}
+
+func isThematicBreak(rest string) bool {
+	if rest == "" || (rest[0] != '-' && rest[0] != '*' && rest[0] != '_') {
+		return false
+	}
+	count := 0
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case rest[0]:
+			count++
+		case ' ', '\t':
+		default:
+			return false
+		}
+	}
+	return count >= 3
+}
+
+func isBlank(line string) bool {
+	return strings.TrimLeft(line, " \t") == ""
+}
+
+// indent returns the width of the leading whitespace of line, with tabs
+// stopping every 4 columns, and the rest of the line.
+func indent(line string) (int, string) {
+	cols := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			cols++
+		case '\t':
+			cols += 4 - cols%4
+		default:
+			return cols, line[i:]
+		}
+	}
+	return cols, ""
+}
+
+// stripIndent removes up to n columns of leading whitespace.
+func stripIndent(line string, n int) string {
+	cols := 0
+	for i := 0; i < len(line); i++ {
+		if cols >= n {
+			return line[i:]
+		}
+		switch line[i] {
+		case ' ':
+			cols++
+		case '\t':
+			cols += 4 - cols%4
+		default:
+			return line[i:]
+		}
+	}
+	return ""
+}
+
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,171 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"paragraph", "Hello *world*", "<p>Hello <em>world</em></p>"},
+		{"script tag", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"inline HTML", `a <b onclick="x">bold</b> & c`, "<p>a &lt;b onclick=&#34;x&#34;&gt;bold&lt;/b&gt; &amp; c</p>"},
+		{"HTML block", "<div>\n<img src=x onerror=alert(1)>\n</div>", "<p>&lt;div&gt;\n&lt;img src=x onerror=alert(1)&gt;\n&lt;/div&gt;</p>"},
+		{"HTML in a code span", "`<b>`", "<p><code>&lt;b&gt;</code></p>"},
+		{"HTML in a heading", "# Title <i>x</i>", `<h1 id="heading-title-i-x-i"><a href="#heading-title-i-x-i" class="anchor">#</a> Title &lt;i&gt;x&lt;/i&gt;</h1>`},
+		{"duplicate headings", "# Same\n# Same", `<h1 id="heading-same"><a href="#heading-same" class="anchor">#</a> Same</h1>` + "\n" +
+			`<h1 id="heading-same-1"><a href="#heading-same-1" class="anchor">#</a> Same</h1>`},
+		{"quote in alt text", `![alt" onerror="x](https://example.com/i.png)`, `<p><img src="https://example.com/i.png" alt="alt&#34; onerror=&#34;x"></p>`},
+		{"quote in a destination", `[x](https://example.com" onmouseover="alert(1))`,
+			`<p>[x](<a href="https://example.com" rel="nofollow ugc">https://example.com</a>&#34; onmouseover=&#34;alert(1))</p>`},
+		{"link", "[x](https://example.com)", `<p><a href="https://example.com" rel="nofollow ugc">x</a></p>`},
+		{"relative link", "[x](/a?b=1&c=2)", `<p><a href="/a?b=1&amp;c=2" rel="nofollow ugc">x</a></p>`},
+		{"mailto link", "[x](mailto:a@example.com)", `<p><a href="mailto:a@example.com" rel="nofollow ugc">x</a></p>`},
+		{"autolink", "<https://example.com>", `<p><a href="https://example.com" rel="nofollow ugc">https://example.com</a></p>`},
+		{"bare URL", "see https://example.com/x.", `<p>see <a href="https://example.com/x" rel="nofollow ugc">https://example.com/x</a>.</p>`},
+		{"nested lists", "- a\n  - b\n    - c\n- d",
+			"<ul>\n<li>a\n<ul>\n<li>b\n<ul>\n<li>c</li>\n</ul></li>\n</ul></li>\n<li>d</li>\n</ul>"},
+		{"loose list", "- a\n\n- b", "<ul>\n<li>\n<p>a</p>\n</li>\n<li>\n<p>b</p>\n</li>\n</ul>"},
+		{"ordered list start", "3. three\n4. four", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>"},
+		{"list in a quote", "> - quoted\n>   list", "<blockquote>\n<ul>\n<li>quoted\nlist</li>\n</ul>\n</blockquote>"},
+		{"code in a list", "1. one\n2. two\n   ```go\n   func main() {}\n   ```",
+			"<ol>\n<li>one</li>\n<li>two\n<pre><code class=\"language-go\"><span class=\"hl-keyword\">func</span> main() {}\n</code></pre></li>\n</ol>"},
+		{"highlighted code", "```js\nvar x = \"<b>\";\n```",
+			"<pre><code class=\"language-js\"><span class=\"hl-keyword\">var</span> x = <span class=\"hl-string\">&#34;&lt;b&gt;&#34;</span>;\n</code></pre>"},
+		{"hostile code language", "```\"><script>\ncode\n```", "<pre><code>code\n</code></pre>"},
+		{"indented code", "    indented <b>", "<pre><code>indented &lt;b&gt;\n</code></pre>"},
+		{"markup in code", "```\n*not* [a](link)\n```", "<pre><code>*not* [a](link)\n</code></pre>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.src); got != tt.want {
+				t.Errorf("Render(%q)\n got %q\nwant %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+// urlAttr matches the URL attributes of the output.
+var urlAttr = regexp.MustCompile(`(?:href|src)="([^"]*)"`)
+
+// scheme matches a URL scheme as browsers parse it.
+var scheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+
+func TestRenderURLSchemes(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"[x](javascript:alert(1))", "<p>x</p>"},
+		{"[x](JavaScript:alert(1))", "<p>x</p>"},
+		{"[x]( javascript:alert(1))", "<p>x</p>"},
+		{"[x](<javascript:alert(1)>)", "<p>x</p>"},
+		{`[x](javascript\:alert(1))`, "<p>x</p>"},
+		{"[x](vbscript:msgbox)", "<p>x</p>"},
+		{"[x](data:text/html;base64,PHNjcmlwdD4=)", "<p>x</p>"},
+		{"[x](file:///etc/passwd)", "<p>x</p>"},
+		{"![x](data:image/png;base64,AAAA)", "<p>x</p>"},
+		{"![x](javascript:alert(1))", "<p>x</p>"},
+		{"![x](mailto:a@example.com)", "<p>x</p>"},
+		{"<javascript:alert(1)>", "<p>&lt;javascript:alert(1)&gt;</p>"},
+		// Entities are not decoded in destinations, so these never form a
+		// scheme: the browser reads them as relative URLs.
+		{"[x](java&#115;cript:alert(1))", `<p><a href="java&amp;#115;cript:alert(1)" rel="nofollow ugc">x</a></p>`},
+		{"[x](javascript&#58;alert(1))", `<p><a href="javascript&amp;#58;alert(1)" rel="nofollow ugc">x</a></p>`},
+		{"[x](&#106;avascript:alert(1))", `<p><a href="&amp;#106;avascript:alert(1)" rel="nofollow ugc">x</a></p>`},
+		{"[x](data&colon;text/html,x)", `<p><a href="data&amp;colon;text/html,x" rel="nofollow ugc">x</a></p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got := Render(tt.src)
+			if got != tt.want {
+				t.Errorf("Render(%q)\n got %q\nwant %q", tt.src, got, tt.want)
+			}
+			for _, m := range urlAttr.FindAllStringSubmatch(got, -1) {
+				// The browser decodes the attribute once before parsing the URL.
+				u := html.UnescapeString(m[1])
+				if s := strings.ToLower(scheme.FindString(u)); s != "" && s != "http:" && s != "https:" && s != "mailto:" {
+					t.Errorf("Render(%q) links to %q", tt.src, u)
+				}
+			}
+		})
+	}
+}
+
+func TestWriterAllowLists(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(w *writer)
+		want  string
+	}{
+		{"allowed attributes", func(w *writer) { w.open("a", "href", "/x", "title", "t") }, `<a href="/x" title="t">`},
+		{"event handler", func(w *writer) { w.open("a", "href", "/x", "onclick", "alert(1)") }, `<a href="/x">`},
+		{"style", func(w *writer) { w.open("p", "style", "color:red") }, "<p>"},
+		{"attribute of another tag", func(w *writer) { w.open("img", "src", "/i.png", "href", "/x") }, `<img src="/i.png">`},
+		{"escaped value", func(w *writer) { w.open("code", "class", `"><script>`) }, `<code class="&#34;&gt;&lt;script&gt;">`},
+		{"unknown tag", func(w *writer) { w.open("script"); w.text("x"); w.close("script") }, "x"},
+		{"iframe", func(w *writer) { w.open("iframe", "src", "/x"); w.close("iframe") }, ""},
+		{"void tag", func(w *writer) { w.open("br"); w.close("br") }, "<br>"},
+		{"text", func(w *writer) { w.text(`<b>&"`) }, "&lt;b&gt;&amp;&#34;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w writer
+			tt.write(&w)
+			if got := w.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"# Title\n\nSome *emphasis* and [a link](https://example.com).", "Title Some emphasis and a link."},
+		{"a <b> & c", "a <b> & c"},
+		{"- one\n  - two\n\n```\ncode\n```", "one two code"},
+	}
+	for _, tt := range tests {
+		// Callers collapse the whitespace between blocks, as in excerpts.
+		if got := strings.Join(strings.Fields(Text(tt.src)), " "); got != tt.want {
+			t.Errorf("Text(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestRenderUnmatchedDelimiters(t *testing.T) {
+	// Delimiters without a match are searched for once, not once each:
+	// these take milliseconds, and minutes when the search is quadratic.
+	const size = 100000
+	for name, src := range map[string]string{
+		"stars":        strings.Repeat("*a ", size/3),
+		"underscores":  strings.Repeat(" _a", size/3),
+		"double stars": strings.Repeat("**a ", size/4),
+		"tildes":       strings.Repeat("~~a ", size/4),
+		"brackets":     strings.Repeat("[", size),
+		"nested":       strings.Repeat("[a", size/2),
+		"images":       strings.Repeat("![a", size/3),
+		"mixed":        strings.Repeat("*[_a ", size/5),
+	} {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			got := Render(src)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("Render took %v for %d bytes", elapsed, len(src))
+			}
+			if !strings.HasPrefix(got, "<p>") || strings.Contains(got, "<em>") || strings.Contains(got, "<a ") {
+				t.Fatalf("Render = %.100q..., want the delimiters as text", got)
+			}
+		})
+	}
+}
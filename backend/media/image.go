@@ -0,0 +1,250 @@
+// Package media validates uploaded images, strips their metadata, resizes
+// them into variants, and stores the files.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+var (
+	// ErrUnsupportedType is returned for files that are not JPEG, PNG or
+	// GIF images, whatever their name or declared type says.
+	ErrUnsupportedType = errors.New("unsupported media type")
+	// ErrInvalidImage is returned when the image cannot be decoded.
+	ErrInvalidImage = errors.New("invalid image")
+	// ErrTooManyPixels is returned for images larger than the pixel limit,
+	// which keeps small compressed files from expanding into huge bitmaps.
+	ErrTooManyPixels = errors.New("image dimensions too large")
+)
+
+// DefaultMaxPixels is the pixel limit used when Process gets zero.
+const DefaultMaxPixels = 40_000_000
+
+// formats maps the sniffed content types to the image decoder names.
+var formats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// VariantSpec describes a resized copy. Images are scaled down to fit
+// within MaxWidth x MaxHeight; Crop fills the box exactly by cutting the
+// edges. Images already smaller than the box get no variant.
+type VariantSpec struct {
+	Name      string
+	MaxWidth  int
+	MaxHeight int
+	Crop      bool
+}
+
+// Variants are generated for every upload.
+var Variants = []VariantSpec{
+	{Name: "thumb", MaxWidth: 160, MaxHeight: 160, Crop: true},
+	{Name: "small", MaxWidth: 480, MaxHeight: 480},
+	{Name: "medium", MaxWidth: 1024, MaxHeight: 1024},
+	{Name: "large", MaxWidth: 1920, MaxHeight: 1920},
+}
+
+// File is one encoded image.
+type File struct {
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Image is a processed upload: the original, cleaned of metadata, and its
+// resized variants by name.
+type Image struct {
+	Original File
+	Variants map[string]File
+}
+
+// Process sniffs data, rejects anything but JPEG, PNG and GIF, and returns
+// the image without metadata along with its variants. JPEG photos are
+// turned upright according to their EXIF orientation before it is dropped.
+// GIFs are kept byte for byte, animation included; they carry no EXIF.
+func Process(data []byte, maxPixels int) (*Image, error) {
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
+	contentType := http.DetectContentType(data)
+	format, ok := formats[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	config, decoded, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decoded != format {
+		return nil, ErrInvalidImage
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	img := toRGBA(src)
+	if format == "jpeg" {
+		img = orient(img, exifOrientation(data))
+	}
+
+	result := &Image{Variants: map[string]File{}}
+	if format == "gif" {
+		result.Original = File{ContentType: contentType, Width: config.Width, Height: config.Height, Data: data}
+	} else if result.Original, err = encode(img, format); err != nil {
+		return nil, err
+	}
+
+	// Variants of GIFs are still images of the first frame.
+	variantFormat := format
+	if format == "gif" {
+		variantFormat = "png"
+	}
+	bounds := img.Bounds()
+	for _, spec := range Variants {
+		if bounds.Dx() <= spec.MaxWidth && bounds.Dy() <= spec.MaxHeight {
+			continue
+		}
+		variant := img
+		width, height := fit(bounds.Dx(), bounds.Dy(), spec.MaxWidth, spec.MaxHeight)
+		if spec.Crop {
+			variant = cropToRatio(img, spec.MaxWidth, spec.MaxHeight)
+			width, height = variant.Bounds().Dx(), variant.Bounds().Dy()
+			if width > spec.MaxWidth || height > spec.MaxHeight {
+				width, height = fit(width, height, spec.MaxWidth, spec.MaxHeight)
+			}
+		}
+		file, err := encode(resize(variant, width, height), variantFormat)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants[spec.Name] = file
+	}
+	return result, nil
+}
+
+func encode(img *image.RGBA, format string) (File, error) {
+	var buf bytes.Buffer
+	file := File{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	var err error
+	switch format {
+	case "jpeg":
+		file.ContentType = "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 88})
+	default:
+		file.ContentType = "image/png"
+		err = png.Encode(&buf, img)
+	}
+	file.Data = buf.Bytes()
+	return file, err
+}
+
+// Extension returns the file extension for one of the content types
+// Process produces.
+func Extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ".png"
+	}
+}
+
+func toRGBA(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// fit returns the largest size with the ratio of width x height that fits
+// within maxWidth x maxHeight.
+func fit(width, height, maxWidth, maxHeight int) (int, int) {
+	if width*maxHeight > height*maxWidth {
+		return maxWidth, max(1, height*maxWidth/width)
+	}
+	return max(1, width*maxHeight/height), maxHeight
+}
+
+// cropToRatio cuts the edges of img so that it has the ratio of
+// width x height, keeping the center.
+func cropToRatio(img *image.RGBA, width, height int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w*height > h*width {
+		cw := h * width / height
+		return img.SubImage(image.Rect((w-cw)/2, 0, (w-cw)/2+cw, h)).(*image.RGBA)
+	}
+	ch := w * height / width
+	return img.SubImage(image.Rect(0, (h-ch)/2, w, (h-ch)/2+ch)).(*image.RGBA)
+}
+
+// resize scales img down to width x height by averaging the source pixels
+// each destination pixel covers, one axis at a time.
+func resize(img *image.RGBA, width, height int) *image.RGBA {
+	b := img.Bounds()
+	if b.Dx() == width && b.Dy() == height {
+		return img
+	}
+
+	// Horizontal pass: b.Dy() rows of width pixels.
+	tmp := make([]uint32, width*b.Dy()*4)
+	for y := 0; y < b.Dy(); y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, b.Dx())
+			var sum [4]uint32
+			for sx := x0; sx < x1; sx++ {
+				for c := 0; c < 4; c++ {
+					sum[c] += uint32(row[sx*4+c])
+				}
+			}
+			for c := 0; c < 4; c++ {
+				tmp[(y*width+x)*4+c] = sum[c] / uint32(x1-x0)
+			}
+		}
+	}
+
+	// Vertical pass.
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, b.Dy())
+		for x := 0; x < width; x++ {
+			var sum [4]uint32
+			for sy := y0; sy < y1; sy++ {
+				for c := 0; c < 4; c++ {
+					sum[c] += tmp[(sy*width+x)*4+c]
+				}
+			}
+			for c := 0; c < 4; c++ {
+				dst.Pix[dst.PixOffset(x, y)+c] = uint8(sum[c] / uint32(y1-y0))
+			}
+		}
+	}
+	return dst
+}
+
+// span returns the source range [from, to) covered by destination pixel i
+// of n when scaling size source pixels down to n.
+func span(i, n, size int) (int, int) {
+	from, to := i*size/n, (i+1)*size/n
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
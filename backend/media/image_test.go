@@ -0,0 +1,282 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// halves returns a width x height image, red on its left half and blue on
+// its right half.
+func halves(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= width/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withEXIF inserts an EXIF block holding orientation and a camera serial
+// number right after the start of a JPEG.
+func withEXIF(data []byte, orientation uint16, serial string) []byte {
+	le := binary.LittleEndian
+	tiff := []byte("II*\x00")
+	tiff = le.AppendUint32(tiff, 8)
+	tiff = le.AppendUint16(tiff, 2) // entries
+	// Orientation, SHORT.
+	tiff = le.AppendUint16(tiff, 0x0112)
+	tiff = le.AppendUint16(tiff, 3)
+	tiff = le.AppendUint32(tiff, 1)
+	tiff = le.AppendUint16(tiff, orientation)
+	tiff = le.AppendUint16(tiff, 0)
+	// BodySerialNumber, ASCII, stored after the IFD.
+	tiff = le.AppendUint16(tiff, 0xA431)
+	tiff = le.AppendUint16(tiff, 2)
+	tiff = le.AppendUint32(tiff, uint32(len(serial)+1))
+	tiff = le.AppendUint32(tiff, uint32(8+2+2*12+4))
+	tiff = le.AppendUint32(tiff, 0) // next IFD
+	tiff = append(append(tiff, serial...), 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+// withText inserts a tEXt chunk right after the header of a PNG.
+func withText(data []byte, keyword, text string) []byte {
+	payload := append(append([]byte(keyword), 0), text...)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	typed := append([]byte("tEXt"), payload...)
+	chunk = append(chunk, typed...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(typed))
+
+	const headerEnd = 8 + 25 // signature and IHDR chunk
+	out := append([]byte{}, data[:headerEnd]...)
+	out = append(out, chunk...)
+	return append(out, data[headerEnd:]...)
+}
+
+// dominant returns "red" or "blue" for the color of the pixel at x, y.
+func dominant(t *testing.T, data []byte, x, y int) string {
+	t.Helper()
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _, b, _ := img.At(x, y).RGBA()
+	if r > b {
+		return "red"
+	}
+	return "blue"
+}
+
+func TestProcessStripsEXIF(t *testing.T) {
+	src := withEXIF(encodeJPEG(t, halves(80, 40)), 6, "SERIAL-1234")
+	if exifOrientation(src) != 6 || !bytes.Contains(src, []byte("SERIAL-1234")) {
+		t.Fatal("test image has no EXIF")
+	}
+
+	img, err := Process(src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := img.Original
+	if orig.ContentType != "image/jpeg" {
+		t.Errorf("content type = %q, want image/jpeg", orig.ContentType)
+	}
+	if bytes.Contains(orig.Data, []byte("Exif")) || bytes.Contains(orig.Data, []byte("SERIAL-1234")) {
+		t.Error("the original keeps its EXIF block")
+	}
+	// Orientation 6 turns the image 90° clockwise: the left half ends up on
+	// top.
+	if orig.Width != 40 || orig.Height != 80 {
+		t.Errorf("size = %dx%d, want 40x80", orig.Width, orig.Height)
+	}
+	if top, bottom := dominant(t, orig.Data, 20, 10), dominant(t, orig.Data, 20, 70); top != "red" || bottom != "blue" {
+		t.Errorf("top is %s and bottom %s, want red over blue", top, bottom)
+	}
+}
+
+func TestProcessStripsPNGText(t *testing.T) {
+	src := withText(encodePNG(t, halves(40, 40)), "Comment", "secret location")
+	if _, err := png.Decode(bytes.NewReader(src)); err != nil {
+		t.Fatalf("test image is invalid: %v", err)
+	}
+
+	img, err := Process(src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Original.ContentType != "image/png" || bytes.Contains(img.Original.Data, []byte("secret location")) {
+		t.Error("the original keeps its text chunk")
+	}
+}
+
+func TestProcessVariants(t *testing.T) {
+	type size struct{ w, h int }
+	tests := []struct {
+		name  string
+		w, h  int
+		sizes map[string]size
+	}{
+		{"landscape", 2000, 1000, map[string]size{"thumb": {160, 160}, "small": {480, 240}, "medium": {1024, 512}, "large": {1920, 960}}},
+		{"portrait", 300, 600, map[string]size{"thumb": {160, 160}, "small": {240, 480}}},
+		{"small", 200, 100, map[string]size{"thumb": {100, 100}}},
+		{"tiny", 100, 100, map[string]size{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Process(encodePNG(t, halves(tt.w, tt.h)), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.Original.Width != tt.w || img.Original.Height != tt.h {
+				t.Errorf("original = %dx%d, want %dx%d", img.Original.Width, img.Original.Height, tt.w, tt.h)
+			}
+			if len(img.Variants) != len(tt.sizes) {
+				t.Errorf("got %d variants, want %d", len(img.Variants), len(tt.sizes))
+			}
+			for name, want := range tt.sizes {
+				v, ok := img.Variants[name]
+				if !ok {
+					t.Errorf("no %s variant", name)
+					continue
+				}
+				decoded, err := png.DecodeConfig(bytes.NewReader(v.Data))
+				if err != nil {
+					t.Errorf("%s: %v", name, err)
+					continue
+				}
+				if v.Width != want.w || v.Height != want.h || decoded.Width != want.w || decoded.Height != want.h {
+					t.Errorf("%s = %dx%d (encoded %dx%d), want %dx%d", name, v.Width, v.Height, decoded.Width, decoded.Height, want.w, want.h)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessResizeKeepsContent(t *testing.T) {
+	img, err := Process(encodePNG(t, halves(1000, 500)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	small := img.Variants["small"]
+	if left, right := dominant(t, small.Data, 10, 100), dominant(t, small.Data, 470, 100); left != "red" || right != "blue" {
+		t.Errorf("small variant is %s then %s, want red then blue", left, right)
+	}
+}
+
+func TestProcessGIF(t *testing.T) {
+	palette := color.Palette{color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}}
+	frame := image.NewPaletted(image.Rect(0, 0, 600, 300), palette)
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{frame, frame}, Delay: []int{10, 10}}); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := Process(buf.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Original.ContentType != "image/gif" || !bytes.Equal(img.Original.Data, buf.Bytes()) {
+		t.Error("the animated GIF was not kept as it is")
+	}
+	if v := img.Variants["small"]; v.ContentType != "image/png" || v.Width != 480 || v.Height != 240 {
+		t.Errorf("small variant = %s %dx%d, want image/png 480x240", v.ContentType, v.Width, v.Height)
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	jpg := encodeJPEG(t, halves(40, 40))
+	pngData := encodePNG(t, halves(40, 40))
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, ErrUnsupportedType},
+		{"HTML", []byte("<html><script>alert(1)</script></html>"), ErrUnsupportedType},
+		{"SVG", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"/>`), ErrUnsupportedType},
+		{"PDF", []byte("%PDF-1.4\n%âãÏÓ\n"), ErrUnsupportedType},
+		{"WebP", append([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), make([]byte, 32)...), ErrUnsupportedType},
+		{"script behind a GIF header", []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00<script>alert(1)</script>"), ErrInvalidImage},
+		{"PNG signature on a JPEG", append([]byte("\x89PNG\r\n\x1a\n"), jpg...), ErrInvalidImage},
+		{"truncated JPEG", jpg[:len(jpg)/2], ErrInvalidImage},
+		{"truncated PNG", pngData[:40], ErrInvalidImage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Process(tt.data, 0); !errors.Is(err, tt.err) {
+				t.Errorf("Process = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestProcessMaxPixels(t *testing.T) {
+	data := encodePNG(t, halves(100, 100))
+	if _, err := Process(data, 9_999); !errors.Is(err, ErrTooManyPixels) {
+		t.Errorf("Process = %v, want %v", err, ErrTooManyPixels)
+	}
+	if _, err := Process(data, 10_000); err != nil {
+		t.Errorf("Process = %v, want no error", err)
+	}
+}
+
+func TestExifOrientation(t *testing.T) {
+	jpg := encodeJPEG(t, halves(8, 8))
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"no EXIF", jpg, 1},
+		{"rotated", withEXIF(jpg, 8, "x"), 8},
+		{"out of range", withEXIF(jpg, 9, "x"), 1},
+		{"not a JPEG", []byte("GIF89a"), 1},
+		{"truncated segment", withEXIF(jpg, 6, "x")[:12], 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exifOrientation(tt.data); got != tt.want {
+				t.Errorf("exifOrientation = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
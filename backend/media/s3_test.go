@@ -0,0 +1,230 @@
+package media
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a bucket server that checks the SigV4 signature of every
+// request on its own, the way S3 does, and keeps the objects by path.
+type fakeS3 struct {
+	accessKeyID    string
+	secret, region string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+	// rejected lists why requests were refused with a 403.
+	rejected []string
+	// status, when set, answers every signed request.
+	status int
+}
+
+var authorization = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=([^/]+)/(\d{8})/([^/]+)/s3/aws4_request, SignedHeaders=([a-z0-9;-]+), Signature=([0-9a-f]{64})$`)
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{
+		accessKeyID: "AKIDEXAMPLE",
+		secret:      "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		region:      "eu-west-3",
+		objects:     map[string][]byte{},
+		types:       map[string]string{},
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if msg := f.check(r, body); msg != "" {
+		f.rejected = append(f.rejected, r.Method+" "+r.RequestURI+": "+msg)
+		http.Error(w, "<Code>SignatureDoesNotMatch</Code>", http.StatusForbidden)
+		return
+	}
+	if f.status != 0 {
+		http.Error(w, "<Code>InternalError</Code>", f.status)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[r.RequestURI] = body
+		f.types[r.RequestURI] = r.Header.Get("Content-Type")
+	case http.MethodDelete:
+		delete(f.objects, r.RequestURI)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// check returns what is wrong with the signature of r, or "".
+func (f *fakeS3) check(r *http.Request, body []byte) string {
+	m := authorization.FindStringSubmatch(r.Header.Get("Authorization"))
+	if m == nil {
+		return fmt.Sprintf("malformed Authorization %q", r.Header.Get("Authorization"))
+	}
+	accessKeyID, date, region, signedHeaders, signature := m[1], m[2], m[3], m[4], m[5]
+	if accessKeyID != f.accessKeyID || region != f.region {
+		return fmt.Sprintf("credential %s/%s, want %s/%s", accessKeyID, region, f.accessKeyID, f.region)
+	}
+
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	if got := r.Header.Get("X-Amz-Content-Sha256"); got != payloadHash {
+		return fmt.Sprintf("payload hash %q, want %q", got, payloadHash)
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	signedAt, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil || !strings.HasPrefix(amzDate, date) || time.Since(signedAt).Abs() > 15*time.Minute {
+		return fmt.Sprintf("date %q does not match scope %s or the clock", amzDate, date)
+	}
+
+	var headers []string
+	for _, name := range strings.Split(signedHeaders, ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		headers = append(headers, name+":"+strings.TrimSpace(value)+"\n")
+	}
+	for _, required := range []string{"host", "x-amz-content-sha256", "x-amz-date"} {
+		if !strings.Contains(";"+signedHeaders+";", ";"+required+";") {
+			return fmt.Sprintf("%s is not signed", required)
+		}
+	}
+	path, query, _ := strings.Cut(r.RequestURI, "?")
+	canonical := r.Method + "\n" + path + "\n" + query + "\n" + strings.Join(headers, "") + "\n" + signedHeaders + "\n" + payloadHash
+
+	mac := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+	key := mac(mac(mac(mac([]byte("AWS4"+f.secret), date), region), "s3"), "aws4_request")
+	if want := hex.EncodeToString(mac(key, stringToSign)); signature != want {
+		return "signature does not match"
+	}
+	return ""
+}
+
+func newTestS3(t *testing.T, f *fakeS3, endpoint string) *S3 {
+	t.Helper()
+	s, err := NewS3(S3Config{
+		Endpoint:        endpoint + "/",
+		Region:          f.region,
+		Bucket:          "blog-media",
+		AccessKeyID:     f.accessKeyID,
+		SecretAccessKey: f.secret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestS3PutAndDelete(t *testing.T) {
+	f, srv := newFakeS3(t)
+	s := newTestS3(t, f, srv.URL)
+	ctx := context.Background()
+
+	tests := []struct {
+		key  string
+		path string
+	}{
+		{"2024/05/abc.jpg", "/blog-media/2024/05/abc.jpg"},
+		{"2024/05/a b+c~é.png", "/blog-media/2024/05/a%20b%2Bc~%C3%A9.png"},
+		{"empty.gif", "/blog-media/empty.gif"},
+	}
+	for _, tt := range tests {
+		data := []byte("data of " + tt.key)
+		if tt.key == "empty.gif" {
+			data = nil
+		}
+		if err := s.Put(ctx, tt.key, "image/png", data); err != nil {
+			t.Fatalf("Put(%q): %v %v", tt.key, err, f.rejected)
+		}
+		stored, ok := f.objects[tt.path]
+		if !ok || string(stored) != string(data) || f.types[tt.path] != "image/png" {
+			t.Fatalf("Put(%q) stored %v at %q (%q), want %q", tt.key, f.objects, tt.path, f.types[tt.path], data)
+		}
+		if got := s.URL(tt.key); got != srv.URL+tt.path {
+			t.Errorf("URL(%q) = %q, want %q", tt.key, got, srv.URL+tt.path)
+		}
+
+		if err := s.Delete(ctx, tt.key); err != nil {
+			t.Fatalf("Delete(%q): %v %v", tt.key, err, f.rejected)
+		}
+		if _, ok := f.objects[tt.path]; ok {
+			t.Fatalf("Delete(%q) left the object", tt.key)
+		}
+	}
+}
+
+func TestS3Errors(t *testing.T) {
+	f, srv := newFakeS3(t)
+	s := newTestS3(t, f, srv.URL)
+	ctx := context.Background()
+
+	f.status = http.StatusNotFound
+	if err := s.Delete(ctx, "gone.jpg"); err != nil {
+		t.Errorf("Delete of a missing object = %v, want no error", err)
+	}
+	f.status = http.StatusInternalServerError
+	if err := s.Put(ctx, "a.jpg", "image/jpeg", []byte("x")); err == nil || !strings.Contains(err.Error(), "500") || !strings.Contains(err.Error(), "InternalError") {
+		t.Errorf("Put = %v, want the 500 and its body", err)
+	}
+	if err := s.Delete(ctx, "a.jpg"); err == nil {
+		t.Error("Delete succeeded on a 500")
+	}
+}
+
+func TestS3WrongSecret(t *testing.T) {
+	f, srv := newFakeS3(t)
+	s, err := NewS3(S3Config{Endpoint: srv.URL, Region: f.region, Bucket: "b", AccessKeyID: f.accessKeyID, SecretAccessKey: "wrong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(context.Background(), "a.jpg", "image/jpeg", []byte("x")); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put = %v, want a 403", err)
+	}
+	if len(f.rejected) != 1 || !strings.HasSuffix(f.rejected[0], "signature does not match") {
+		t.Errorf("rejected = %q, want a signature mismatch", f.rejected)
+	}
+}
+
+func TestNewS3(t *testing.T) {
+	if _, err := NewS3(S3Config{Endpoint: "https://s3.example.com", Bucket: "b"}); err == nil {
+		t.Error("NewS3 succeeded without credentials")
+	}
+	s, err := NewS3(S3Config{Endpoint: "https://s3.example.com", Bucket: "b", AccessKeyID: "k", SecretAccessKey: "s", PublicURL: "https://cdn.example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.cfg.Region != "us-east-1" {
+		t.Errorf("region = %q, want us-east-1", s.cfg.Region)
+	}
+	if got := s.URL("a/b c.jpg"); got != "https://cdn.example.com/a/b%20c.jpg" {
+		t.Errorf("URL = %q", got)
+	}
+}
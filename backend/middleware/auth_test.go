@@ -0,0 +1,209 @@
+package middleware_test
+
+import (
+	"blog-api/apperr"
+	"blog-api/middleware"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var secret = []byte("test-secret")
+
+// newKey returns a fresh RS256 key.
+func newKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// sign signs claims with method and key, setting the kid header when not
+// empty.
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func claims(sub string, exp time.Duration, aud string) jwt.MapClaims {
+	c := jwt.MapClaims{"sub": sub, "exp": time.Now().Add(exp).Unix()}
+	if aud != "" {
+		c["aud"] = aud
+	}
+	return c
+}
+
+func TestVerify(t *testing.T) {
+	key, other := newKey(t), newKey(t)
+	v, err := middleware.NewVerifier(middleware.AuthConfig{
+		HS256Secret: secret,
+		RSAKeys:     map[string]*rsa.PublicKey{"": &key.PublicKey, "k1": &key.PublicKey},
+		Audience:    "authenticated",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := claims("user-1", time.Hour, "authenticated")
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"HS256", sign(t, jwt.SigningMethodHS256, secret, "", valid), true},
+		{"RS256 without kid", sign(t, jwt.SigningMethodRS256, key, "", valid), true},
+		{"RS256 with kid", sign(t, jwt.SigningMethodRS256, key, "k1", valid), true},
+		{"expired", sign(t, jwt.SigningMethodHS256, secret, "", claims("user-1", -time.Minute, "authenticated")), false},
+		{"without expiry", sign(t, jwt.SigningMethodHS256, secret, "", jwt.MapClaims{"sub": "user-1", "aud": "authenticated"}), false},
+		{"wrong audience", sign(t, jwt.SigningMethodHS256, secret, "", claims("user-1", time.Hour, "anon")), false},
+		{"no audience", sign(t, jwt.SigningMethodHS256, secret, "", claims("user-1", time.Hour, "")), false},
+		{"no subject", sign(t, jwt.SigningMethodHS256, secret, "", claims("", time.Hour, "authenticated")), false},
+		{"wrong secret", sign(t, jwt.SigningMethodHS256, []byte("other"), "", valid), false},
+		{"wrong alg", sign(t, jwt.SigningMethodHS512, secret, "", valid), false},
+		{"alg none", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", valid), false},
+		{"unknown kid", sign(t, jwt.SigningMethodRS256, key, "k2", valid), false},
+		{"wrong RSA key", sign(t, jwt.SigningMethodRS256, other, "", valid), false},
+		{"garbage", "not.a.token", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := v.Verify(tt.token)
+			if tt.ok && (err != nil || sub != "user-1") {
+				t.Fatalf("Verify = %q, %v; want user-1", sub, err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatalf("Verify = %q, want an error", sub)
+			}
+		})
+	}
+}
+
+func TestVerifyAlgorithmConfusion(t *testing.T) {
+	// Without an HS256 secret, a token signed with HS256 is refused rather
+	// than checked against some other key.
+	key := newKey(t)
+	v, err := middleware.NewVerifier(middleware.AuthConfig{RSAKeys: map[string]*rsa.PublicKey{"": &key.PublicKey}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := sign(t, jwt.SigningMethodHS256, []byte("guess"), "", claims("user-1", time.Hour, ""))
+	if sub, err := v.Verify(token); err == nil {
+		t.Fatalf("Verify = %q, want an error", sub)
+	}
+}
+
+func TestVerifyJWKSFile(t *testing.T) {
+	key := newKey(t)
+	doc, err := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "k1",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, doc, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v, err := middleware.NewVerifier(middleware.AuthConfig{JWKSFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sub, err := v.Verify(sign(t, jwt.SigningMethodRS256, key, "k1", claims("user-1", time.Hour, ""))); err != nil || sub != "user-1" {
+		t.Fatalf("Verify = %q, %v; want user-1", sub, err)
+	}
+	if _, err := v.Verify(sign(t, jwt.SigningMethodRS256, key, "", claims("user-1", time.Hour, ""))); err == nil {
+		t.Fatal("Verify accepted a token without kid")
+	}
+}
+
+func TestNewVerifierWithoutKeys(t *testing.T) {
+	if _, err := middleware.NewVerifier(middleware.AuthConfig{}); err == nil {
+		t.Fatal("NewVerifier succeeded without any key")
+	}
+}
+
+func TestAuth(t *testing.T) {
+	v, err := middleware.NewVerifier(middleware.AuthConfig{HS256Secret: secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: apperr.Handler})
+	app.Use(middleware.Auth(v))
+	whoami := func(c *fiber.Ctx) error { return c.SendString(middleware.UserID(c)) }
+	app.Get("/", whoami)
+	app.Post("/", whoami)
+
+	valid := sign(t, jwt.SigningMethodHS256, secret, "", claims("user-1", time.Hour, ""))
+	expired := sign(t, jwt.SigningMethodHS256, secret, "", claims("user-1", -time.Minute, ""))
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		accessToken   string
+		status        int
+		user          string
+	}{
+		{"anonymous", http.MethodGet, "", "", http.StatusOK, ""},
+		{"bearer", http.MethodGet, "Bearer " + valid, "", http.StatusOK, "user-1"},
+		{"lowercase scheme", http.MethodPost, "bearer " + valid, "", http.StatusOK, "user-1"},
+		{"expired", http.MethodGet, "Bearer " + expired, "", http.StatusUnauthorized, ""},
+		{"other scheme", http.MethodGet, "Basic dXNlcjpwYXNz", "", http.StatusUnauthorized, ""},
+		{"empty bearer", http.MethodGet, "Bearer ", "", http.StatusUnauthorized, ""},
+		{"access_token on GET", http.MethodGet, "", valid, http.StatusOK, "user-1"},
+		{"invalid access_token on GET", http.MethodGet, "", expired, http.StatusUnauthorized, ""},
+		{"access_token on POST", http.MethodPost, "", valid, http.StatusOK, ""},
+		{"header over access_token", http.MethodGet, "Bearer " + expired, valid, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/"
+			if tt.accessToken != "" {
+				target += "?access_token=" + url.QueryEscape(tt.accessToken)
+			}
+			req := httptest.NewRequest(tt.method, target, nil)
+			if tt.authorization != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, body)
+			}
+			if tt.status == http.StatusOK && string(body) != tt.user {
+				t.Fatalf("user = %q, want %q", body, tt.user)
+			}
+		})
+	}
+}
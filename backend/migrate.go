@@ -0,0 +1,71 @@
+package main
+
+import (
+	"blog-api/config"
+	"blog-api/db"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// runMigrate implements the "migrate up|down|status|to N" subcommand.
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: migrate up|down|status|to N")
+	}
+
+	if err := cfg.Database.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	db.Connect(cfg.Database)
+	defer db.DB.Close()
+
+	ctx := context.Background()
+	var err error
+	switch args[0] {
+	case "up":
+		err = db.MigrateUp(ctx, db.DB)
+	case "down":
+		err = db.MigrateDown(ctx, db.DB)
+	case "to":
+		if len(args) != 2 {
+			log.Fatal("usage: migrate to N")
+		}
+		target, convErr := strconv.Atoi(args[1])
+		if convErr != nil || target < 0 {
+			log.Fatalf("invalid migration version %q", args[1])
+		}
+		err = db.MigrateTo(ctx, db.DB, target)
+	case "status":
+		err = printMigrationStatus(ctx)
+	default:
+		log.Fatalf("unknown migrate command %q", args[0])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if args[0] != "status" {
+		version, err := db.SchemaVersion(ctx, db.DB)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Schema is at version %d", version)
+	}
+}
+
+func printMigrationStatus(ctx context.Context) error {
+	statuses, err := db.Status(ctx, db.DB)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		state := "pending"
+		if s.AppliedAt != nil {
+			state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%04d  %-30s %s\n", s.Version, s.Name, state)
+	}
+	return nil
+}
@@ -2,48 +2,172 @@ package models
 
 import "time"
 
+// User roles, from least to most trusted. What each one may do is decided
+// by package policy.
+const (
+	RoleReader    = "reader"
+	RoleAuthor    = "author"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
 
 type User struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	FirstName string    `json:"firstname,omitempty"`
-	LastName  string    `json:"lastname,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	FirstName string `json:"firstname,omitempty"`
+	LastName  string `json:"lastname,omitempty"`
+	// AvatarMediaID is the uploaded image shown as the user's avatar.
+	AvatarMediaID *string   `json:"avatar_media_id"`
+	Role          string    `json:"role"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type Profile struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	FirstName string    `json:"firstname,omitempty"`
-	LastName  string    `json:"lastname,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            string    `json:"id"`
+	Email         string    `json:"email"`
+	FirstName     string    `json:"firstname,omitempty"`
+	LastName      string    `json:"lastname,omitempty"`
+	AvatarMediaID *string   `json:"avatar_media_id"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
+// Article lifecycle statuses. Only published articles are visible to
+// readers other than their author.
+const (
+	ArticleDraft     = "draft"
+	ArticleScheduled = "scheduled"
+	ArticlePublished = "published"
+	ArticleArchived  = "archived"
+)
+
 type Article struct {
-	ID        string    `json:"id"`
-	ProfileID string    `json:"profile_id"`
-	Content   string    `json:"content"`
-	UserID    string    `json:"user_id"` 
-	Likes     int       `json:"likes"`
-	CreatedAt time.Time `json:"created_at"`
-	Author    *Profile  `json:"author,omitempty"`
+	ID        string `json:"id"`
+	ProfileID string `json:"profile_id"`
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
+	Excerpt   string `json:"excerpt"`
+	Content   string `json:"content"`
+	// ContentHTML is Content rendered from Markdown and sanitized.
+	ContentHTML string     `json:"content_html"`
+	UserID      string     `json:"user_id"`
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	Tags        []string   `json:"tags"`
+	// CoverMediaID is the uploaded image shown with the article.
+	CoverMediaID *string `json:"cover_media_id"`
+	// Hidden articles were taken down by a moderator; only their author
+	// and moderators see them.
+	Hidden bool `json:"hidden"`
+	Likes  int  `json:"likes"`
+	// Engagement and HotScore are refreshed periodically, not on every
+	// interaction.
+	Engagement int       `json:"engagement"`
+	HotScore   float64   `json:"hot_score"`
+	CreatedAt  time.Time `json:"created_at"`
+	Author     *Profile  `json:"author,omitempty"`
 }
 
+// Tag is a tag name with the number of published articles using it.
+type Tag struct {
+	Name     string `json:"name"`
+	Articles int    `json:"articles"`
+}
+
+// Revision is an immutable snapshot of an article's title and content.
+type Revision struct {
+	ID           string    `json:"id"`
+	ArticleID    string    `json:"article_id"`
+	Number       int       `json:"number"`
+	UserID       string    `json:"user_id"`
+	Title        string    `json:"title"`
+	Content      string    `json:"content"`
+	RestoredFrom *int      `json:"restored_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// DeletedCommentContent replaces the content of a deleted comment kept in
+// place because it still has replies.
+const DeletedCommentContent = "[deleted]"
+
 type Comment struct {
-	ID        string    `json:"id"`
-	ArticleID string    `json:"article_id"`
-	ProfileID string    `json:"profile_id"`
-	Content   string    `json:"content"`
-	UserID    string    `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
-	Author    *Profile  `json:"author,omitempty"`
+	ID        string  `json:"id"`
+	ArticleID string  `json:"article_id"`
+	ProfileID string  `json:"profile_id"`
+	ParentID  *string `json:"parent_id"`
+	// Depth is 0 for top-level comments and grows by one per reply level.
+	Depth   int    `json:"depth"`
+	Content string `json:"content"`
+	// ContentHTML is Content rendered from Markdown and sanitized.
+	ContentHTML string    `json:"content_html"`
+	UserID      string    `json:"user_id"`
+	Deleted     bool      `json:"deleted"`
+	ReplyCount  int       `json:"reply_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	Author      *Profile  `json:"author,omitempty"`
+	// Replies is only filled in comment trees.
+	Replies []Comment `json:"replies,omitempty"`
+}
+
+// Notification kinds.
+const (
+	NotificationFollow  = "follow"
+	NotificationLike    = "like"
+	NotificationComment = "comment"
+	NotificationReply   = "reply"
+)
+
+// Notification tells a user that others followed them, liked or commented on
+// their article, or replied to their comment. Repeated events are merged
+// into one notification until it is read.
+type Notification struct {
+	ID           string  `json:"id"`
+	UserID       string  `json:"user_id"`
+	Kind         string  `json:"kind"`
+	ArticleID    *string `json:"article_id"`
+	ArticleTitle string  `json:"article_title,omitempty"`
+	// CommentID is the recipient's comment that got replies.
+	CommentID *string `json:"comment_id"`
+	// ActorCount is the number of distinct users behind the notification;
+	// Actors holds the most recent of them.
+	ActorCount int       `json:"actor_count"`
+	Actors     []Profile `json:"actors"`
+	Message    string    `json:"message"`
+	Read       bool      `json:"read"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Media is an uploaded image with its resized variants. Keys locate the
+// files in the media storage and URLs are derived from them.
+type Media struct {
+	ID          string         `json:"id"`
+	UserID      string         `json:"user_id"`
+	ContentType string         `json:"content_type"`
+	Size        int64          `json:"size"`
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	Key         string         `json:"-"`
+	URL         string         `json:"url"`
+	Variants    []MediaVariant `json:"variants"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// MediaVariant is a resized copy of an uploaded image, such as "thumb".
+type MediaVariant struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Key         string `json:"-"`
+	URL         string `json:"url"`
 }
 
 type Favorite struct {
 	ID        string    `json:"id"`
 	ProfileID string    `json:"profile_id"`
 	ArticleID string    `json:"article_id"`
-	UserID    string    `json:"user_id"` 
+	UserID    string    `json:"user_id"`
 	CreatedAt time.Time `json:"created_at"`
 	Article   *Article  `json:"article,omitempty"`
 }
@@ -55,4 +179,15 @@ type Follower struct {
 	CreatedAt   time.Time `json:"created_at"`
 	Follower    *Profile  `json:"follower,omitempty"`
 	Following   *Profile  `json:"following,omitempty"`
-}
\ No newline at end of file
+}
+
+type SearchResult struct {
+	Type      string    `json:"type"` // "article" or "comment"
+	ID        string    `json:"id"`
+	ArticleID string    `json:"article_id"`
+	UserID    string    `json:"user_id"`
+	Snippet   string    `json:"snippet"`
+	Rank      float64   `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+	Author    *Profile  `json:"author,omitempty"`
+}
@@ -0,0 +1,115 @@
+// Package notify turns user actions into notifications for the users they
+// concern.
+package notify
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Kinds lists every notification kind, in the order preferences show them.
+var Kinds = []string{
+	models.NotificationFollow,
+	models.NotificationLike,
+	models.NotificationComment,
+	models.NotificationReply,
+}
+
+// Event is something ActorID did that RecipientID should hear about.
+type Event struct {
+	Kind        string
+	RecipientID string
+	ActorID     string
+	ArticleID   string
+	// CommentID is the recipient's comment for replies.
+	CommentID string
+}
+
+// Service records events as notifications, honouring mute preferences.
+type Service struct {
+	store store.NotificationStore
+}
+
+func NewService(s store.NotificationStore) *Service {
+	return &Service{store: s}
+}
+
+// Emit records e unless it is about the actor's own content or the recipient
+// muted its kind. It returns the stored notification, or nil when nothing was
+// recorded.
+func (s *Service) Emit(ctx context.Context, e Event) (*models.Notification, error) {
+	if e.RecipientID == "" || e.RecipientID == e.ActorID {
+		return nil, nil
+	}
+	muted, err := s.store.Muted(ctx, e.RecipientID)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(muted, e.Kind) {
+		return nil, nil
+	}
+
+	n := &models.Notification{UserID: e.RecipientID, Kind: e.Kind}
+	if e.ArticleID != "" {
+		n.ArticleID = &e.ArticleID
+	}
+	if e.CommentID != "" {
+		n.CommentID = &e.CommentID
+	}
+	if err := s.store.Add(ctx, n, e.ActorID); err != nil {
+		return nil, fmt.Errorf("notifying %s of %s: %w", e.RecipientID, e.Kind, err)
+	}
+	return n, nil
+}
+
+// ValidKind reports whether kind is a notification kind.
+func ValidKind(kind string) bool {
+	return slices.Contains(Kinds, kind)
+}
+
+// Message describes a notification in a sentence, naming its most recent
+// actor and counting the others: "Ann and 11 others liked your article".
+func Message(n models.Notification) string {
+	who := "Someone"
+	if len(n.Actors) > 0 {
+		who = displayName(n.Actors[0])
+	}
+	switch others := n.ActorCount - 1; {
+	case others == 1 && len(n.Actors) > 1:
+		who += " and " + displayName(n.Actors[1])
+	case others == 1:
+		who += " and 1 other"
+	case others > 1:
+		who += fmt.Sprintf(" and %d others", others)
+	}
+
+	article := "your article"
+	if n.ArticleTitle != "" {
+		article = fmt.Sprintf("your article %q", n.ArticleTitle)
+	}
+	switch n.Kind {
+	case models.NotificationFollow:
+		return who + " started following you"
+	case models.NotificationLike:
+		return who + " liked " + article
+	case models.NotificationComment:
+		return who + " commented on " + article
+	case models.NotificationReply:
+		return who + " replied to your comment on " + strings.TrimPrefix(article, "your ")
+	}
+	return who + " interacted with you"
+}
+
+func displayName(p models.Profile) string {
+	if name := strings.TrimSpace(p.FirstName + " " + p.LastName); name != "" {
+		return name
+	}
+	if p.Email != "" {
+		return p.Email
+	}
+	return "Someone"
+}
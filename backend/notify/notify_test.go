@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"blog-api/models"
+	"testing"
+)
+
+func TestMessage(t *testing.T) {
+	ann := models.Profile{FirstName: "Ann", LastName: "Lee"}
+	bob := models.Profile{FirstName: "Bob"}
+	mail := models.Profile{Email: "cy@example.com"}
+
+	tests := []struct {
+		n    models.Notification
+		want string
+	}{
+		{models.Notification{Kind: models.NotificationFollow, ActorCount: 1, Actors: []models.Profile{ann}}, "Ann Lee started following you"},
+		{models.Notification{Kind: models.NotificationLike, ActorCount: 2, Actors: []models.Profile{ann, bob}, ArticleTitle: "Go"}, `Ann Lee and Bob liked your article "Go"`},
+		{models.Notification{Kind: models.NotificationLike, ActorCount: 2, Actors: []models.Profile{mail}}, "cy@example.com and 1 other liked your article"},
+		{models.Notification{Kind: models.NotificationComment, ActorCount: 12, Actors: []models.Profile{bob, ann, mail}, ArticleTitle: "Go"}, `Bob and 11 others commented on your article "Go"`},
+		{models.Notification{Kind: models.NotificationReply, ActorCount: 1, Actors: []models.Profile{{}}, ArticleTitle: "Go"}, `Someone replied to your comment on article "Go"`},
+		{models.Notification{Kind: models.NotificationReply, ActorCount: 1}, "Someone replied to your comment on article"},
+		{models.Notification{Kind: "other", ActorCount: 1, Actors: []models.Profile{bob}}, "Bob interacted with you"},
+	}
+	for _, tt := range tests {
+		if got := Message(tt.n); got != tt.want {
+			t.Errorf("Message(%+v) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestValidKind(t *testing.T) {
+	for _, kind := range Kinds {
+		if !ValidKind(kind) {
+			t.Errorf("ValidKind(%q) = false", kind)
+		}
+	}
+	if ValidKind("mention") {
+		t.Error("ValidKind(mention) = true")
+	}
+}
@@ -0,0 +1,98 @@
+// Package policy decides what users may do according to their role.
+//
+// Roles grant permissions, checked before an action whatever resource it
+// touches (RequirePermission in the handlers). Actions on a given article,
+// comment or user go through the Can* functions, which weigh ownership
+// against the permissions that override it.
+package policy
+
+import "blog-api/models"
+
+// Permission is something a role allows regardless of ownership.
+type Permission string
+
+const (
+	// WriteComments allows commenting on articles.
+	WriteComments Permission = "comments:write"
+	// WriteArticles allows writing, updating and restoring one's articles.
+	WriteArticles Permission = "articles:write"
+	// ModerateComments allows editing and deleting anyone's comments.
+	ModerateComments Permission = "comments:moderate"
+	// ModerateArticles allows hiding and showing anyone's articles, and
+	// seeing hidden ones.
+	ModerateArticles Permission = "articles:moderate"
+	// DeleteArticles allows deleting anyone's articles.
+	DeleteArticles Permission = "articles:delete"
+	// ManageRoles allows listing users and changing their roles.
+	ManageRoles Permission = "users:roles"
+)
+
+var permissions = map[string][]Permission{
+	models.RoleReader:    {WriteComments},
+	models.RoleAuthor:    {WriteComments, WriteArticles},
+	models.RoleModerator: {WriteComments, WriteArticles, ModerateComments, ModerateArticles},
+	models.RoleAdmin:     {WriteComments, WriteArticles, ModerateComments, ModerateArticles, DeleteArticles, ManageRoles},
+}
+
+// Roles lists the valid roles, from least to most trusted.
+var Roles = []string{models.RoleReader, models.RoleAuthor, models.RoleModerator, models.RoleAdmin}
+
+// ValidRole reports whether role is one of Roles.
+func ValidRole(role string) bool {
+	_, ok := permissions[role]
+	return ok
+}
+
+// Actor is the user performing an action. The zero Actor is anonymous and
+// has no permission.
+type Actor struct {
+	ID   string
+	Role string
+}
+
+// Can reports whether the actor's role grants p.
+func (a Actor) Can(p Permission) bool {
+	for _, granted := range permissions[a.Role] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Actor) owns(userID string) bool {
+	return a.ID != "" && a.ID == userID
+}
+
+// CanViewArticle: published articles that are not hidden are public; the
+// others are visible to their author, and hidden ones to moderators too.
+func CanViewArticle(a Actor, article *models.Article) bool {
+	if a.owns(article.UserID) {
+		return true
+	}
+	if article.Status != models.ArticlePublished {
+		return false
+	}
+	return !article.Hidden || a.Can(ModerateArticles)
+}
+
+// CanDeleteArticle: the author, or admins.
+func CanDeleteArticle(a Actor, article *models.Article) bool {
+	return a.owns(article.UserID) || a.Can(DeleteArticles)
+}
+
+// CanEditComment: the author, or moderators.
+func CanEditComment(a Actor, comment *models.Comment) bool {
+	return a.owns(comment.UserID) || a.Can(ModerateComments)
+}
+
+// CanDeleteComment: the author, or moderators.
+func CanDeleteComment(a Actor, comment *models.Comment) bool {
+	return a.owns(comment.UserID) || a.Can(ModerateComments)
+}
+
+// CanSetRole: admins, on anyone but themselves, so that the last admin
+// cannot lock everyone out by mistake.
+func CanSetRole(a Actor, user *models.User) bool {
+	return a.Can(ManageRoles) && !a.owns(user.ID)
+}
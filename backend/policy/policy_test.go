@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"blog-api/models"
+	"testing"
+)
+
+func TestCan(t *testing.T) {
+	tests := []struct {
+		role string
+		p    Permission
+		want bool
+	}{
+		{models.RoleReader, WriteComments, true},
+		{models.RoleReader, WriteArticles, false},
+		{models.RoleAuthor, WriteArticles, true},
+		{models.RoleAuthor, ModerateComments, false},
+		{models.RoleModerator, ModerateArticles, true},
+		{models.RoleModerator, DeleteArticles, false},
+		{models.RoleAdmin, DeleteArticles, true},
+		{models.RoleAdmin, ManageRoles, true},
+		{"", WriteComments, false},
+		{"owner", WriteComments, false},
+	}
+	for _, tt := range tests {
+		if got := (Actor{ID: "u", Role: tt.role}).Can(tt.p); got != tt.want {
+			t.Errorf("%q can %s = %v, want %v", tt.role, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestValidRole(t *testing.T) {
+	for _, role := range Roles {
+		if !ValidRole(role) {
+			t.Errorf("ValidRole(%q) = false", role)
+		}
+	}
+	if ValidRole("owner") || ValidRole("") {
+		t.Error("ValidRole accepts unknown roles")
+	}
+}
+
+func TestCanViewArticle(t *testing.T) {
+	author := Actor{ID: "author", Role: models.RoleAuthor}
+	reader := Actor{ID: "reader", Role: models.RoleReader}
+	moderator := Actor{ID: "moderator", Role: models.RoleModerator}
+	anonymous := Actor{}
+	published := &models.Article{UserID: "author", Status: models.ArticlePublished}
+	hidden := &models.Article{UserID: "author", Status: models.ArticlePublished, Hidden: true}
+	draft := &models.Article{UserID: "author", Status: models.ArticleDraft}
+
+	tests := []struct {
+		name    string
+		actor   Actor
+		article *models.Article
+		want    bool
+	}{
+		{"published to anyone", anonymous, published, true},
+		{"hidden to its author", author, hidden, true},
+		{"hidden to a moderator", moderator, hidden, true},
+		{"hidden to a reader", reader, hidden, false},
+		{"hidden anonymously", anonymous, hidden, false},
+		{"draft to its author", author, draft, true},
+		{"draft to a moderator", moderator, draft, false},
+		{"anonymous draft", anonymous, &models.Article{Status: models.ArticleDraft}, false},
+	}
+	for _, tt := range tests {
+		if got := CanViewArticle(tt.actor, tt.article); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOwnershipRules(t *testing.T) {
+	author := Actor{ID: "author", Role: models.RoleAuthor}
+	other := Actor{ID: "other", Role: models.RoleAuthor}
+	moderator := Actor{ID: "moderator", Role: models.RoleModerator}
+	admin := Actor{ID: "admin", Role: models.RoleAdmin}
+	article := &models.Article{UserID: "author"}
+	comment := &models.Comment{UserID: "author"}
+
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"author deletes their article", CanDeleteArticle(author, article), true},
+		{"other deletes the article", CanDeleteArticle(other, article), false},
+		{"moderator deletes the article", CanDeleteArticle(moderator, article), false},
+		{"admin deletes the article", CanDeleteArticle(admin, article), true},
+		{"author edits their comment", CanEditComment(author, comment), true},
+		{"other edits the comment", CanEditComment(other, comment), false},
+		{"moderator edits the comment", CanEditComment(moderator, comment), true},
+		{"moderator deletes the comment", CanDeleteComment(moderator, comment), true},
+		{"other deletes the comment", CanDeleteComment(other, comment), false},
+		{"admin sets another role", CanSetRole(admin, &models.User{ID: "author"}), true},
+		{"admin sets their own role", CanSetRole(admin, &models.User{ID: "admin"}), false},
+		{"moderator sets a role", CanSetRole(moderator, &models.User{ID: "author"}), false},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
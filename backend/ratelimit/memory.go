@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Memory forgets the buckets that are full.
+const sweepInterval = time.Minute
+
+// Memory keeps the buckets of a single instance.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	bucket
+	// full is when the bucket is full again and can be forgotten.
+	full time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*memoryBucket)}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, p Policy, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		for k, b := range m.buckets {
+			if !now.Before(b.full) {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &memoryBucket{bucket: newBucket(p, now)}
+		m.buckets[key] = b
+	}
+	res := b.take(p, now)
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"blog-api/worker"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Postgres keeps the buckets in the rate_limits table, shared by every
+// instance using the same database. Each Take locks the row of its bucket,
+// so concurrent requests of a client never spend the same token twice.
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (s *Postgres) Take(ctx context.Context, key string, p Policy, now time.Time) (Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback()
+
+	b := newBucket(p, now)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rate_limits (key, tokens, updated_at, full_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (key) DO NOTHING
+	`, key, b.tokens, b.updated)
+	if err != nil {
+		return Result{}, err
+	}
+	err = tx.QueryRowContext(ctx, `
+		SELECT tokens, updated_at FROM rate_limits WHERE key = $1 FOR UPDATE
+	`, key).Scan(&b.tokens, &b.updated)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := b.take(p, now)
+	_, err = tx.ExecContext(ctx, `
+		UPDATE rate_limits SET tokens = $2, updated_at = $3, full_at = $4
+		WHERE key = $1
+	`, key, b.tokens, b.updated, now.Add(res.Reset))
+	if err != nil {
+		return Result{}, err
+	}
+	return res, tx.Commit()
+}
+
+// Prune deletes the buckets that are full as of now, which a new request
+// would recreate as they are, and returns how many it deleted.
+func (s *Postgres) Prune(ctx context.Context, now time.Time) (int, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM rate_limits WHERE full_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// PruneJob runs Prune periodically.
+func (s *Postgres) PruneJob(interval time.Duration) worker.Job {
+	return worker.Job{
+		Name:     "prune-rate-limits",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			_, err := s.Prune(ctx, time.Now())
+			return err
+		},
+	}
+}
@@ -0,0 +1,168 @@
+// Package ratelimit throttles clients with token buckets.
+//
+// Each policy gives a client a bucket of Burst tokens refilled at Requests
+// per Per; a request takes one token and is refused with a 429 when the
+// bucket is empty. Buckets live in a Store: Memory for a single instance,
+// Postgres to share them between instances.
+package ratelimit
+
+import (
+	"blog-api/apperr"
+	"blog-api/middleware"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Policy is the rate allowed on a group of routes.
+type Policy struct {
+	// Requests are allowed per Per on average.
+	Requests int
+	Per      time.Duration
+	// Burst is the size of the bucket, the number of requests allowed in a
+	// row after a quiet period. It defaults to Requests.
+	Burst int
+}
+
+func (p Policy) burst() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Requests
+}
+
+// rate is the number of tokens added per second.
+func (p Policy) rate() float64 {
+	return float64(p.Requests) / p.Per.Seconds()
+}
+
+// Result is the state of a bucket after a request took from it.
+type Result struct {
+	Allowed bool
+	// Limit is the size of the bucket and Remaining the whole tokens left.
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token when the request was
+	// refused.
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets.
+type Store interface {
+	// Take removes a token from the bucket key, refilled according to p as
+	// of now. A bucket that does not exist yet is full.
+	Take(ctx context.Context, key string, p Policy, now time.Time) (Result, error)
+}
+
+// bucket is the state shared by the stores.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func newBucket(p Policy, now time.Time) bucket {
+	return bucket{tokens: float64(p.burst()), updated: now}
+}
+
+// take refills b up to now and removes a token when there is a whole one.
+// Clocks of several instances may disagree slightly: a bucket is never
+// refilled for a negative elapsed time.
+func (b *bucket) take(p Policy, now time.Time) Result {
+	burst := float64(p.burst())
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*p.rate())
+		b.updated = now
+	}
+
+	res := Result{Limit: p.burst()}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / p.rate())
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((burst - b.tokens) / p.rate())
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Key identifies the client of a request: the authenticated user, or the
+// IP address of anonymous clients.
+func Key(c *fiber.Ctx) string {
+	if userID := middleware.UserID(c); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.IP()
+}
+
+// Limit returns a middleware applying p to the requests it handles, with a
+// bucket per client under name. Every response carries the RateLimit-Limit,
+// RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers; refused
+// requests get a 429 with Retry-After. When the store fails the request goes
+// through.
+func Limit(store Store, name string, p Policy) fiber.Handler {
+	policy := fmt.Sprintf("%d;w=%d", p.burst(), ceilSeconds(seconds(float64(p.burst())/p.rate())))
+	return func(c *fiber.Ctx) error {
+		res, err := store.Take(c.UserContext(), name+":"+Key(c), p, time.Now())
+		if err != nil {
+			log.Printf("[%s] rate limit %s: %v", apperr.RequestID(c), name, err)
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		c.Set("RateLimit-Policy", policy)
+		if !res.Allowed {
+			retry := ceilSeconds(res.RetryAfter)
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retry))
+			return apperr.New(fiber.StatusTooManyRequests, "rate_limited",
+				fmt.Sprintf("Too many requests, retry in %ds", retry))
+		}
+		return c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// ParsePolicies reads policies written name=requests/period and separated
+// by commas, such as "comments=10/1m,likes=60/1m". Zero requests turn the
+// limit off.
+func ParsePolicies(s string) (map[string]Policy, error) {
+	policies := make(map[string]Policy)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rate, ok := strings.Cut(entry, "=")
+		requests, period, ok2 := strings.Cut(rate, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("rate limit %q: want name=requests/period", entry)
+		}
+		n, err := strconv.Atoi(requests)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("rate limit %q: invalid number of requests", entry)
+		}
+		per, err := time.ParseDuration(period)
+		if err != nil || per <= 0 {
+			return nil, fmt.Errorf("rate limit %q: invalid period", entry)
+		}
+		policies[strings.TrimSpace(name)] = Policy{Requests: n, Per: per}
+	}
+	return policies, nil
+}
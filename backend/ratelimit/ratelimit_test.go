@@ -0,0 +1,225 @@
+package ratelimit
+
+import (
+	"blog-api/apperr"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// clock is a time the tests move by hand.
+type clock struct{ now time.Time }
+
+func (c *clock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// clockStore takes from a store as of the clock rather than the time
+// Limit passes.
+type clockStore struct {
+	Store
+	clock *clock
+}
+
+func (s clockStore) Take(ctx context.Context, key string, p Policy, _ time.Time) (Result, error) {
+	return s.Store.Take(ctx, key, p, s.clock.now)
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func TestMemoryBurstAndRefill(t *testing.T) {
+	m := NewMemory()
+	clk := newClock()
+	p := Policy{Requests: 3, Per: 3 * time.Second} // a token per second
+	take := func() Result {
+		t.Helper()
+		res, err := m.Take(context.Background(), "k", p, clk.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	// A new bucket is full: the whole burst goes through at once.
+	for want := 2; want >= 0; want-- {
+		if res := take(); !res.Allowed || res.Remaining != want || res.Limit != 3 {
+			t.Fatalf("take = %+v, want allowed with %d remaining", res, want)
+		}
+	}
+	res := take()
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != time.Second || res.Reset != 3*time.Second {
+		t.Fatalf("take on an empty bucket = %+v, want refused, retry in 1s, full in 3s", res)
+	}
+
+	// Half a token is not enough.
+	clk.advance(500 * time.Millisecond)
+	if res := take(); res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("take after 500ms = %+v, want refused, retry in 500ms", res)
+	}
+	clk.advance(500 * time.Millisecond)
+	if res := take(); !res.Allowed || res.Remaining != 0 || res.Reset != 3*time.Second {
+		t.Fatalf("take after 1s = %+v, want allowed, full in 3s", res)
+	}
+
+	// Refilling stops at the burst.
+	clk.advance(time.Hour)
+	if res := take(); !res.Allowed || res.Remaining != 2 || res.Reset != time.Second {
+		t.Fatalf("take after an hour = %+v, want allowed with 2 remaining, full in 1s", res)
+	}
+}
+
+func TestMemoryBurst(t *testing.T) {
+	m := NewMemory()
+	clk := newClock()
+	p := Policy{Requests: 1, Per: time.Minute, Burst: 5}
+	for i := 0; i < 5; i++ {
+		if res, _ := m.Take(context.Background(), "k", p, clk.now); !res.Allowed {
+			t.Fatalf("request %d refused within the burst: %+v", i+1, res)
+		}
+	}
+	res, _ := m.Take(context.Background(), "k", p, clk.now)
+	if res.Allowed || res.Limit != 5 || res.RetryAfter != time.Minute || res.Reset != 5*time.Minute {
+		t.Fatalf("take past the burst = %+v, want refused, retry in 1m, full in 5m", res)
+	}
+}
+
+func TestMemoryKeysAndClockSkew(t *testing.T) {
+	m := NewMemory()
+	clk := newClock()
+	p := Policy{Requests: 1, Per: time.Minute}
+	ctx := context.Background()
+
+	if res, _ := m.Take(ctx, "a", p, clk.now); !res.Allowed {
+		t.Fatal("first request of a refused")
+	}
+	if res, _ := m.Take(ctx, "b", p, clk.now); !res.Allowed {
+		t.Fatal("b shares the bucket of a")
+	}
+	// A clock behind the last update refills nothing.
+	if res, _ := m.Take(ctx, "a", p, clk.now.Add(-time.Hour)); res.Allowed || res.RetryAfter != time.Minute {
+		t.Fatalf("take with a clock behind = %+v, want refused, retry in 1m", res)
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	m := NewMemory()
+	clk := newClock()
+	p := Policy{Requests: 2, Per: time.Second}
+	ctx := context.Background()
+
+	m.Take(ctx, "idle", p, clk.now)
+	clk.advance(sweepInterval)
+	m.Take(ctx, "busy", p, clk.now)
+	m.Take(ctx, "busy", p, clk.now)
+	if _, ok := m.buckets["idle"]; ok {
+		t.Error("a full bucket was kept past the sweep")
+	}
+	if _, ok := m.buckets["busy"]; !ok {
+		t.Error("a bucket in use was swept")
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Policy, time.Time) (Result, error) {
+	return Result{}, errors.New("store down")
+}
+
+func TestLimit(t *testing.T) {
+	clk := newClock()
+	app := fiber.New(fiber.Config{ErrorHandler: apperr.Handler})
+	app.Get("/", Limit(clockStore{NewMemory(), clk}, "test", Policy{Requests: 2, Per: time.Minute}), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	get := func() *http.Response {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	check := func(resp *http.Response, status int, headers map[string]string) {
+		t.Helper()
+		if resp.StatusCode != status {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, status)
+		}
+		for name, want := range headers {
+			if got := resp.Header.Get(name); got != want {
+				t.Errorf("%s = %q, want %q", name, got, want)
+			}
+		}
+	}
+
+	// A token every 30s, two at most.
+	check(get(), http.StatusOK, map[string]string{
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "1",
+		"RateLimit-Reset":     "30",
+		"RateLimit-Policy":    "2;w=60",
+		"Retry-After":         "",
+	})
+	check(get(), http.StatusOK, map[string]string{"RateLimit-Remaining": "0", "RateLimit-Reset": "60"})
+
+	clk.advance(10 * time.Second)
+	resp := get()
+	check(resp, http.StatusTooManyRequests, map[string]string{
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "50",
+		"Retry-After":         "20",
+	})
+	var problem struct{ Code string }
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil || problem.Code != "rate_limited" {
+		t.Errorf("problem code = %q (%v), want rate_limited", problem.Code, err)
+	}
+
+	clk.advance(20 * time.Second)
+	check(get(), http.StatusOK, map[string]string{"RateLimit-Remaining": "0", "RateLimit-Reset": "60"})
+}
+
+func TestLimitStoreFailure(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", Limit(failingStore{}, "test", Policy{Requests: 1, Per: time.Minute}), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("RateLimit-Limit") != "" {
+		t.Fatalf("status = %d with RateLimit-Limit %q, want 200 without headers", resp.StatusCode, resp.Header.Get("RateLimit-Limit"))
+	}
+}
+
+func TestParsePolicies(t *testing.T) {
+	got, err := ParsePolicies(" comments=10/1m, likes=0/1s,,uploads=5/1h ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Policy{
+		"comments": {Requests: 10, Per: time.Minute},
+		"likes":    {Requests: 0, Per: time.Second},
+		"uploads":  {Requests: 5, Per: time.Hour},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ParsePolicies = %v, want %v", got, want)
+	}
+	for name, p := range want {
+		if got[name] != p {
+			t.Errorf("%s = %+v, want %+v", name, got[name], p)
+		}
+	}
+
+	for _, s := range []string{"comments", "comments=10", "comments=x/1m", "comments=-1/1m", "comments=10/x", "comments=10/0s"} {
+		if _, err := ParsePolicies(s); err == nil {
+			t.Errorf("ParsePolicies(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Broadcaster publishes messages to the subscribers of every instance of
+// the API.
+type Broadcaster interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// NewMessage encodes data as the payload of an event on channel.
+func NewMessage(channel, event string, data interface{}) (Message, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Channel: channel, Event: event, Data: payload}, nil
+}
+
+// Local is the Broadcaster of a single instance: messages go straight to
+// its hub.
+type Local struct {
+	hub *Hub
+}
+
+func NewLocal(hub *Hub) *Local {
+	return &Local{hub: hub}
+}
+
+func (l *Local) Publish(ctx context.Context, msg Message) error {
+	l.hub.Deliver(msg)
+	return nil
+}
@@ -0,0 +1,116 @@
+// Package realtime fans out live events, such as new comments or like
+// counts, to the clients subscribed to their channel.
+package realtime
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// subscriptionBuffer is how many messages a slow subscriber may fall behind
+// before new ones are dropped for it.
+const subscriptionBuffer = 32
+
+// Message is one event on a channel. Data is the JSON payload; it is null
+// when the payload was too large to broadcast and clients should refetch.
+type Message struct {
+	Channel string          `json:"channel"`
+	Event   string          `json:"event"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// ArticleChannel carries the new comments and like counts of an article.
+func ArticleChannel(articleID string) string {
+	return "article:" + articleID
+}
+
+// UserChannel carries the notifications of a user.
+func UserChannel(userID string) string {
+	return "user:" + userID
+}
+
+// Hub delivers messages to the subscriptions of this process.
+type Hub struct {
+	mu     sync.RWMutex
+	subs   map[string]map[*Subscription]struct{}
+	closed bool
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[string]map[*Subscription]struct{})}
+}
+
+// Subscription receives the messages of its channels on C until it is
+// closed, by the subscriber or by Hub.Close.
+type Subscription struct {
+	C        <-chan Message
+	c        chan Message
+	hub      *Hub
+	channels []string
+	once     sync.Once
+}
+
+// Subscribe listens on the given channels.
+func (h *Hub) Subscribe(channels ...string) *Subscription {
+	c := make(chan Message, subscriptionBuffer)
+	sub := &Subscription{C: c, c: c, hub: h, channels: channels}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		sub.once.Do(func() { close(c) })
+		return sub
+	}
+	for _, ch := range channels {
+		if h.subs[ch] == nil {
+			h.subs[ch] = make(map[*Subscription]struct{})
+		}
+		h.subs[ch][sub] = struct{}{}
+	}
+	return sub
+}
+
+// Close stops the subscription and closes C.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.close()
+}
+
+// close removes the subscription. Callers must hold hub.mu for writing.
+func (s *Subscription) close() {
+	s.once.Do(func() {
+		for _, ch := range s.channels {
+			delete(s.hub.subs[ch], s)
+			if len(s.hub.subs[ch]) == 0 {
+				delete(s.hub.subs, ch)
+			}
+		}
+		close(s.c)
+	})
+}
+
+// Deliver hands msg to every local subscriber of its channel without
+// blocking: a subscriber whose buffer is full misses the message.
+func (h *Hub) Deliver(msg Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs[msg.Channel] {
+		select {
+		case sub.c <- msg:
+		default:
+		}
+	}
+}
+
+// Close ends every subscription, for example when the server shuts down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, subs := range h.subs {
+		for sub := range subs {
+			sub.close()
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// receive returns the messages waiting on sub without blocking.
+func receive(sub *Subscription) []Message {
+	var msgs []Message
+	for {
+		select {
+		case msg, ok := <-sub.C:
+			if !ok {
+				return msgs
+			}
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub()
+	broadcaster := NewLocal(hub)
+	article := hub.Subscribe(ArticleChannel("a1"))
+	both := hub.Subscribe(ArticleChannel("a1"), UserChannel("u1"))
+
+	for _, m := range []struct{ channel, event string }{
+		{ArticleChannel("a1"), "comment"},
+		{UserChannel("u1"), "notification"},
+		{ArticleChannel("a2"), "comment"},
+	} {
+		msg, err := NewMessage(m.channel, m.event, map[string]string{"id": "x"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := broadcaster.Publish(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := receive(article); len(got) != 1 || got[0].Event != "comment" || string(got[0].Data) != `{"id":"x"}` {
+		t.Fatalf("article subscription got %+v, want the a1 comment", got)
+	}
+	if got := receive(both); len(got) != 2 || got[1].Channel != "user:u1" {
+		t.Fatalf("two-channel subscription got %+v, want the comment and the notification", got)
+	}
+
+	// A closed subscription is removed from the hub.
+	article.Close()
+	article.Close()
+	if _, ok := <-article.C; ok {
+		t.Fatal("C is still open after Close")
+	}
+	if n := len(hub.subs[ArticleChannel("a1")]); n != 1 {
+		t.Fatalf("a1 has %d subscriptions, want 1", n)
+	}
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe("c")
+	for i := range subscriptionBuffer + 5 {
+		hub.Deliver(Message{Channel: "c", Event: fmt.Sprint(i)})
+	}
+	got := receive(sub)
+	if len(got) != subscriptionBuffer || got[0].Event != "0" {
+		t.Fatalf("got %d messages, want the first %d", len(got), subscriptionBuffer)
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe("c")
+	hub.Close()
+	if _, ok := <-sub.C; ok {
+		t.Fatal("subscription still open after Hub.Close")
+	}
+	sub.Close()
+
+	late := hub.Subscribe("c")
+	if _, ok := <-late.C; ok {
+		t.Fatal("subscription on a closed hub is open")
+	}
+	// Its subscriber closes it as usual.
+	late.Close()
+	hub.Deliver(Message{Channel: "c"})
+}
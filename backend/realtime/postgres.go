@@ -0,0 +1,84 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// notifyChannel is the PostgreSQL channel shared by every instance.
+const notifyChannel = "blog_events"
+
+// maxNotifyPayload stays under the 8000 byte limit of NOTIFY payloads.
+const maxNotifyPayload = 7900
+
+// Postgres broadcasts through PostgreSQL LISTEN/NOTIFY, so that every
+// instance connected to the same database delivers each message to its own
+// hub, including the one that published it.
+type Postgres struct {
+	db       *sql.DB
+	hub      *Hub
+	listener *pq.Listener
+	done     chan struct{}
+}
+
+// NewPostgres starts listening on a dedicated connection opened with dsn.
+// Messages are published through db.
+func NewPostgres(db *sql.DB, dsn string, hub *Hub) (*Postgres, error) {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("realtime: listener: %v", err)
+		}
+	})
+	if err := listener.Listen(notifyChannel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	p := &Postgres{db: db, hub: hub, listener: listener, done: make(chan struct{})}
+	go p.receive()
+	return p, nil
+}
+
+func (p *Postgres) Publish(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		msg.Data = nil
+		if payload, err = json.Marshal(msg); err != nil {
+			return err
+		}
+	}
+	_, err = p.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, notifyChannel, string(payload))
+	return err
+}
+
+func (p *Postgres) receive() {
+	defer close(p.done)
+	for n := range p.listener.Notify {
+		// A nil notification means the connection was re-established;
+		// messages sent meanwhile are lost.
+		if n == nil {
+			continue
+		}
+		var msg Message
+		if err := json.Unmarshal([]byte(n.Extra), &msg); err != nil {
+			log.Printf("realtime: invalid notification: %v", err)
+			continue
+		}
+		p.hub.Deliver(msg)
+	}
+}
+
+// Close stops listening.
+func (p *Postgres) Close() error {
+	err := p.listener.Close()
+	<-p.done
+	return err
+}
@@ -0,0 +1,85 @@
+// Package server runs the HTTP server until a shutdown signal, then stops
+// it gracefully: it stops accepting connections and waits, up to a deadline,
+// for the requests in progress to finish.
+package server
+
+import (
+	"blog-api/apperr"
+	"blog-api/config"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Run serves app on addr until ctx is done, over TLS when cfg names a
+// certificate. It then keeps serving for cfg.ShutdownDelay, runs the
+// onShutdown functions, which must end the requests that would otherwise
+// never finish, such as event streams, and shuts app down. Requests still
+// running after cfg.ShutdownTimeout are abandoned. Run only returns an
+// error when the server could not serve.
+func Run(ctx context.Context, app *fiber.App, addr string, cfg config.Server, onShutdown ...func()) error {
+	ln, err := net.Listen(app.Config().Network, addr)
+	if err != nil {
+		return err
+	}
+	if cfg.TLSCertFile != "" {
+		certs, err := LoadCertificates(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			ln.Close()
+			return err
+		}
+		go certs.Watch(ctx, cfg.TLSReloadInterval)
+		ln = tls.NewListener(ln, &tls.Config{
+			GetCertificate: certs.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		})
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- app.Listener(ln) }()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	if cfg.ShutdownDelay > 0 {
+		log.Printf("Shutting down in %s", cfg.ShutdownDelay)
+		time.Sleep(cfg.ShutdownDelay)
+	}
+	log.Printf("Shutting down, waiting up to %s for requests in progress", cfg.ShutdownTimeout)
+	for _, f := range onShutdown {
+		f()
+	}
+	if err := app.ShutdownWithTimeout(cfg.ShutdownTimeout); err != nil {
+		log.Printf("Shutdown: requests still in progress abandoned: %v", err)
+		return nil
+	}
+	return <-served
+}
+
+// BodyLimit returns a middleware refusing request bodies larger than limit
+// bytes with a 413. The upload routes, written "METHOD /path", are left to
+// their handler. Middlewares only run once fasthttp has read the whole body:
+// this keeps handlers from parsing large bodies, while the memory a request
+// takes is bounded by fiber.Config.BodyLimit, which must cover the uploads.
+func BodyLimit(limit int, uploads ...string) fiber.Handler {
+	errTooLarge := apperr.New(fiber.StatusRequestEntityTooLarge, "body_too_large",
+		fmt.Sprintf("Request body too large (max %d bytes)", limit))
+	exempt := make(map[string]bool, len(uploads))
+	for _, route := range uploads {
+		exempt[strings.TrimSuffix(route, "/")] = true
+	}
+	return func(c *fiber.Ctx) error {
+		if len(c.Request().Body()) > limit && !exempt[c.Method()+" "+strings.TrimSuffix(c.Path(), "/")] {
+			return errTooLarge
+		}
+		return c.Next()
+	}
+}
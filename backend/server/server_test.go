@@ -0,0 +1,221 @@
+package server
+
+import (
+	"blog-api/apperr"
+	"blog-api/config"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestBodyLimit(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: apperr.Handler})
+	app.Use(BodyLimit(10, "POST /upload/"))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(http.StatusNoContent) }
+	app.Post("/", ok)
+	app.Post("/upload", ok)
+	app.Put("/upload", ok)
+
+	multipart := "--x\r\nContent-Disposition: form-data; name=\"file\"\r\n\r\n" + strings.Repeat("x", 100) + "\r\n--x--\r\n"
+	tests := []struct {
+		method, path      string
+		body, contentType string
+		status            int
+	}{
+		{http.MethodPost, "/", "short", fiber.MIMEApplicationJSON, http.StatusNoContent},
+		{http.MethodPost, "/", "0123456789", fiber.MIMEApplicationJSON, http.StatusNoContent},
+		{http.MethodPost, "/", "0123456789+", fiber.MIMEApplicationJSON, http.StatusRequestEntityTooLarge},
+		// Only the upload route is exempt, whatever the content type.
+		{http.MethodPost, "/", multipart, fiber.MIMEMultipartForm + "; boundary=x", http.StatusRequestEntityTooLarge},
+		{http.MethodPost, "/upload", multipart, fiber.MIMEMultipartForm + "; boundary=x", http.StatusNoContent},
+		{http.MethodPost, "/upload/", strings.Repeat("x", 100), fiber.MIMEApplicationJSON, http.StatusNoContent},
+		{http.MethodPut, "/upload", strings.Repeat("x", 100), fiber.MIMEApplicationJSON, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set(fiber.HeaderContentType, tt.contentType)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s %s with %d bytes of %s: status %d, want %d", tt.method, tt.path, len(tt.body), tt.contentType, resp.StatusCode, tt.status)
+		}
+	}
+}
+
+// client does not keep connections open, which would delay shutdowns.
+var client = &http.Client{Transport: &http.Transport{
+	DisableKeepAlives: true,
+	TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+}}
+
+// freeAddr returns a local address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// waitForServer polls url until the server answers.
+func waitForServer(t *testing.T, url string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, err := client.Get(url); err == nil {
+			resp.Body.Close()
+			return
+		}
+	}
+	t.Fatalf("%s never answered", url)
+}
+
+func TestRunWaitsForRequests(t *testing.T) {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	started, release := make(chan struct{}), make(chan struct{})
+	app.Get("/ping", func(c *fiber.Ctx) error { return c.SendString("pong") })
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		close(started)
+		<-release
+		return c.SendString("done")
+	})
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	shutdownHooks := 0
+	ran := make(chan error, 1)
+	go func() {
+		ran <- Run(ctx, app, addr, config.Server{ShutdownTimeout: 5 * time.Second}, func() { shutdownHooks++ })
+	}()
+	waitForServer(t, "http://"+addr+"/ping")
+
+	slow := make(chan string, 1)
+	go func() {
+		resp, err := client.Get("http://" + addr + "/slow")
+		if err != nil {
+			slow <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		slow <- string(body)
+	}()
+	<-started
+	cancel()
+	select {
+	case err := <-ran:
+		t.Fatalf("Run returned %v with a request in progress", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	if got := <-slow; got != "done" {
+		t.Fatalf("request in progress = %q, want it to finish", got)
+	}
+	if err := <-ran; err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+	if shutdownHooks != 1 {
+		t.Fatalf("shutdown hooks ran %d times, want 1", shutdownHooks)
+	}
+}
+
+func TestRunAbandonsRequestsAfterTimeout(t *testing.T) {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	app.Get("/ping", func(c *fiber.Ctx) error { return c.SendString("pong") })
+	app.Get("/stuck", func(c *fiber.Ctx) error {
+		close(started)
+		<-release
+		return nil
+	})
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan error, 1)
+	go func() { ran <- Run(ctx, app, addr, config.Server{ShutdownTimeout: 50 * time.Millisecond}) }()
+	waitForServer(t, "http://"+addr+"/ping")
+	go client.Get("http://" + addr + "/stuck")
+	<-started
+
+	cancel()
+	select {
+	case err := <-ran:
+		if err != nil {
+			t.Fatalf("Run = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run waited past the shutdown timeout")
+	}
+}
+
+func TestRunTLS(t *testing.T) {
+	certFile, keyFile := writeCertificate(t, t.TempDir(), "localhost")
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("secure") })
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg := config.Server{ShutdownTimeout: time.Second, TLSCertFile: certFile, TLSKeyFile: keyFile, TLSReloadInterval: time.Minute}
+	go Run(ctx, app, addr, cfg)
+
+	waitForServer(t, "https://"+addr+"/")
+	resp, err := client.Get("https://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.TLS == nil || resp.TLS.PeerCertificates[0].Subject.CommonName != "localhost" {
+		t.Fatalf("TLS state = %+v, want the certificate of localhost", resp.TLS)
+	}
+}
+
+func TestRunListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	if err := Run(context.Background(), fiber.New(), ln.Addr().String(), config.Server{}); err == nil {
+		t.Fatal("no error for an address in use")
+	}
+	if err := Run(context.Background(), fiber.New(), freeAddr(t), config.Server{TLSCertFile: "nope.pem", TLSKeyFile: "nope.pem"}); err == nil {
+		t.Fatal("no error for missing certificates")
+	}
+}
+
+func TestRunShutdownDelay(t *testing.T) {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/ping", func(c *fiber.Ctx) error { return c.SendString("pong") })
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan error, 1)
+	go func() {
+		ran <- Run(ctx, app, addr, config.Server{ShutdownDelay: 200 * time.Millisecond, ShutdownTimeout: time.Second})
+	}()
+	waitForServer(t, "http://"+addr+"/ping")
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	resp, err := client.Get("http://" + addr + "/ping")
+	if err != nil {
+		t.Fatalf("request during the shutdown delay: %v", err)
+	}
+	resp.Body.Close()
+	if err := <-ran; err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+	if _, err := client.Get("http://" + addr + "/ping"); err == nil {
+		t.Fatal("server still serving after Run returned")
+	}
+}
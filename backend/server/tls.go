@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Certificates serves a certificate read from disk and reads it again when
+// its files change, so that a renewed certificate applies to new
+// connections without a restart.
+type Certificates struct {
+	certFile, keyFile string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modified time.Time
+}
+
+// LoadCertificates reads the PEM certificate chain and private key.
+func LoadCertificates(certFile, keyFile string) (*Certificates, error) {
+	c := &Certificates{certFile: certFile, keyFile: keyFile}
+	if _, err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCertificate is meant for tls.Config.GetCertificate.
+func (c *Certificates) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// Watch checks the files every interval until ctx is done. A certificate
+// that fails to load, say because only one of the files was replaced yet,
+// is logged and tried again on the next check; the previous one is kept
+// meanwhile.
+func (c *Certificates) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		reloaded, err := c.reload()
+		if err != nil {
+			log.Printf("TLS: %v", err)
+		} else if reloaded {
+			log.Printf("TLS: certificate %s reloaded", c.certFile)
+		}
+	}
+}
+
+// reload reads the files when they were modified since the last load.
+func (c *Certificates) reload() (bool, error) {
+	modified, err := c.lastModified()
+	if err != nil {
+		return false, err
+	}
+	c.mu.RLock()
+	unchanged := c.cert != nil && modified.Equal(c.modified)
+	c.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("loading certificate %s: %w", c.certFile, err)
+	}
+	c.mu.Lock()
+	c.cert, c.modified = &cert, modified
+	c.mu.Unlock()
+	return true, nil
+}
+
+// lastModified is the latest modification time of the two files.
+func (c *Certificates) lastModified() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
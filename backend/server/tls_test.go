@@ -0,0 +1,109 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate for name and its key
+// to dir, and returns their paths.
+func writeCertificate(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	write := func(path, blockType string, der []byte) {
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(certFile, "CERTIFICATE", der)
+	write(keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+// commonName returns the name of the certificate currently served.
+func commonName(t *testing.T, c *Certificates) string {
+	t.Helper()
+	cert, err := c.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertificatesReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, "old.example.com")
+	certs, err := LoadCertificates(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := certs.reload(); reloaded || err != nil {
+		t.Fatalf("reload of unchanged files = %v, %v", reloaded, err)
+	}
+
+	// A half-replaced pair keeps the previous certificate.
+	later := time.Now().Add(time.Minute)
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(keyFile, later, later)
+	if _, err := certs.reload(); err == nil {
+		t.Fatal("no error for an invalid key")
+	}
+	if got := commonName(t, certs); got != "old.example.com" {
+		t.Fatalf("certificate = %s, want the previous one", got)
+	}
+
+	writeCertificate(t, dir, "new.example.com")
+	later = later.Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	if reloaded, err := certs.reload(); !reloaded || err != nil {
+		t.Fatalf("reload = %v, %v", reloaded, err)
+	}
+	if got := commonName(t, certs); got != "new.example.com" {
+		t.Fatalf("certificate = %s, want the renewed one", got)
+	}
+}
+
+func TestLoadCertificatesErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadCertificates(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")); err == nil {
+		t.Fatal("no error for missing files")
+	}
+	certFile, _ := writeCertificate(t, dir, "example.com")
+	if _, err := LoadCertificates(certFile, certFile); err == nil {
+		t.Fatal("no error for a certificate without its key")
+	}
+}
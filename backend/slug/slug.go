@@ -0,0 +1,67 @@
+// Package slug builds URL-friendly identifiers from article titles.
+package slug
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength caps generated slugs, cutting at a word boundary.
+const MaxLength = 80
+
+// ligatures are letters that don't decompose into a base letter plus accents.
+var ligatures = strings.NewReplacer(
+	"œ", "oe", "Œ", "oe",
+	"æ", "ae", "Æ", "ae",
+	"ß", "ss",
+	"ø", "o", "Ø", "o",
+	"ł", "l", "Ł", "l",
+	"đ", "d", "Đ", "d",
+)
+
+// Make transliterates s to ASCII ("Élève à l'œuvre" becomes
+// "eleve-a-l-oeuvre"), lowercases it and joins words with dashes. It returns
+// "" when s contains no letters or digits.
+func Make(s string) string {
+	s = ligatures.Replace(s)
+	stripAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if t, _, err := transform.String(stripAccents, s); err == nil {
+		s = t
+	}
+
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > MaxLength {
+		slug = slug[:MaxLength]
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	return slug
+}
+
+// WithSuffix returns the n-th candidate for a taken slug: base, base-2, base-3...
+func WithSuffix(base string, n int) string {
+	if n <= 1 {
+		return base
+	}
+	return base + "-" + strconv.Itoa(n)
+}
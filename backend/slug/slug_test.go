@@ -0,0 +1,42 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"Élève à l'œuvre", "eleve-a-l-oeuvre"},
+		{"Straße Łódź Æsir", "strasse-lodz-aesir"},
+		{"  --Go 1.22 -- released--  ", "go-1-22-released"},
+		{"日本語", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Make(tt.in); got != tt.want {
+			t.Errorf("Make(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMakeMaxLength(t *testing.T) {
+	got := Make(strings.Repeat("abcdefghi ", 20))
+	if len(got) > MaxLength || strings.HasSuffix(got, "-") || !strings.HasPrefix(got, "abcdefghi-abcdefghi") {
+		t.Errorf("Make of a long title = %q, want at most %d chars cut between words", got, MaxLength)
+	}
+	if got := Make(strings.Repeat("x", 100)); got != strings.Repeat("x", MaxLength) {
+		t.Errorf("Make of a long word = %q, want it cut at %d chars", got, MaxLength)
+	}
+}
+
+func TestWithSuffix(t *testing.T) {
+	for n, want := range map[int]string{0: "post", 1: "post", 2: "post-2", 10: "post-10"} {
+		if got := WithSuffix("post", n); got != want {
+			t.Errorf("WithSuffix(post, %d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package memory
+
+import (
+	"blog-api/markdown"
+	"blog-api/models"
+	"blog-api/slug"
+	"blog-api/store"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ArticleStore struct {
+	d *data
+}
+
+func (s *ArticleStore) List(ctx context.Context, filter store.ArticleFilter, page store.PageRequest) (store.Page[models.Article], error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	var articles []models.Article
+	for id, a := range s.d.articles {
+		if filter.AuthorID != "" && a.UserID != filter.AuthorID {
+			continue
+		}
+		if filter.FeedOf != "" && a.UserID != filter.FeedOf && !s.d.follows(filter.FeedOf, a.UserID) {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, a.Status) {
+			continue
+		}
+		if len(filter.Tags) > 0 && !hasTags(a.Tags, filter.Tags, filter.AllTags) {
+			continue
+		}
+		if filter.PublishedSince != nil && (a.PublishAt == nil || a.PublishAt.Before(*filter.PublishedSince)) {
+			continue
+		}
+		if a.Hidden && !filter.IncludeHidden {
+			continue
+		}
+		a, _ = s.d.article(id)
+		articles = append(articles, a)
+	}
+
+	switch filter.Sort {
+	case store.SortHot:
+		return paginate(articles, page, true, hotCursor), nil
+	case store.SortTop:
+		return paginate(articles, page, true, topCursor), nil
+	}
+	return paginate(articles, page, true, articleCursor), nil
+}
+
+func hotCursor(a models.Article) store.Cursor {
+	return store.Cursor{Score: a.HotScore, CreatedAt: a.CreatedAt, ID: a.ID}
+}
+
+func topCursor(a models.Article) store.Cursor {
+	return store.Cursor{Score: float64(a.Engagement), CreatedAt: a.CreatedAt, ID: a.ID}
+}
+
+// hasTags reports whether tags contains any of want, or all of them when all
+// is set.
+func hasTags(tags, want []string, all bool) bool {
+	for _, t := range want {
+		if slices.Contains(tags, t) != all {
+			return !all
+		}
+	}
+	return all
+}
+
+func articleCursor(a models.Article) store.Cursor {
+	return store.Cursor{CreatedAt: store.PublishedAt(a), ID: a.ID}
+}
+
+func (s *ArticleStore) Get(ctx context.Context, id string) (*models.Article, error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	a, ok := s.d.article(id)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &a, nil
+}
+
+func (s *ArticleStore) GetBySlug(ctx context.Context, slug string) (*models.Article, error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	for id, a := range s.d.articles {
+		if a.Slug == slug {
+			a, _ := s.d.article(id)
+			return &a, nil
+		}
+	}
+	if id, ok := s.d.oldSlugs[slug]; ok {
+		a, _ := s.d.article(id)
+		return &a, nil
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *ArticleStore) Create(ctx context.Context, article *models.Article) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	article.Slug = s.d.uniqueSlug(article.Slug, article.ID)
+	article.Likes = 0
+	article.ContentHTML = markdown.Render(article.Content)
+	if article.CoverMediaID != nil {
+		article.CoverMediaID = optionalID(*article.CoverMediaID)
+	}
+	article.CreatedAt = time.Now()
+	stored := *article
+	stored.Author = nil
+	stored.Tags = sortedTags(article.Tags)
+	s.d.articles[article.ID] = stored
+	s.d.addRevision(stored, nil)
+	return nil
+}
+
+func (s *ArticleStore) Update(ctx context.Context, article *models.Article) error {
+	return s.update(article, nil)
+}
+
+func (s *ArticleStore) Restore(ctx context.Context, article *models.Article, number int) error {
+	return s.update(article, &number)
+}
+
+func (s *ArticleStore) update(article *models.Article, restoredFrom *int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	a, ok := s.d.articles[article.ID]
+	if !ok || a.UserID != article.UserID {
+		return store.ErrNotFound
+	}
+
+	if article.Title != a.Title {
+		newSlug := s.d.uniqueSlug(article.Slug, a.ID)
+		if newSlug != a.Slug {
+			delete(s.d.oldSlugs, newSlug)
+			s.d.oldSlugs[a.Slug] = a.ID
+			a.Slug = newSlug
+		}
+	}
+	changed := restoredFrom != nil || a.Title != article.Title || a.Content != article.Content
+	a.Title = article.Title
+	a.Excerpt = article.Excerpt
+	a.Content = article.Content
+	a.ContentHTML = markdown.Render(article.Content)
+	a.Status = article.Status
+	a.PublishAt = article.PublishAt
+	if article.Tags != nil {
+		a.Tags = sortedTags(article.Tags)
+	}
+	if article.CoverMediaID != nil {
+		a.CoverMediaID = optionalID(*article.CoverMediaID)
+	}
+	s.d.articles[a.ID] = a
+	if changed {
+		s.d.addRevision(a, restoredFrom)
+	}
+
+	*article, _ = s.d.article(a.ID)
+	return nil
+}
+
+// RenderStale finds nothing to do: memory stores only hold content rendered
+// by the running markdown.Version.
+func (s *ArticleStore) RenderStale(ctx context.Context, limit int) (int, error) {
+	return 0, nil
+}
+
+func (s *ArticleStore) Delete(ctx context.Context, id, userID string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	a, ok := s.d.articles[id]
+	if !ok || a.UserID != userID {
+		return store.ErrNotFound
+	}
+	s.d.deleteArticle(id)
+	return nil
+}
+
+func (s *ArticleStore) SetHidden(ctx context.Context, id string, hidden bool) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	a, ok := s.d.articles[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	a.Hidden = hidden
+	s.d.articles[a.ID] = a
+	return nil
+}
+
+func (s *ArticleStore) Revisions(ctx context.Context, articleID string) ([]models.Revision, error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	history := s.d.revisions[articleID]
+	revisions := make([]models.Revision, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		revisions = append(revisions, history[i])
+	}
+	return revisions, nil
+}
+
+func (s *ArticleStore) Revision(ctx context.Context, articleID string, number int) (*models.Revision, error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	history := s.d.revisions[articleID]
+	if number < 1 || number > len(history) {
+		return nil, store.ErrNotFound
+	}
+	rev := history[number-1]
+	return &rev, nil
+}
+
+// addRevision appends the article's current title and content to its
+// history. Callers must hold d.mu for writing.
+func (d *data) addRevision(a models.Article, restoredFrom *int) {
+	d.revisions[a.ID] = append(d.revisions[a.ID], models.Revision{
+		ID:           uuid.New().String(),
+		ArticleID:    a.ID,
+		Number:       len(d.revisions[a.ID]) + 1,
+		UserID:       a.UserID,
+		Title:        a.Title,
+		Content:      a.Content,
+		RestoredFrom: restoredFrom,
+		CreatedAt:    time.Now(),
+	})
+}
+
+func (s *ArticleStore) PublishDue(ctx context.Context, now time.Time) ([]models.Article, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	var published []models.Article
+	for id, a := range s.d.articles {
+		if a.Status == models.ArticleScheduled && a.PublishAt != nil && !a.PublishAt.After(now) {
+			a.Status = models.ArticlePublished
+			s.d.articles[a.ID] = a
+			a, _ = s.d.article(id)
+			published = append(published, a)
+		}
+	}
+	return published, nil
+}
+
+// sortedTags copies tags in the order the PostgreSQL store returns them.
+func sortedTags(tags []string) []string {
+	sorted := append([]string{}, tags...)
+	slices.Sort(sorted)
+	return sorted
+}
+
+func (s *ArticleStore) RefreshScores(ctx context.Context, now time.Time) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	changed := 0
+	for id, a := range s.d.articles {
+		if a.Status != models.ArticlePublished || a.PublishAt == nil {
+			continue
+		}
+		engagement := a.Likes * store.LikeWeight
+		for _, c := range s.d.comments {
+			if c.ArticleID == id && !c.Deleted {
+				engagement += store.CommentWeight
+			}
+		}
+		for _, f := range s.d.favorites {
+			if f.ArticleID == id {
+				engagement += store.FavoriteWeight
+			}
+		}
+		hot := store.HotScore(engagement, now.Sub(*a.PublishAt))
+		if engagement != a.Engagement || hot != a.HotScore {
+			a.Engagement, a.HotScore = engagement, hot
+			s.d.articles[a.ID] = a
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// uniqueSlug returns the first of base, base-2, base-3... that no other
+// article uses now or used before. Callers must hold d.mu.
+func (d *data) uniqueSlug(base, articleID string) string {
+	for n := 1; ; n++ {
+		candidate := slug.WithSuffix(base, n)
+		if owner, ok := d.oldSlugs[candidate]; ok && owner != articleID {
+			continue
+		}
+		taken := false
+		for id, a := range d.articles {
+			if a.Slug == candidate && id != articleID {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return candidate
+		}
+	}
+}
+
+// deleteArticle removes an article and cascades to the rows referencing it,
+// like the ON DELETE CASCADE foreign keys. Callers must hold d.mu.
+func (d *data) deleteArticle(id string) {
+	delete(d.articles, id)
+	for cid, c := range d.comments {
+		if c.ArticleID == id {
+			delete(d.comments, cid)
+		}
+	}
+	for fid, f := range d.favorites {
+		if f.ArticleID == id {
+			delete(d.favorites, fid)
+		}
+	}
+	for k := range d.likes {
+		if k.articleID == id {
+			delete(d.likes, k)
+		}
+	}
+	for s, articleID := range d.oldSlugs {
+		if articleID == id {
+			delete(d.oldSlugs, s)
+		}
+	}
+	delete(d.revisions, id)
+	d.deleteNotifications(func(n *notification) bool {
+		return n.ArticleID != nil && *n.ArticleID == id
+	})
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPublishDue(t *testing.T) {
+	ctx := context.Background()
+	articles := New().Articles
+	at := day.Add(time.Hour)
+	for _, a := range []models.Article{
+		{ID: "due", UserID: "u", Slug: "due", Status: models.ArticleScheduled, PublishAt: &at},
+		{ID: "later", UserID: "u", Slug: "later", Status: models.ArticleScheduled, PublishAt: ptr(at.Add(time.Hour))},
+		{ID: "draft", UserID: "u", Slug: "draft", Status: models.ArticleDraft, PublishAt: &at},
+	} {
+		if err := articles.Create(ctx, &a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if due, err := articles.PublishDue(ctx, day); err != nil || len(due) != 0 {
+		t.Fatalf("PublishDue before the date = %v, %v; want none", due, err)
+	}
+	due, err := articles.PublishDue(ctx, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].Slug != "due" || due[0].Status != models.ArticlePublished {
+		t.Fatalf("PublishDue = %+v, want the due article published", due)
+	}
+	got, err := articles.Get(ctx, due[0].ID)
+	if err != nil || got.Status != models.ArticlePublished {
+		t.Fatalf("stored article = %+v, %v; want published", got, err)
+	}
+
+	// Already published articles are not returned again.
+	if due, _ := articles.PublishDue(ctx, at); len(due) != 0 {
+		t.Fatalf("second PublishDue = %v, want none", due)
+	}
+}
+
+func TestListOrdersByPublication(t *testing.T) {
+	ctx := context.Background()
+	articles := New().Articles
+	// Created in this order, published in another.
+	for _, a := range []models.Article{
+		{ID: "scheduled", UserID: "u", Slug: "scheduled", Status: models.ArticlePublished, PublishAt: ptr(day.Add(2 * time.Hour))},
+		{ID: "early", UserID: "u", Slug: "early", Status: models.ArticlePublished, PublishAt: ptr(day)},
+		{ID: "backdated", UserID: "u", Slug: "backdated", Status: models.ArticlePublished, PublishAt: ptr(day.Add(-time.Hour))},
+		{ID: "draft", UserID: "u", Slug: "draft", Status: models.ArticleDraft},
+	} {
+		if err := articles.Create(ctx, &a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, err := articles.List(ctx, store.ArticleFilter{}, store.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, a := range page.Items {
+		ids = append(ids, a.ID)
+	}
+	// The draft has no publication time and falls back to its creation,
+	// the latest.
+	if want := []string{"draft", "scheduled", "early", "backdated"}; !slices.Equal(ids, want) {
+		t.Fatalf("articles = %v, want %v", ids, want)
+	}
+}
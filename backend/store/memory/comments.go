@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"blog-api/markdown"
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"sort"
+	"time"
+)
+
+type CommentStore struct {
+	d *data
+}
+
+func (s *CommentStore) ListByArticle(ctx context.Context, articleID string, page store.PageRequest) (store.Page[models.Comment], error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	var comments []models.Comment
+	for id, c := range s.d.comments {
+		if c.ArticleID == articleID {
+			comments = append(comments, s.d.comment(id))
+		}
+	}
+	return paginate(comments, page, false, commentCursor), nil
+}
+
+func (s *CommentStore) Thread(ctx context.Context, articleID string, page store.PageRequest) (store.Page[models.Comment], error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	var roots, replies []models.Comment
+	for id, c := range s.d.comments {
+		if c.ArticleID != articleID {
+			continue
+		}
+		if c.ParentID == nil {
+			roots = append(roots, s.d.comment(id))
+		} else {
+			replies = append(replies, s.d.comment(id))
+		}
+	}
+	sort.Slice(replies, func(i, j int) bool {
+		return newer(commentCursor(replies[j]), commentCursor(replies[i]))
+	})
+
+	result := paginate(roots, page, false, commentCursor)
+	store.NestReplies(result.Items, replies)
+	return result, nil
+}
+
+func commentCursor(c models.Comment) store.Cursor {
+	return store.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+}
+
+func (s *CommentStore) Get(ctx context.Context, id string) (*models.Comment, error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	if _, ok := s.d.comments[id]; !ok {
+		return nil, store.ErrNotFound
+	}
+	c := s.d.comment(id)
+	return &c, nil
+}
+
+func (s *CommentStore) Create(ctx context.Context, comment *models.Comment) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if _, ok := s.d.articles[comment.ArticleID]; !ok {
+		return store.ErrNotFound
+	}
+	if comment.ParentID != nil {
+		if _, ok := s.d.comments[*comment.ParentID]; !ok {
+			return store.ErrNotFound
+		}
+	}
+	comment.ContentHTML = markdown.Render(comment.Content)
+	comment.CreatedAt = time.Now()
+	stored := *comment
+	stored.Author = nil
+	s.d.comments[comment.ID] = stored
+	return nil
+}
+
+func (s *CommentStore) Update(ctx context.Context, id, userID, content string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	c, ok := s.d.comments[id]
+	if !ok || c.UserID != userID || c.Deleted {
+		return store.ErrNotFound
+	}
+	c.Content = content
+	c.ContentHTML = markdown.Render(content)
+	s.d.comments[c.ID] = c
+	return nil
+}
+
+// RenderStale is ArticleStore.RenderStale for comments.
+func (s *CommentStore) RenderStale(ctx context.Context, limit int) (int, error) {
+	return 0, nil
+}
+
+func (s *CommentStore) Delete(ctx context.Context, id, userID string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	c, ok := s.d.comments[id]
+	if !ok || c.UserID != userID || c.Deleted {
+		return store.ErrNotFound
+	}
+	if s.d.replyCount(c.ID) > 0 {
+		c.Content = ""
+		c.ContentHTML = ""
+		c.Deleted = true
+		s.d.comments[c.ID] = c
+		return nil
+	}
+
+	s.d.deleteComment(c.ID)
+	// Walk up the thread removing deleted placeholders that lost their last reply.
+	for parentID := c.ParentID; parentID != nil; {
+		p, ok := s.d.comments[*parentID]
+		if !ok || !p.Deleted || s.d.replyCount(p.ID) > 0 {
+			break
+		}
+		s.d.deleteComment(p.ID)
+		parentID = p.ParentID
+	}
+	return nil
+}
+
+// deleteComment removes a comment and the notifications about it, like the
+// ON DELETE CASCADE foreign key. Callers must hold d.mu for writing.
+func (d *data) deleteComment(id string) {
+	delete(d.comments, id)
+	d.deleteNotifications(func(n *notification) bool {
+		return n.CommentID != nil && *n.CommentID == id
+	})
+}
+
+// comment returns a copy of the comment as the API shows it: with its
+// author and reply count, or as a placeholder once deleted. Callers must
+// hold d.mu.
+func (d *data) comment(id string) models.Comment {
+	c := d.comments[id]
+	c.ReplyCount = d.replyCount(id)
+	if c.Deleted {
+		c.Content = models.DeletedCommentContent
+		c.ContentHTML = markdown.Render(c.Content)
+		c.UserID = ""
+		return c
+	}
+	c.Author = d.profile(c.UserID)
+	return c
+}
+
+// replyCount counts the direct replies of a comment. Callers must hold d.mu.
+func (d *data) replyCount(id string) int {
+	n := 0
+	for _, c := range d.comments {
+		if c.ParentID != nil && *c.ParentID == id {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"time"
+)
+
+type FavoriteStore struct {
+	d *data
+}
+
+func (s *FavoriteStore) ListByUser(ctx context.Context, userID string, page store.PageRequest) (store.Page[models.Favorite], error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	var favorites []models.Favorite
+	for _, f := range s.d.favorites {
+		if f.ProfileID != userID {
+			continue
+		}
+		a, ok := s.d.article(f.ArticleID)
+		if !ok || a.Status != models.ArticlePublished || a.Hidden {
+			continue
+		}
+		a.ProfileID = a.UserID
+		a.UserID = ""
+		f.Article = &a
+		favorites = append(favorites, f)
+	}
+	return paginate(favorites, page, true, favoriteCursor), nil
+}
+
+func favoriteCursor(f models.Favorite) store.Cursor {
+	return store.Cursor{CreatedAt: f.CreatedAt, ID: f.ID}
+}
+
+func (s *FavoriteStore) Add(ctx context.Context, fav *models.Favorite) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if _, ok := s.d.articles[fav.ArticleID]; !ok {
+		return store.ErrNotFound
+	}
+	for _, f := range s.d.favorites {
+		if f.ProfileID == fav.ProfileID && f.ArticleID == fav.ArticleID {
+			return store.ErrConflict
+		}
+	}
+
+	fav.CreatedAt = time.Now()
+	stored := *fav
+	stored.Article = nil
+	s.d.favorites[fav.ID] = stored
+	return nil
+}
+
+func (s *FavoriteStore) Remove(ctx context.Context, id, userID string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	f, ok := s.d.favorites[id]
+	if !ok || f.ProfileID != userID {
+		return store.ErrNotFound
+	}
+	delete(s.d.favorites, id)
+	return nil
+}
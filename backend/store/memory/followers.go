@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"time"
+)
+
+type FollowerStore struct {
+	d *data
+}
+
+func (s *FollowerStore) Followers(ctx context.Context, userID string, page store.PageRequest) (store.Page[models.Follower], error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	var followers []models.Follower
+	for _, f := range s.d.followers {
+		if f.FollowingID == userID {
+			f.Follower = s.d.profile(f.FollowerID)
+			followers = append(followers, f)
+		}
+	}
+	return paginate(followers, page, true, followerCursor), nil
+}
+
+func (s *FollowerStore) Following(ctx context.Context, userID string, page store.PageRequest) (store.Page[models.Follower], error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	var following []models.Follower
+	for _, f := range s.d.followers {
+		if f.FollowerID == userID {
+			f.Following = s.d.profile(f.FollowingID)
+			following = append(following, f)
+		}
+	}
+	return paginate(following, page, true, followerCursor), nil
+}
+
+func (s *FollowerStore) Follow(ctx context.Context, follower *models.Follower) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if _, ok := s.d.users[follower.FollowerID]; !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.d.users[follower.FollowingID]; !ok {
+		return store.ErrNotFound
+	}
+	if s.d.follows(follower.FollowerID, follower.FollowingID) {
+		return store.ErrConflict
+	}
+
+	follower.CreatedAt = time.Now()
+	stored := *follower
+	stored.Follower, stored.Following = nil, nil
+	s.d.followers[follower.ID] = stored
+	return nil
+}
+
+func (s *FollowerStore) Unfollow(ctx context.Context, followerID, followingID string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	for id, f := range s.d.followers {
+		if f.FollowerID == followerID && f.FollowingID == followingID {
+			delete(s.d.followers, id)
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func followerCursor(f models.Follower) store.Cursor {
+	return store.Cursor{CreatedAt: f.CreatedAt, ID: f.ID}
+}
+
+// follows reports whether followerID follows followingID. Callers must hold
+// d.mu.
+func (d *data) follows(followerID, followingID string) bool {
+	for _, f := range d.followers {
+		if f.FollowerID == followerID && f.FollowingID == followingID {
+			return true
+		}
+	}
+	return false
+}
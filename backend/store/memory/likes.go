@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"blog-api/store"
+	"context"
+	"time"
+)
+
+type LikeStore struct {
+	d *data
+}
+
+func (s *LikeStore) Status(ctx context.Context, articleID, userID string) (bool, error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	_, ok := s.d.likes[likeKey{articleID, userID}]
+	return ok, nil
+}
+
+func (s *LikeStore) Count(ctx context.Context, articleID string) (int, error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	return s.d.likeCount(articleID), nil
+}
+
+func (s *LikeStore) Add(ctx context.Context, articleID, userID string) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if _, ok := s.d.articles[articleID]; !ok {
+		return 0, store.ErrNotFound
+	}
+	key := likeKey{articleID, userID}
+	if _, ok := s.d.likes[key]; !ok {
+		s.d.likes[key] = time.Now()
+	}
+	return s.d.syncLikes(articleID), nil
+}
+
+func (s *LikeStore) Remove(ctx context.Context, articleID, userID string) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	key := likeKey{articleID, userID}
+	if _, ok := s.d.likes[key]; !ok {
+		return 0, store.ErrNotFound
+	}
+	delete(s.d.likes, key)
+	return s.d.syncLikes(articleID), nil
+}
+
+// likeCount counts the likes of an article. Callers must hold d.mu.
+func (d *data) likeCount(articleID string) int {
+	count := 0
+	for k := range d.likes {
+		if k.articleID == articleID {
+			count++
+		}
+	}
+	return count
+}
+
+// syncLikes stores the like count on the article, mirroring articles.likes.
+// Callers must hold d.mu for writing.
+func (d *data) syncLikes(articleID string) int {
+	count := d.likeCount(articleID)
+	if a, ok := d.articles[articleID]; ok {
+		a.Likes = count
+		d.articles[a.ID] = a
+	}
+	return count
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"time"
+)
+
+type MediaStore struct {
+	d *data
+}
+
+func (s *MediaStore) Create(ctx context.Context, media *models.Media) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	media.CreatedAt = time.Now()
+	stored := *media
+	stored.Variants = append([]models.MediaVariant{}, media.Variants...)
+	s.d.media[media.ID] = stored
+	return nil
+}
+
+func (s *MediaStore) Get(ctx context.Context, id string) (*models.Media, error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	m, ok := s.d.media[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	m.Variants = append([]models.MediaVariant{}, m.Variants...)
+	return &m, nil
+}
+
+// Delete also clears the covers and avatars using the image, like the
+// ON DELETE SET NULL foreign keys.
+func (s *MediaStore) Delete(ctx context.Context, id, userID string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	m, ok := s.d.media[id]
+	if !ok || m.UserID != userID {
+		return store.ErrNotFound
+	}
+	delete(s.d.media, m.ID)
+	for _, a := range s.d.articles {
+		if a.CoverMediaID != nil && *a.CoverMediaID == m.ID {
+			a.CoverMediaID = nil
+			s.d.articles[a.ID] = a
+		}
+	}
+	for _, u := range s.d.users {
+		if u.AvatarMediaID != nil && *u.AvatarMediaID == m.ID {
+			u.AvatarMediaID = nil
+			s.d.users[u.ID] = u
+		}
+	}
+	return nil
+}
+
+// optionalID turns the empty string, which clears a reference, into nil.
+func optionalID(id string) *string {
+	if id == "" {
+		return nil
+	}
+	return &id
+}
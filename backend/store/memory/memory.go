@@ -0,0 +1,132 @@
+// Package memory is an in-memory implementation of the store interfaces,
+// used to run the API without a database.
+package memory
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"sort"
+	"sync"
+	"time"
+)
+
+type likeKey struct {
+	articleID string
+	userID    string
+}
+
+// data holds every table; the per-resource stores share it so joins such as
+// article authors behave like the PostgreSQL queries.
+type data struct {
+	mu        sync.RWMutex
+	users     map[string]models.User
+	articles  map[string]models.Article
+	comments  map[string]models.Comment
+	favorites map[string]models.Favorite
+	followers map[string]models.Follower
+	likes     map[likeKey]time.Time
+	// oldSlugs maps previous slugs to their article ID.
+	oldSlugs map[string]string
+	// revisions holds each article's history, oldest first.
+	revisions     map[string][]models.Revision
+	notifications map[string]*notification
+	// mutes holds the muted notification kinds of each user.
+	mutes map[string][]string
+	media map[string]models.Media
+}
+
+// New returns empty in-memory stores.
+func New() store.Stores {
+	d := &data{
+		users:     make(map[string]models.User),
+		articles:  make(map[string]models.Article),
+		comments:  make(map[string]models.Comment),
+		favorites: make(map[string]models.Favorite),
+		followers: make(map[string]models.Follower),
+		likes:     make(map[likeKey]time.Time),
+		oldSlugs:  make(map[string]string),
+		revisions: make(map[string][]models.Revision),
+
+		notifications: make(map[string]*notification),
+		mutes:         make(map[string][]string),
+		media:         make(map[string]models.Media),
+	}
+	return store.Stores{
+		Articles:      &ArticleStore{d},
+		Comments:      &CommentStore{d},
+		Users:         &UserStore{d},
+		Favorites:     &FavoriteStore{d},
+		Followers:     &FollowerStore{d},
+		Likes:         &LikeStore{d},
+		Search:        &SearchStore{d},
+		Tags:          &TagStore{d},
+		Notifications: &NotificationStore{d},
+		Media:         &MediaStore{d},
+	}
+}
+
+// profile mirrors the LEFT JOIN on users. Callers must hold d.mu.
+func (d *data) profile(userID string) *models.Profile {
+	u := d.users[userID]
+	return &models.Profile{
+		Email:     u.Email,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		CreatedAt: u.CreatedAt,
+
+		AvatarMediaID: u.AvatarMediaID,
+	}
+}
+
+// article returns a copy of the article with its author. Callers must hold d.mu.
+func (d *data) article(id string) (models.Article, bool) {
+	a, ok := d.articles[id]
+	if !ok {
+		return a, false
+	}
+	a.Tags = append([]string{}, a.Tags...)
+	a.Author = d.profile(a.UserID)
+	return a, true
+}
+
+// newer reports whether a sorts before b in newest-first order. Ranked lists
+// put the highest score first.
+func newer(a, b store.Cursor) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+// paginate mirrors the keyset queries of the PostgreSQL store: it orders
+// items (newest first when desc), skips up to the cursor and hands at most
+// Limit+1 rows in scan order to store.NewPage.
+func paginate[T any](items []T, page store.PageRequest, desc bool, cursorOf func(T) store.Cursor) store.Page[T] {
+	descending := desc != page.Backward()
+	sort.Slice(items, func(i, j int) bool {
+		if descending {
+			return newer(cursorOf(items[i]), cursorOf(items[j]))
+		}
+		return newer(cursorOf(items[j]), cursorOf(items[i]))
+	})
+
+	var rows []T
+	cursor := page.Cursor()
+	for _, item := range items {
+		if cursor != nil {
+			c := cursorOf(item)
+			same := c.ID == cursor.ID && c.CreatedAt.Equal(cursor.CreatedAt) && c.Score == cursor.Score
+			if same || newer(c, *cursor) == descending {
+				continue
+			}
+		}
+		rows = append(rows, item)
+		if len(rows) > page.Limit {
+			break
+		}
+	}
+	return store.NewPage(rows, page, cursorOf)
+}
@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// notification is a stored notification with its grouping key and actors,
+// most recent last.
+type notification struct {
+	models.Notification
+	group  string
+	actors []string
+}
+
+type NotificationStore struct {
+	d *data
+}
+
+func (s *NotificationStore) Add(ctx context.Context, n *models.Notification, actorID string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if _, ok := s.d.users[n.UserID]; !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.d.users[actorID]; !ok {
+		return store.ErrNotFound
+	}
+
+	group := store.NotificationGroup(n)
+	now := time.Now()
+	var stored *notification
+	for _, existing := range s.d.notifications {
+		if existing.UserID == n.UserID && existing.group == group && !existing.Read {
+			stored = existing
+			break
+		}
+	}
+	if stored == nil {
+		stored = &notification{Notification: *n, group: group}
+		stored.ID = uuid.New().String()
+		stored.Read = false
+		stored.CreatedAt = now
+		s.d.notifications[stored.ID] = stored
+	}
+	stored.UpdatedAt = now
+	stored.actors = append(slices.DeleteFunc(stored.actors, func(id string) bool { return id == actorID }), actorID)
+
+	*n = stored.Notification
+	n.ActorCount = len(stored.actors)
+	return nil
+}
+
+func (s *NotificationStore) List(ctx context.Context, userID string, unreadOnly bool, page store.PageRequest) (store.Page[models.Notification], error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	var notifications []models.Notification
+	for _, stored := range s.d.notifications {
+		if stored.UserID != userID || (unreadOnly && stored.Read) {
+			continue
+		}
+		n := stored.Notification
+		if n.ArticleID != nil {
+			n.ArticleTitle = s.d.articles[*n.ArticleID].Title
+		}
+		n.ActorCount = len(stored.actors)
+		n.Actors = []models.Profile{}
+		for i := len(stored.actors) - 1; i >= 0 && len(n.Actors) < store.MaxNotificationActors; i-- {
+			actor := s.d.profile(stored.actors[i])
+			actor.ID = stored.actors[i]
+			n.Actors = append(n.Actors, *actor)
+		}
+		notifications = append(notifications, n)
+	}
+	return paginate(notifications, page, true, notificationCursor), nil
+}
+
+func notificationCursor(n models.Notification) store.Cursor {
+	return store.Cursor{CreatedAt: n.UpdatedAt, ID: n.ID}
+}
+
+func (s *NotificationStore) UnreadCount(ctx context.Context, userID string) (int, error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	count := 0
+	for _, n := range s.d.notifications {
+		if n.UserID == userID && !n.Read {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *NotificationStore) MarkRead(ctx context.Context, id, userID string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	n, ok := s.d.notifications[id]
+	if !ok || n.UserID != userID {
+		return store.ErrNotFound
+	}
+	n.Read = true
+	return nil
+}
+
+func (s *NotificationStore) MarkAllRead(ctx context.Context, userID string) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	count := 0
+	for _, n := range s.d.notifications {
+		if n.UserID == userID && !n.Read {
+			n.Read = true
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *NotificationStore) Muted(ctx context.Context, userID string) ([]string, error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	kinds := append([]string{}, s.d.mutes[userID]...)
+	sort.Strings(kinds)
+	return kinds, nil
+}
+
+func (s *NotificationStore) SetMuted(ctx context.Context, userID string, kinds []string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if _, ok := s.d.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	s.d.mutes[userID] = append([]string{}, kinds...)
+	return nil
+}
+
+// deleteNotifications removes the notifications about an article or comment,
+// like the ON DELETE CASCADE foreign keys. Callers must hold d.mu.
+func (d *data) deleteNotifications(match func(n *notification) bool) {
+	for id, n := range d.notifications {
+		if match(n) {
+			delete(d.notifications, id)
+		}
+	}
+}
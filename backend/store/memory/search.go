@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"html"
+	"strings"
+	"unicode"
+)
+
+type SearchStore struct {
+	d *data
+}
+
+// snippetWords is roughly the MaxWords used by ts_headline in PostgreSQL.
+const snippetWords = 35
+
+// Search is a simple fallback for PostgreSQL full-text search: it matches
+// words, phrases and prefixes case-insensitively and ranks by the number of
+// matches.
+func (s *SearchStore) Search(ctx context.Context, query store.SearchQuery, page store.PageRequest) (store.Page[models.SearchResult], error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	var results []models.SearchResult
+
+	if query.Type == "" || query.Type == "article" {
+		for _, a := range s.d.articles {
+			if a.Status != models.ArticlePublished || a.Hidden {
+				continue
+			}
+			if r, ok := s.match(query, strings.TrimSpace(a.Title+"\n"+a.Content), a.UserID); ok {
+				r.Type, r.ID, r.ArticleID, r.CreatedAt = "article", a.ID, a.ID, a.CreatedAt
+				results = appendInRange(results, r, query)
+			}
+		}
+	}
+	if query.Type == "" || query.Type == "comment" {
+		for _, c := range s.d.comments {
+			if a := s.d.articles[c.ArticleID]; a.Status != models.ArticlePublished || a.Hidden {
+				continue
+			}
+			if r, ok := s.match(query, c.Content, c.UserID); ok {
+				r.Type, r.ID, r.ArticleID, r.CreatedAt = "comment", c.ID, c.ArticleID, c.CreatedAt
+				results = appendInRange(results, r, query)
+			}
+		}
+	}
+
+	return paginate(results, page, true, searchCursor), nil
+}
+
+func searchCursor(r models.SearchResult) store.Cursor {
+	return store.Cursor{Score: r.Rank, CreatedAt: r.CreatedAt, ID: r.ID}
+}
+
+// match checks content against every term and builds the result without its
+// identity fields. Callers must hold d.mu.
+func (s *SearchStore) match(query store.SearchQuery, content, userID string) (models.SearchResult, bool) {
+	if query.AuthorID != "" && userID != query.AuthorID {
+		return models.SearchResult{}, false
+	}
+
+	tokens := tokenize(content)
+	marked := make([]bool, len(tokens))
+	hits := 0
+	for _, term := range query.Terms {
+		found := false
+		for i := 0; i+len(term.Words) <= len(tokens); i++ {
+			if termMatchesAt(term, tokens, i) {
+				found = true
+				hits++
+				for j := range term.Words {
+					marked[i+j] = true
+				}
+			}
+		}
+		if !found {
+			return models.SearchResult{}, false
+		}
+	}
+
+	return models.SearchResult{
+		UserID:  userID,
+		Rank:    float64(hits),
+		Snippet: snippet(content, tokens, marked),
+		Author:  s.d.profile(userID),
+	}, true
+}
+
+func appendInRange(results []models.SearchResult, r models.SearchResult, query store.SearchQuery) []models.SearchResult {
+	if query.From != nil && r.CreatedAt.Before(*query.From) {
+		return results
+	}
+	if query.To != nil && !r.CreatedAt.Before(*query.To) {
+		return results
+	}
+	return append(results, r)
+}
+
+// token is a lowercased word and its byte span in the original text.
+type token struct {
+	word       string
+	start, end int
+}
+
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		wordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if wordRune && start < 0 {
+			start = i
+		} else if !wordRune && start >= 0 {
+			tokens = append(tokens, token{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return tokens
+}
+
+func termMatchesAt(term store.SearchTerm, tokens []token, i int) bool {
+	for j, w := range term.Words {
+		last := j == len(term.Words)-1
+		if last && term.Prefix {
+			if !strings.HasPrefix(tokens[i+j].word, w) {
+				return false
+			}
+		} else if tokens[i+j].word != w {
+			return false
+		}
+	}
+	return true
+}
+
+// snippet returns an HTML-escaped window of the text around the first match
+// with matched words wrapped in <mark>, like ts_headline.
+func snippet(text string, tokens []token, marked []bool) string {
+	first := 0
+	for i, m := range marked {
+		if m {
+			first = i
+			break
+		}
+	}
+	from := max(0, first-snippetWords/3)
+	to := min(len(tokens), from+snippetWords)
+	if from >= to {
+		return html.EscapeString(text)
+	}
+
+	var b strings.Builder
+	pos := 0
+	if from > 0 {
+		pos = tokens[from].start
+	}
+	for i := from; i < to; i++ {
+		b.WriteString(html.EscapeString(text[pos:tokens[i].start]))
+		word := html.EscapeString(text[tokens[i].start:tokens[i].end])
+		if marked[i] {
+			word = "<mark>" + word + "</mark>"
+		}
+		b.WriteString(word)
+		pos = tokens[i].end
+	}
+	if to == len(tokens) {
+		b.WriteString(html.EscapeString(text[pos:]))
+	}
+	return b.String()
+}
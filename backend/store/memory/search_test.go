@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var day = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// searchData seeds articles and comments straight into the tables, with
+// their creation times.
+type searchData struct {
+	d *data
+}
+
+func (s searchData) article(id, status string, created time.Time, content string) {
+	s.d.articles[id] = models.Article{ID: id, UserID: "author-" + id, Status: status, Content: content, CreatedAt: created}
+}
+
+func (s searchData) comment(id, articleID string, created time.Time, content string) {
+	s.d.comments[id] = models.Comment{ID: id, ArticleID: articleID, UserID: "commenter", Content: content, CreatedAt: created}
+}
+
+func newSearch() (*SearchStore, searchData) {
+	stores := New()
+	search := stores.Search.(*SearchStore)
+	return search, searchData{search.d}
+}
+
+// ids returns the type:id of the results in order.
+func ids(page store.Page[models.SearchResult]) []string {
+	var out []string
+	for _, r := range page.Items {
+		out = append(out, r.Type+":"+r.ID)
+	}
+	return out
+}
+
+func TestSearchExcludesUnpublished(t *testing.T) {
+	s, seed := newSearch()
+	seed.article("pub", models.ArticlePublished, day, "Gophers dig")
+	seed.article("draft", models.ArticleDraft, day, "Gophers draft")
+	seed.article("scheduled", models.ArticleScheduled, day, "Gophers soon")
+	seed.article("archived", models.ArticleArchived, day, "Gophers past")
+	seed.comment("c-pub", "pub", day, "Nice gophers")
+	seed.comment("c-draft", "draft", day, "Gophers too")
+	seed.comment("c-orphan", "gone", day, "Gophers orphaned")
+
+	page, err := s.Search(context.Background(), store.SearchQuery{Terms: store.ParseSearchTerms("gophers")}, store.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ids(page)
+	slices.Sort(got)
+	if want := []string{"article:pub", "comment:c-pub"}; !slices.Equal(got, want) {
+		t.Fatalf("results = %v, want %v", got, want)
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	s, seed := newSearch()
+	seed.article("a1", models.ArticlePublished, day, "Go concurrency patterns with goroutines")
+	seed.article("a2", models.ArticlePublished, day.Add(time.Hour), "Patterns of go go go")
+	seed.article("a3", models.ArticlePublished, day.AddDate(0, 0, 2), "Concurrency is not parallelism")
+	seed.comment("c1", "a1", day.Add(2*time.Hour), "More go patterns please")
+
+	tests := []struct {
+		name  string
+		query store.SearchQuery
+		want  []string
+	}{
+		// Ranked by matches, then newest first.
+		{"word", store.SearchQuery{Terms: store.ParseSearchTerms("go")}, []string{"article:a2", "comment:c1", "article:a1"}},
+		{"every term", store.SearchQuery{Terms: store.ParseSearchTerms("GO concurrency")}, []string{"article:a1"}},
+		{"phrase", store.SearchQuery{Terms: store.ParseSearchTerms(`"go patterns"`)}, []string{"comment:c1"}},
+		{"prefix", store.SearchQuery{Terms: store.ParseSearchTerms("gorout*")}, []string{"article:a1"}},
+		{"no prefix match without star", store.SearchQuery{Terms: store.ParseSearchTerms("gorout")}, nil},
+		{"type", store.SearchQuery{Terms: store.ParseSearchTerms("patterns"), Type: "comment"}, []string{"comment:c1"}},
+		{"author", store.SearchQuery{Terms: store.ParseSearchTerms("concurrency"), AuthorID: "author-a3"}, []string{"article:a3"}},
+		{"from", store.SearchQuery{Terms: store.ParseSearchTerms("concurrency"), From: ptr(day.AddDate(0, 0, 1))}, []string{"article:a3"}},
+		{"to is exclusive", store.SearchQuery{Terms: store.ParseSearchTerms("patterns"), To: ptr(day.Add(time.Hour))}, []string{"article:a1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := s.Search(context.Background(), tt.query, store.PageRequest{Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(page); !slices.Equal(got, tt.want) {
+				t.Fatalf("results = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchPagination(t *testing.T) {
+	s, seed := newSearch()
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		seed.article(id, models.ArticlePublished, day.Add(time.Duration(i)*time.Hour), "gopher")
+	}
+	query := store.SearchQuery{Terms: store.ParseSearchTerms("gopher")}
+
+	var got []string
+	page := store.PageRequest{Limit: 2}
+	for {
+		res, err := s.Search(context.Background(), query, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ids(res)...)
+		if res.NextCursor == nil {
+			break
+		}
+		page.After = res.NextCursor
+	}
+	if want := []string{"article:e", "article:d", "article:c", "article:b", "article:a"}; !slices.Equal(got, want) {
+		t.Fatalf("results = %v, want %v", got, want)
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	s, seed := newSearch()
+	seed.article("xss", models.ArticlePublished, day, `Beware <script>alert("x")</script> & <b>bold</b> claims`)
+	long := strings.Repeat("filler ", 50) + "needle" + strings.Repeat(" filler", 50)
+	seed.article("long", models.ArticlePublished, day, long)
+
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"script", `Beware &lt;<mark>script</mark>&gt;alert(&#34;x&#34;)&lt;/<mark>script</mark>&gt; &amp; &lt;b&gt;bold&lt;/b&gt; claims`},
+		{`<script>`, `Beware &lt;<mark>script</mark>&gt;alert(&#34;x&#34;)&lt;/<mark>script</mark>&gt; &amp; &lt;b&gt;bold&lt;/b&gt; claims`},
+		{`"b bold"`, `Beware &lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; &lt;<mark>b</mark>&gt;<mark>bold</mark>&lt;/b&gt; claims`},
+	}
+	for _, tt := range tests {
+		page, err := s.Search(context.Background(), store.SearchQuery{Terms: store.ParseSearchTerms(tt.q)}, store.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Items) != 1 || page.Items[0].Snippet != tt.want {
+			t.Errorf("q=%s: snippets %v, want %q", tt.q, page.Items, tt.want)
+		}
+	}
+
+	// Long texts are cut around the first match.
+	page, _ := s.Search(context.Background(), store.SearchQuery{Terms: store.ParseSearchTerms("needle")}, store.PageRequest{Limit: 10})
+	if len(page.Items) != 1 {
+		t.Fatalf("got %d results, want 1", len(page.Items))
+	}
+	snippet := page.Items[0].Snippet
+	if words := len(strings.Fields(snippet)); words != snippetWords || !strings.Contains(snippet, "<mark>needle</mark>") {
+		t.Errorf("snippet of %d words %q, want %d words around the match", words, snippet, snippetWords)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"blog-api/models"
+	"context"
+	"sort"
+)
+
+type TagStore struct {
+	d *data
+}
+
+func (s *TagStore) List(ctx context.Context) ([]models.Tag, error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, a := range s.d.articles {
+		if a.Status != models.ArticlePublished || a.Hidden {
+			continue
+		}
+		for _, name := range a.Tags {
+			counts[name]++
+		}
+	}
+
+	tags := make([]models.Tag, 0, len(counts))
+	for name, n := range counts {
+		tags = append(tags, models.Tag{Name: name, Articles: n})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Articles != tags[j].Articles {
+			return tags[i].Articles > tags[j].Articles
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
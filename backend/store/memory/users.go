@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"time"
+)
+
+type UserStore struct {
+	d *data
+}
+
+func (s *UserStore) Create(ctx context.Context, user *models.User) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if _, ok := s.d.users[user.ID]; ok {
+		return store.ErrConflict
+	}
+	for _, u := range s.d.users {
+		if u.Email == user.Email {
+			return store.ErrConflict
+		}
+	}
+
+	if user.Role == "" {
+		user.Role = models.RoleAuthor
+	}
+	user.CreatedAt = time.Now()
+	s.d.users[user.ID] = *user
+	return nil
+}
+
+func (s *UserStore) Get(ctx context.Context, id string) (*models.User, error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	u, ok := s.d.users[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &u, nil
+}
+
+func (s *UserStore) List(ctx context.Context, role string, page store.PageRequest) (store.Page[models.User], error) {
+	s.d.mu.RLock()
+	defer s.d.mu.RUnlock()
+
+	var users []models.User
+	for _, u := range s.d.users {
+		if role == "" || u.Role == role {
+			users = append(users, u)
+		}
+	}
+	return paginate(users, page, true, userCursor), nil
+}
+
+func userCursor(u models.User) store.Cursor {
+	return store.Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
+}
+
+func (s *UserStore) Update(ctx context.Context, user *models.User) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u, ok := s.d.users[user.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.FirstName = user.FirstName
+	u.LastName = user.LastName
+	if user.AvatarMediaID != nil {
+		u.AvatarMediaID = optionalID(*user.AvatarMediaID)
+	}
+	s.d.users[u.ID] = u
+	return nil
+}
+
+func (s *UserStore) SetRole(ctx context.Context, id, role string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u, ok := s.d.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.Role = role
+	s.d.users[u.ID] = u
+	return nil
+}
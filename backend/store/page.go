@@ -0,0 +1,127 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor identifies a row in a list ordered by (created_at, id), or by
+// (score, created_at, id) for ranked lists. Lists ordered by another time,
+// such as articles by PublishedAt, keep it in CreatedAt. It is opaque to
+// clients.
+type Cursor struct {
+	Score     float64
+	CreatedAt time.Time
+	ID        string
+}
+
+func (c Cursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	if c.Score != 0 {
+		raw += "|" + strconv.FormatFloat(c.Score, 'g', -1, 64)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) < 2 || len(parts) > 3 || parts[1] == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	cursor := Cursor{CreatedAt: createdAt, ID: parts[1]}
+	if len(parts) == 3 {
+		if cursor.Score, err = strconv.ParseFloat(parts[2], 64); err != nil {
+			return Cursor{}, ErrInvalidCursor
+		}
+	}
+	return cursor, nil
+}
+
+// PageRequest selects a window of a list. After continues in the list's
+// natural order from a cursor, Before walks back towards its start. At most
+// one of them is set.
+type PageRequest struct {
+	Limit  int
+	After  *Cursor
+	Before *Cursor
+}
+
+// Backward reports whether the page is read against the list order.
+func (p PageRequest) Backward() bool {
+	return p.Before != nil
+}
+
+// Cursor returns whichever of After or Before is set.
+func (p PageRequest) Cursor() *Cursor {
+	if p.Before != nil {
+		return p.Before
+	}
+	return p.After
+}
+
+// Page is one window of a list with cursors to its neighbours. A nil cursor
+// means there is nothing more in that direction.
+type Page[T any] struct {
+	Items      []T
+	NextCursor *Cursor
+	PrevCursor *Cursor
+}
+
+// NewPage builds a Page from up to Limit+1 rows read in query order, which is
+// the list order reversed for backward requests. The extra row only signals
+// that another page exists.
+func NewPage[T any](rows []T, req PageRequest, cursorOf func(T) Cursor) Page[T] {
+	more := len(rows) > req.Limit
+	if more {
+		rows = rows[:req.Limit]
+	}
+	if req.Backward() {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	page := Page[T]{Items: rows}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if len(rows) == 0 {
+		// Nothing in that direction: point back to where the client came from.
+		if req.Backward() {
+			page.NextCursor = req.Before
+		} else {
+			page.PrevCursor = req.After
+		}
+		return page
+	}
+
+	first, last := cursorOf(rows[0]), cursorOf(rows[len(rows)-1])
+	if req.Backward() {
+		page.NextCursor = &last
+		if more {
+			page.PrevCursor = &first
+		}
+	} else {
+		if more {
+			page.NextCursor = &last
+		}
+		if req.After != nil {
+			page.PrevCursor = &first
+		}
+	}
+	return page
+}
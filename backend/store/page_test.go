@@ -0,0 +1,85 @@
+package store
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	c := Cursor{CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.FixedZone("CEST", 2*3600)), ID: "0b7e1d1e-6c1b-4a38-9f8e-5a7b7a1d2c3e"}
+	got, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID {
+		t.Fatalf("DecodeCursor(Encode) = %+v, want %+v", got, c)
+	}
+
+	for _, s := range []string{"", "!!", "bm8tc2VwYXJhdG9y", "MjAyNC0wNS0wMXw", "bm90LWEtdGltZXxpZA"} {
+		if _, err := DecodeCursor(s); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	at := func(id int) Cursor { return Cursor{CreatedAt: time.Unix(int64(id), 0), ID: string(rune('a' + id))} }
+	cursorOf := func(id int) Cursor { return at(id) }
+	after, before := at(9), at(0)
+
+	tests := []struct {
+		name       string
+		rows       []int
+		req        PageRequest
+		items      []int
+		next, prev *Cursor
+	}{
+		{"first page", []int{1, 2, 3}, PageRequest{Limit: 2}, []int{1, 2}, ptr(at(2)), nil},
+		{"last page", []int{1, 2}, PageRequest{Limit: 2}, []int{1, 2}, nil, nil},
+		{"after", []int{1, 2, 3}, PageRequest{Limit: 2, After: &after}, []int{1, 2}, ptr(at(2)), ptr(at(1))},
+		{"after, last page", []int{1}, PageRequest{Limit: 2, After: &after}, []int{1}, nil, ptr(at(1))},
+		// Backward rows come in reverse order and are put back in list order.
+		{"before", []int{3, 2, 1}, PageRequest{Limit: 2, Before: &before}, []int{2, 3}, ptr(at(3)), ptr(at(2))},
+		{"before, first page", []int{2, 1}, PageRequest{Limit: 2, Before: &before}, []int{1, 2}, ptr(at(2)), nil},
+		{"empty after", nil, PageRequest{Limit: 2, After: &after}, []int{}, nil, &after},
+		{"empty before", nil, PageRequest{Limit: 2, Before: &before}, []int{}, &before, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewPage(slices.Clone(tt.rows), tt.req, cursorOf)
+			if !slices.Equal(page.Items, tt.items) {
+				t.Errorf("items = %v, want %v", page.Items, tt.items)
+			}
+			if !sameCursor(page.NextCursor, tt.next) || !sameCursor(page.PrevCursor, tt.prev) {
+				t.Errorf("cursors = %v, %v; want %v, %v", page.NextCursor, page.PrevCursor, tt.next, tt.prev)
+			}
+		})
+	}
+}
+
+func sameCursor(a, b *Cursor) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.ID == b.ID && a.CreatedAt.Equal(b.CreatedAt)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestRankedCursor(t *testing.T) {
+	c := Cursor{Score: 0.25, CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), ID: "0b7e1d1e-6c1b-4a38-9f8e-5a7b7a1d2c3e"}
+	got, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Score != c.Score || !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID {
+		t.Fatalf("DecodeCursor(Encode) = %+v, want %+v", got, c)
+	}
+	// "2024-05-01T12:00:00Z|id|x": the score is not a number.
+	if _, err := DecodeCursor("MjAyNC0wNS0wMVQxMjowMDowMFp8aWR8eA"); err != ErrInvalidCursor {
+		t.Errorf("DecodeCursor with a bad score = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,526 @@
+package postgres
+
+import (
+	"blog-api/markdown"
+	"blog-api/models"
+	"blog-api/slug"
+	"blog-api/store"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ArticleStore struct {
+	db *sql.DB
+}
+
+const articleSelect = `
+	SELECT a.id, a.user_id, a.title, a.slug, a.excerpt, a.content, a.content_html, a.render_version, a.status, a.publish_at, a.cover_media_id, a.hidden, a.likes,
+	       a.engagement, a.hot_score, a.created_at,
+	       ARRAY(
+	         SELECT t.name FROM article_tags tg JOIN tags t ON t.id = tg.tag_id
+	         WHERE tg.article_id = a.id ORDER BY t.name
+	       ),
+	       ` + authorColumns + `
+	FROM articles a
+	LEFT JOIN users u ON a.user_id = u.id`
+
+func scanArticle(row scanner) (*models.Article, error) {
+	var article models.Article
+	var author models.Profile
+	var tags pq.StringArray
+	var renderVersion int
+	var cover sql.NullString
+	dest, finish := authorDest(&author)
+
+	err := row.Scan(append([]interface{}{
+		&article.ID,
+		&article.UserID,
+		&article.Title,
+		&article.Slug,
+		&article.Excerpt,
+		&article.Content,
+		&article.ContentHTML,
+		&renderVersion,
+		&article.Status,
+		&article.PublishAt,
+		&cover,
+		&article.Hidden,
+		&article.Likes,
+		&article.Engagement,
+		&article.HotScore,
+		&article.CreatedAt,
+		&tags,
+	}, dest...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	finish()
+	article.CoverMediaID = nullString(cover)
+	if renderVersion != markdown.Version {
+		article.ContentHTML = markdown.Render(article.Content)
+	}
+	article.Tags = append([]string{}, tags...)
+	article.Author = &author
+	return &article, nil
+}
+
+func (s *ArticleStore) List(ctx context.Context, filter store.ArticleFilter, page store.PageRequest) (store.Page[models.Article], error) {
+	var args []interface{}
+	conditions := []string{"TRUE"}
+	if filter.AuthorID != "" {
+		args = append(args, filter.AuthorID)
+		conditions = append(conditions, fmt.Sprintf("a.user_id = $%d", len(args)))
+	}
+	if filter.FeedOf != "" {
+		args = append(args, filter.FeedOf)
+		conditions = append(conditions, fmt.Sprintf(
+			"(a.user_id = $%[1]d OR a.user_id IN (SELECT following_id FROM followers WHERE follower_id = $%[1]d))", len(args)))
+	}
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		conditions = append(conditions, fmt.Sprintf("a.status = ANY($%d)", len(args)))
+	}
+	if len(filter.Tags) > 0 {
+		args = append(args, pq.Array(filter.Tags))
+		tagged := fmt.Sprintf(`
+			SELECT tg.article_id FROM article_tags tg JOIN tags t ON t.id = tg.tag_id
+			WHERE t.name = ANY($%d)`, len(args))
+		if filter.AllTags {
+			args = append(args, len(filter.Tags))
+			tagged += fmt.Sprintf(`
+			GROUP BY tg.article_id HAVING count(*) = $%d`, len(args))
+		}
+		conditions = append(conditions, "a.id IN ("+tagged+")")
+	}
+	if filter.PublishedSince != nil {
+		args = append(args, *filter.PublishedSince)
+		conditions = append(conditions, fmt.Sprintf("a.publish_at >= $%d", len(args)))
+	}
+	if !filter.IncludeHidden {
+		conditions = append(conditions, "NOT a.hidden")
+	}
+
+	var where, order string
+	var pageArgs []interface{}
+	cursorOf := articleCursor
+	switch filter.Sort {
+	case store.SortHot:
+		where, order, pageArgs = rankedKeyset(page, "a.hot_score", "a.created_at", "a.id", len(args)+1)
+		cursorOf = hotCursor
+	case store.SortTop:
+		where, order, pageArgs = rankedKeyset(page, "a.engagement", "a.created_at", "a.id", len(args)+1)
+		cursorOf = topCursor
+	default:
+		where, order, pageArgs = keyset(page, "COALESCE(a.publish_at, a.created_at)", "a.id", true, len(args)+1)
+	}
+	rows, err := s.db.QueryContext(ctx, articleSelect+`
+		WHERE `+strings.Join(append(conditions, where), " AND ")+`
+		`+order, append(args, pageArgs...)...)
+	if err != nil {
+		return store.Page[models.Article]{}, err
+	}
+	defer rows.Close()
+
+	var articles []models.Article
+	for rows.Next() {
+		article, err := scanArticle(rows)
+		if err != nil {
+			return store.Page[models.Article]{}, err
+		}
+		articles = append(articles, *article)
+	}
+	if err := rows.Err(); err != nil {
+		return store.Page[models.Article]{}, err
+	}
+	return store.NewPage(articles, page, cursorOf), nil
+}
+
+func articleCursor(a models.Article) store.Cursor {
+	return store.Cursor{CreatedAt: store.PublishedAt(a), ID: a.ID}
+}
+
+func hotCursor(a models.Article) store.Cursor {
+	return store.Cursor{Score: a.HotScore, CreatedAt: a.CreatedAt, ID: a.ID}
+}
+
+func topCursor(a models.Article) store.Cursor {
+	return store.Cursor{Score: float64(a.Engagement), CreatedAt: a.CreatedAt, ID: a.ID}
+}
+
+func (s *ArticleStore) Get(ctx context.Context, id string) (*models.Article, error) {
+	article, err := scanArticle(s.db.QueryRowContext(ctx, articleSelect+`
+		WHERE a.id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	return article, err
+}
+
+func (s *ArticleStore) GetBySlug(ctx context.Context, slug string) (*models.Article, error) {
+	article, err := scanArticle(s.db.QueryRowContext(ctx, articleSelect+`
+		WHERE a.slug = $1
+		   OR a.id = (SELECT article_id FROM article_slugs WHERE slug = $1)
+	`, slug))
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	return article, err
+}
+
+func (s *ArticleStore) Create(ctx context.Context, article *models.Article) error {
+	return retrySlug(article, func() error { return s.create(ctx, article) })
+}
+
+func (s *ArticleStore) create(ctx context.Context, article *models.Article) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	article.Slug, err = uniqueSlug(ctx, tx, article.Slug, article.ID)
+	if err != nil {
+		return err
+	}
+	article.ContentHTML = markdown.Render(article.Content)
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO articles (id, user_id, title, slug, excerpt, content, content_html, render_version, status, publish_at,
+		                      cover_media_id, likes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, '')::uuid, $12)
+		RETURNING created_at
+	`, article.ID, article.UserID, article.Title, article.Slug, article.Excerpt, article.Content,
+		article.ContentHTML, markdown.Version, article.Status, article.PublishAt, article.CoverMediaID, 0).Scan(&article.CreatedAt)
+	if isUniqueViolation(err) {
+		return store.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := setTags(ctx, tx, article.ID, article.Tags); err != nil {
+		return err
+	}
+	if err := insertRevision(ctx, tx, article, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *ArticleStore) Update(ctx context.Context, article *models.Article) error {
+	return retrySlug(article, func() error { return s.update(ctx, article, nil) })
+}
+
+func (s *ArticleStore) Restore(ctx context.Context, article *models.Article, number int) error {
+	return retrySlug(article, func() error { return s.update(ctx, article, &number) })
+}
+
+// update implements Update and Restore. A revision is always recorded for a
+// restore, and otherwise only when the title or content changed.
+func (s *ArticleStore) update(ctx context.Context, article *models.Article, restoredFrom *int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var currentTitle, currentSlug, currentContent string
+	err = tx.QueryRowContext(ctx, `
+		SELECT title, slug, content FROM articles
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
+	`, article.ID, article.UserID).Scan(&currentTitle, &currentSlug, &currentContent)
+	if err == sql.ErrNoRows {
+		return store.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	newSlug := currentSlug
+	if article.Title != currentTitle {
+		if newSlug, err = uniqueSlug(ctx, tx, article.Slug, article.ID); err != nil {
+			return err
+		}
+	}
+	if newSlug != currentSlug {
+		// The article may be taking back one of its own previous slugs.
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM article_slugs WHERE slug = $1
+		`, newSlug); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO article_slugs (slug, article_id) VALUES ($1, $2)
+		`, currentSlug, article.ID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE articles
+		SET title = $1, slug = $2, excerpt = $3, content = $4, content_html = $5, render_version = $6,
+		    status = $7, publish_at = $8,
+		    cover_media_id = CASE WHEN $9::text IS NULL THEN cover_media_id ELSE NULLIF($9, '')::uuid END
+		WHERE id = $10
+	`, article.Title, newSlug, article.Excerpt, article.Content, markdown.Render(article.Content), markdown.Version,
+		article.Status, article.PublishAt, article.CoverMediaID, article.ID)
+	if isUniqueViolation(err) {
+		return store.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	if article.Tags != nil {
+		if err := setTags(ctx, tx, article.ID, article.Tags); err != nil {
+			return err
+		}
+	}
+	if restoredFrom != nil || article.Title != currentTitle || article.Content != currentContent {
+		if err := insertRevision(ctx, tx, article, restoredFrom); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	updated, err := s.Get(ctx, article.ID)
+	if err != nil {
+		return err
+	}
+	*article = *updated
+	return nil
+}
+
+func (s *ArticleStore) Delete(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM articles
+		WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	return rowsAffected(result)
+}
+
+func (s *ArticleStore) SetHidden(ctx context.Context, id string, hidden bool) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE articles SET hidden = $1 WHERE id = $2
+	`, hidden, id)
+	if err != nil {
+		return err
+	}
+	return rowsAffected(result)
+}
+
+func (s *ArticleStore) PublishDue(ctx context.Context, now time.Time) ([]models.Article, error) {
+	// SKIP LOCKED lets several instances run the scheduler at once: each due
+	// article is claimed by exactly one UPDATE.
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE articles
+		SET status = 'published'
+		WHERE id IN (
+			SELECT id FROM articles
+			WHERE status = 'scheduled' AND publish_at <= $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var published []models.Article
+	for _, id := range ids {
+		article, err := s.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		published = append(published, *article)
+	}
+	return published, nil
+}
+
+func (s *ArticleStore) RefreshScores(ctx context.Context, now time.Time) (int, error) {
+	// Only rows whose values move are written, which keeps old articles
+	// from being rewritten once their hot score has settled.
+	result, err := s.db.ExecContext(ctx, `
+		WITH counts AS (
+			SELECT a.id,
+			       COALESCE(a.likes, 0) * $2
+			       + (SELECT COUNT(*) FROM comments c WHERE c.article_id = a.id AND c.deleted_at IS NULL) * $3
+			       + (SELECT COUNT(*) FROM favorites f WHERE f.article_id = a.id) * $4 AS engagement,
+			       GREATEST(EXTRACT(EPOCH FROM $1::timestamptz - a.publish_at)::float8 / 3600, 0) AS age_hours
+			FROM articles a
+			WHERE a.status = 'published'
+		), scores AS (
+			SELECT id, engagement, (engagement + 1) / power(age_hours + 2, $5::float8) AS hot_score
+			FROM counts
+		)
+		UPDATE articles a
+		SET engagement = s.engagement, hot_score = s.hot_score
+		FROM scores s
+		WHERE a.id = s.id
+		  AND (a.engagement <> s.engagement OR abs(a.hot_score - s.hot_score) > 1e-9)
+	`, now, store.LikeWeight, store.CommentWeight, store.FavoriteWeight, store.HotGravity)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+func (s *ArticleStore) RenderStale(ctx context.Context, limit int) (int, error) {
+	return renderStale(ctx, s.db, "articles", limit)
+}
+
+const revisionSelect = `
+	SELECT id, article_id, number, user_id, title, content, restored_from, created_at
+	FROM article_revisions`
+
+func scanRevision(row scanner) (*models.Revision, error) {
+	var rev models.Revision
+	var userID sql.NullString
+	var restoredFrom sql.NullInt64
+	err := row.Scan(&rev.ID, &rev.ArticleID, &rev.Number, &userID, &rev.Title, &rev.Content, &restoredFrom, &rev.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	rev.UserID = userID.String
+	if restoredFrom.Valid {
+		n := int(restoredFrom.Int64)
+		rev.RestoredFrom = &n
+	}
+	return &rev, nil
+}
+
+func (s *ArticleStore) Revisions(ctx context.Context, articleID string) ([]models.Revision, error) {
+	rows, err := s.db.QueryContext(ctx, revisionSelect+`
+		WHERE article_id = $1
+		ORDER BY number DESC
+	`, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.Revision{}
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *rev)
+	}
+	return revisions, rows.Err()
+}
+
+func (s *ArticleStore) Revision(ctx context.Context, articleID string, number int) (*models.Revision, error) {
+	rev, err := scanRevision(s.db.QueryRowContext(ctx, revisionSelect+`
+		WHERE article_id = $1 AND number = $2
+	`, articleID, number))
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	return rev, err
+}
+
+// insertRevision appends the article's current title and content to its
+// history. The article row is locked by the caller, so numbers don't race.
+func insertRevision(ctx context.Context, tx *sql.Tx, article *models.Article, restoredFrom *int) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO article_revisions (article_id, number, user_id, title, content, restored_from)
+		SELECT $1, COALESCE(MAX(number), 0) + 1, $2, $3, $4, $5
+		FROM article_revisions
+		WHERE article_id = $1
+	`, article.ID, article.UserID, article.Title, article.Content, restoredFrom)
+	return err
+}
+
+// setTags replaces the tags of an article, creating tags seen for the first
+// time. Names must already be normalized and distinct.
+func setTags(ctx context.Context, tx *sql.Tx, articleID string, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM article_tags WHERE article_id = $1
+	`, articleID); err != nil {
+		return err
+	}
+	for _, name := range tags {
+		// DO UPDATE rather than DO NOTHING so RETURNING also yields existing tags.
+		var tagID string
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO tags (name) VALUES ($1)
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id
+		`, name).Scan(&tagID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO article_tags (article_id, tag_id) VALUES ($1, $2)
+		`, articleID, tagID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slugAttempts bounds the attempts of retrySlug.
+const slugAttempts = 3
+
+// retrySlug runs write, which picks a slug with uniqueSlug, again when a
+// concurrent write took that slug before it was stored: the next attempt
+// sees it taken and picks the next suffix. article.Slug is reset to the
+// base slug before each attempt.
+func retrySlug(article *models.Article, write func() error) error {
+	base := article.Slug
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if !errors.Is(err, store.ErrConflict) || attempt == slugAttempts {
+			return err
+		}
+		article.Slug = base
+	}
+}
+
+// uniqueSlug returns the first of base, base-2, base-3... that no other
+// article uses now or used before.
+func uniqueSlug(ctx context.Context, tx *sql.Tx, base, articleID string) (string, error) {
+	for n := 1; ; n++ {
+		candidate := slug.WithSuffix(base, n)
+		var taken bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM articles WHERE slug = $1 AND id <> $2)
+			    OR EXISTS(SELECT 1 FROM article_slugs WHERE slug = $1 AND article_id <> $2)
+		`, candidate, articleID).Scan(&taken)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+}
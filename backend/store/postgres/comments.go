@@ -0,0 +1,235 @@
+package postgres
+
+import (
+	"blog-api/markdown"
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type CommentStore struct {
+	db *sql.DB
+}
+
+const commentSelect = `
+	SELECT c.id, c.article_id, c.parent_id, c.depth, c.user_id, c.content, c.content_html, c.render_version,
+	       c.deleted_at IS NOT NULL,
+	       (SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id),
+	       c.created_at,
+	       ` + authorColumns + `
+	FROM comments c
+	LEFT JOIN users u ON c.user_id = u.id`
+
+func scanComment(row scanner) (*models.Comment, error) {
+	var comment models.Comment
+	var author models.Profile
+	var parentID sql.NullString
+	var renderVersion int
+	dest, finish := authorDest(&author)
+
+	err := row.Scan(append([]interface{}{
+		&comment.ID,
+		&comment.ArticleID,
+		&parentID,
+		&comment.Depth,
+		&comment.UserID,
+		&comment.Content,
+		&comment.ContentHTML,
+		&renderVersion,
+		&comment.Deleted,
+		&comment.ReplyCount,
+		&comment.CreatedAt,
+	}, dest...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID.Valid {
+		comment.ParentID = &parentID.String
+	}
+	if comment.Deleted {
+		comment.Content = models.DeletedCommentContent
+		comment.ContentHTML = markdown.Render(comment.Content)
+		comment.UserID = ""
+		return &comment, nil
+	}
+	if renderVersion != markdown.Version {
+		comment.ContentHTML = markdown.Render(comment.Content)
+	}
+	finish()
+	comment.Author = &author
+	return &comment, nil
+}
+
+func scanComments(rows *sql.Rows) ([]models.Comment, error) {
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *comment)
+	}
+	return comments, rows.Err()
+}
+
+func (s *CommentStore) ListByArticle(ctx context.Context, articleID string, page store.PageRequest) (store.Page[models.Comment], error) {
+	where, order, args := keyset(page, "c.created_at", "c.id", false, 2)
+	rows, err := s.db.QueryContext(ctx, commentSelect+`
+		WHERE c.article_id = $1 AND `+where+`
+		`+order, append([]interface{}{articleID}, args...)...)
+	if err != nil {
+		return store.Page[models.Comment]{}, err
+	}
+	comments, err := scanComments(rows)
+	if err != nil {
+		return store.Page[models.Comment]{}, err
+	}
+	return store.NewPage(comments, page, commentCursor), nil
+}
+
+func (s *CommentStore) Thread(ctx context.Context, articleID string, page store.PageRequest) (store.Page[models.Comment], error) {
+	where, order, args := keyset(page, "c.created_at", "c.id", false, 2)
+	rows, err := s.db.QueryContext(ctx, commentSelect+`
+		WHERE c.article_id = $1 AND c.parent_id IS NULL AND `+where+`
+		`+order, append([]interface{}{articleID}, args...)...)
+	if err != nil {
+		return store.Page[models.Comment]{}, err
+	}
+	roots, err := scanComments(rows)
+	if err != nil {
+		return store.Page[models.Comment]{}, err
+	}
+	result := store.NewPage(roots, page, commentCursor)
+	if len(result.Items) == 0 {
+		return result, nil
+	}
+
+	rootIDs := make([]string, len(result.Items))
+	for i, c := range result.Items {
+		rootIDs[i] = c.ID
+	}
+	rows, err = s.db.QueryContext(ctx, `
+		WITH RECURSIVE thread AS (
+			SELECT id FROM comments WHERE parent_id = ANY($1::uuid[])
+			UNION ALL
+			SELECT r.id FROM comments r JOIN thread t ON r.parent_id = t.id
+		)`+commentSelect+`
+		WHERE c.id IN (SELECT id FROM thread)
+		ORDER BY c.created_at, c.id
+	`, pq.Array(rootIDs))
+	if err != nil {
+		return store.Page[models.Comment]{}, err
+	}
+	replies, err := scanComments(rows)
+	if err != nil {
+		return store.Page[models.Comment]{}, err
+	}
+	store.NestReplies(result.Items, replies)
+	return result, nil
+}
+
+func commentCursor(c models.Comment) store.Cursor {
+	return store.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+}
+
+func (s *CommentStore) Get(ctx context.Context, id string) (*models.Comment, error) {
+	comment, err := scanComment(s.db.QueryRowContext(ctx, commentSelect+`
+		WHERE c.id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	return comment, err
+}
+
+func (s *CommentStore) Create(ctx context.Context, comment *models.Comment) error {
+	comment.ContentHTML = markdown.Render(comment.Content)
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO comments (id, article_id, parent_id, depth, user_id, content, content_html, render_version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at
+	`, comment.ID, comment.ArticleID, comment.ParentID, comment.Depth, comment.UserID, comment.Content,
+		comment.ContentHTML, markdown.Version).Scan(&comment.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+func (s *CommentStore) Update(ctx context.Context, id, userID, content string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE comments
+		SET content = $1, content_html = $2, render_version = $3
+		WHERE id = $4 AND user_id = $5 AND deleted_at IS NULL
+	`, content, markdown.Render(content), markdown.Version, id, userID)
+	if err != nil {
+		return err
+	}
+	return rowsAffected(result)
+}
+
+func (s *CommentStore) RenderStale(ctx context.Context, limit int) (int, error) {
+	return renderStale(ctx, s.db, "comments", limit)
+}
+
+func (s *CommentStore) Delete(ctx context.Context, id, userID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The row lock makes replies posted meanwhile wait, then fail their
+	// foreign key check if the comment ends up removed.
+	var parentID sql.NullString
+	var hasReplies bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT c.parent_id, EXISTS(SELECT 1 FROM comments r WHERE r.parent_id = c.id)
+		FROM comments c
+		WHERE c.id = $1 AND c.user_id = $2 AND c.deleted_at IS NULL
+		FOR UPDATE
+	`, id, userID).Scan(&parentID, &hasReplies)
+	if err == sql.ErrNoRows {
+		return store.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if hasReplies {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE comments SET content = '', content_html = '', deleted_at = now() WHERE id = $1
+		`, id); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM comments WHERE id = $1`, id); err != nil {
+		return err
+	}
+	// Walk up the thread removing deleted placeholders that lost their last reply.
+	for parentID.Valid {
+		next := sql.NullString{}
+		err := tx.QueryRowContext(ctx, `
+			DELETE FROM comments p
+			WHERE p.id = $1 AND p.deleted_at IS NOT NULL
+			  AND NOT EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = p.id)
+			RETURNING p.parent_id
+		`, parentID.String).Scan(&next)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		parentID = next
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"database/sql"
+)
+
+type FavoriteStore struct {
+	db *sql.DB
+}
+
+func (s *FavoriteStore) ListByUser(ctx context.Context, userID string, page store.PageRequest) (store.Page[models.Favorite], error) {
+	where, order, args := keyset(page, "f.created_at", "f.id", true, 2)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT f.id, f.user_id, f.article_id, f.created_at,
+		       a.id, a.user_id, a.title, a.slug, a.excerpt, a.content, a.likes, a.created_at,
+		       `+authorColumns+`
+		FROM favorites f
+		JOIN articles a ON f.article_id = a.id AND a.status = 'published' AND NOT a.hidden
+		LEFT JOIN users u ON a.user_id = u.id
+		WHERE f.user_id = $1 AND `+where+`
+		`+order, append([]interface{}{userID}, args...)...)
+	if err != nil {
+		return store.Page[models.Favorite]{}, err
+	}
+	defer rows.Close()
+
+	var favorites []models.Favorite
+	for rows.Next() {
+		var fav models.Favorite
+		var article models.Article
+		var author models.Profile
+		dest, finish := authorDest(&author)
+
+		err := rows.Scan(append([]interface{}{
+			&fav.ID,
+			&fav.ProfileID, // f.user_id
+			&fav.ArticleID,
+			&fav.CreatedAt,
+			&article.ID,
+			&article.ProfileID, // a.user_id
+			&article.Title,
+			&article.Slug,
+			&article.Excerpt,
+			&article.Content,
+			&article.Likes,
+			&article.CreatedAt,
+		}, dest...)...)
+		if err != nil {
+			return store.Page[models.Favorite]{}, err
+		}
+
+		finish()
+		article.Author = &author
+		fav.Article = &article
+		favorites = append(favorites, fav)
+	}
+	if err := rows.Err(); err != nil {
+		return store.Page[models.Favorite]{}, err
+	}
+	return store.NewPage(favorites, page, favoriteCursor), nil
+}
+
+func favoriteCursor(f models.Favorite) store.Cursor {
+	return store.Cursor{CreatedAt: f.CreatedAt, ID: f.ID}
+}
+
+func (s *FavoriteStore) Add(ctx context.Context, fav *models.Favorite) error {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO favorites (id, user_id, article_id)
+		VALUES ($1, $2, $3)
+		RETURNING created_at
+	`, fav.ID, fav.ProfileID, fav.ArticleID).Scan(&fav.CreatedAt)
+	if isUniqueViolation(err) {
+		return store.ErrConflict
+	}
+	if isForeignKeyViolation(err) {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+func (s *FavoriteStore) Remove(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM favorites
+		WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	return rowsAffected(result)
+}
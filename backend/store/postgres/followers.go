@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"database/sql"
+)
+
+type FollowerStore struct {
+	db *sql.DB
+}
+
+// list returns follow relations matching whereColumn = userID, joined with
+// the profile found through joinColumn.
+func (s *FollowerStore) list(ctx context.Context, joinColumn, whereColumn, userID string, page store.PageRequest) ([]models.Follower, []*models.Profile, error) {
+	where, order, args := keyset(page, "f.created_at", "f.id", true, 2)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT f.id, f.follower_id, f.following_id, f.created_at,
+		       `+authorColumns+`
+		FROM followers f
+		LEFT JOIN users u ON f.`+joinColumn+` = u.id
+		WHERE f.`+whereColumn+` = $1 AND `+where+`
+		`+order, append([]interface{}{userID}, args...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var relations []models.Follower
+	var profiles []*models.Profile
+	for rows.Next() {
+		var rel models.Follower
+		var profile models.Profile
+		dest, finish := authorDest(&profile)
+
+		err := rows.Scan(append([]interface{}{
+			&rel.ID,
+			&rel.FollowerID,
+			&rel.FollowingID,
+			&rel.CreatedAt,
+		}, dest...)...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		finish()
+		relations = append(relations, rel)
+		profiles = append(profiles, &profile)
+	}
+	return relations, profiles, rows.Err()
+}
+
+func (s *FollowerStore) Followers(ctx context.Context, userID string, page store.PageRequest) (store.Page[models.Follower], error) {
+	followers, profiles, err := s.list(ctx, "follower_id", "following_id", userID, page)
+	if err != nil {
+		return store.Page[models.Follower]{}, err
+	}
+	for i := range followers {
+		followers[i].Follower = profiles[i]
+	}
+	return store.NewPage(followers, page, followerCursor), nil
+}
+
+func (s *FollowerStore) Following(ctx context.Context, userID string, page store.PageRequest) (store.Page[models.Follower], error) {
+	following, profiles, err := s.list(ctx, "following_id", "follower_id", userID, page)
+	if err != nil {
+		return store.Page[models.Follower]{}, err
+	}
+	for i := range following {
+		following[i].Following = profiles[i]
+	}
+	return store.NewPage(following, page, followerCursor), nil
+}
+
+func followerCursor(f models.Follower) store.Cursor {
+	return store.Cursor{CreatedAt: f.CreatedAt, ID: f.ID}
+}
+
+func (s *FollowerStore) Follow(ctx context.Context, follower *models.Follower) error {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO followers (id, follower_id, following_id)
+		VALUES ($1, $2, $3)
+		RETURNING created_at
+	`, follower.ID, follower.FollowerID, follower.FollowingID).Scan(&follower.CreatedAt)
+	if isUniqueViolation(err) {
+		return store.ErrConflict
+	}
+	if isForeignKeyViolation(err) {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+func (s *FollowerStore) Unfollow(ctx context.Context, followerID, followingID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM followers
+		WHERE follower_id = $1 AND following_id = $2
+	`, followerID, followingID)
+	if err != nil {
+		return err
+	}
+	return rowsAffected(result)
+}
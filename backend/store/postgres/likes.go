@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"blog-api/store"
+	"context"
+	"database/sql"
+)
+
+type LikeStore struct {
+	db *sql.DB
+}
+
+func (s *LikeStore) Status(ctx context.Context, articleID, userID string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM likes
+			WHERE article_id = $1 AND user_id = $2
+		)
+	`, articleID, userID).Scan(&exists)
+	return exists, err
+}
+
+func (s *LikeStore) Count(ctx context.Context, articleID string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM likes
+		WHERE article_id = $1
+	`, articleID).Scan(&count)
+	return count, err
+}
+
+func (s *LikeStore) Add(ctx context.Context, articleID, userID string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO likes (article_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, article_id) DO NOTHING
+	`, articleID, userID)
+	if isForeignKeyViolation(err) {
+		return 0, store.ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := syncLikes(ctx, tx, articleID)
+	if err != nil {
+		return 0, err
+	}
+	return count, tx.Commit()
+}
+
+func (s *LikeStore) Remove(ctx context.Context, articleID, userID string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM likes
+		WHERE article_id = $1 AND user_id = $2
+	`, articleID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := rowsAffected(result); err != nil {
+		return 0, err
+	}
+
+	count, err := syncLikes(ctx, tx, articleID)
+	if err != nil {
+		return 0, err
+	}
+	return count, tx.Commit()
+}
+
+// syncLikes recounts the likes of an article and stores the total in
+// articles.likes.
+func syncLikes(ctx context.Context, tx *sql.Tx, articleID string) (int, error) {
+	var count int
+	err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM likes
+		WHERE article_id = $1
+	`, articleID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE articles
+		SET likes = $1
+		WHERE id = $2
+	`, count, articleID)
+	return count, err
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"database/sql"
+)
+
+type MediaStore struct {
+	db *sql.DB
+}
+
+func (s *MediaStore) Create(ctx context.Context, media *models.Media) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO media (id, user_id, content_type, size, width, height, storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at
+	`, media.ID, media.UserID, media.ContentType, media.Size, media.Width, media.Height, media.Key).Scan(&media.CreatedAt)
+	if err != nil {
+		return err
+	}
+	for _, v := range media.Variants {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO media_variants (media_id, name, content_type, size, width, height, storage_key)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, media.ID, v.Name, v.ContentType, v.Size, v.Width, v.Height, v.Key); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *MediaStore) Get(ctx context.Context, id string) (*models.Media, error) {
+	var media models.Media
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, content_type, size, width, height, storage_key, created_at
+		FROM media
+		WHERE id = $1
+	`, id).Scan(&media.ID, &media.UserID, &media.ContentType, &media.Size, &media.Width, &media.Height,
+		&media.Key, &media.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT name, content_type, size, width, height, storage_key
+		FROM media_variants
+		WHERE media_id = $1
+		ORDER BY width, name
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	media.Variants = []models.MediaVariant{}
+	for rows.Next() {
+		var v models.MediaVariant
+		if err := rows.Scan(&v.Name, &v.ContentType, &v.Size, &v.Width, &v.Height, &v.Key); err != nil {
+			return nil, err
+		}
+		media.Variants = append(media.Variants, v)
+	}
+	return &media, rows.Err()
+}
+
+func (s *MediaStore) Delete(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM media
+		WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	return rowsAffected(result)
+}
@@ -0,0 +1,228 @@
+package postgres
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type NotificationStore struct {
+	db *sql.DB
+}
+
+func (s *NotificationStore) Add(ctx context.Context, n *models.Notification, actorID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO notifications (user_id, kind, article_id, comment_id, group_key)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, group_key) WHERE read_at IS NULL
+		DO UPDATE SET updated_at = now()
+		RETURNING id, created_at, updated_at
+	`, n.UserID, n.Kind, n.ArticleID, n.CommentID, store.NotificationGroup(n)).Scan(&n.ID, &n.CreatedAt, &n.UpdatedAt)
+	if isForeignKeyViolation(err) {
+		return store.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO notification_actors (notification_id, actor_id)
+		VALUES ($1, $2)
+		ON CONFLICT (notification_id, actor_id) DO UPDATE SET created_at = now()
+	`, n.ID, actorID)
+	if isForeignKeyViolation(err) {
+		return store.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM notification_actors WHERE notification_id = $1
+	`, n.ID).Scan(&n.ActorCount); err != nil {
+		return err
+	}
+	n.Read = false
+	return tx.Commit()
+}
+
+func (s *NotificationStore) List(ctx context.Context, userID string, unreadOnly bool, page store.PageRequest) (store.Page[models.Notification], error) {
+	where, order, args := keyset(page, "n.updated_at", "n.id", true, 3)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT n.id, n.user_id, n.kind, n.article_id, COALESCE(a.title, ''), n.comment_id,
+		       (SELECT COUNT(*) FROM notification_actors na WHERE na.notification_id = n.id),
+		       n.read_at IS NOT NULL, n.created_at, n.updated_at
+		FROM notifications n
+		LEFT JOIN articles a ON a.id = n.article_id
+		WHERE n.user_id = $1 AND (NOT $2::boolean OR n.read_at IS NULL) AND `+where+`
+		`+order, append([]interface{}{userID, unreadOnly}, args...)...)
+	if err != nil {
+		return store.Page[models.Notification]{}, err
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		var n models.Notification
+		var articleID, commentID sql.NullString
+		err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &articleID, &n.ArticleTitle, &commentID,
+			&n.ActorCount, &n.Read, &n.CreatedAt, &n.UpdatedAt)
+		if err != nil {
+			return store.Page[models.Notification]{}, err
+		}
+		if articleID.Valid {
+			n.ArticleID = &articleID.String
+		}
+		if commentID.Valid {
+			n.CommentID = &commentID.String
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return store.Page[models.Notification]{}, err
+	}
+
+	result := store.NewPage(notifications, page, notificationCursor)
+	if err := s.loadActors(ctx, result.Items); err != nil {
+		return store.Page[models.Notification]{}, err
+	}
+	return result, nil
+}
+
+func notificationCursor(n models.Notification) store.Cursor {
+	return store.Cursor{CreatedAt: n.UpdatedAt, ID: n.ID}
+}
+
+// loadActors fills in the most recent actors of each notification.
+func (s *NotificationStore) loadActors(ctx context.Context, notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	ids := make([]string, len(notifications))
+	byID := make(map[string]*models.Notification, len(notifications))
+	for i := range notifications {
+		ids[i] = notifications[i].ID
+		notifications[i].Actors = []models.Profile{}
+		byID[notifications[i].ID] = &notifications[i]
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT na.notification_id, u.id, `+authorColumns+`
+		FROM (
+			SELECT notification_id, actor_id,
+			       row_number() OVER (PARTITION BY notification_id ORDER BY created_at DESC) AS rank
+			FROM notification_actors
+			WHERE notification_id = ANY($1::uuid[])
+		) na
+		JOIN users u ON u.id = na.actor_id
+		WHERE na.rank <= $2
+		ORDER BY na.notification_id, na.rank
+	`, pq.Array(ids), store.MaxNotificationActors)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notificationID string
+		var actor models.Profile
+		dest, finish := authorDest(&actor)
+		if err := rows.Scan(append([]interface{}{&notificationID, &actor.ID}, dest...)...); err != nil {
+			return err
+		}
+		finish()
+		n := byID[notificationID]
+		n.Actors = append(n.Actors, actor)
+	}
+	return rows.Err()
+}
+
+func (s *NotificationStore) UnreadCount(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM notifications
+		WHERE user_id = $1 AND read_at IS NULL
+	`, userID).Scan(&count)
+	return count, err
+}
+
+func (s *NotificationStore) MarkRead(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, now())
+		WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	return rowsAffected(result)
+}
+
+func (s *NotificationStore) MarkAllRead(ctx context.Context, userID string) (int, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE notifications
+		SET read_at = now()
+		WHERE user_id = $1 AND read_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+func (s *NotificationStore) Muted(ctx context.Context, userID string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT kind FROM notification_mutes
+		WHERE user_id = $1
+		ORDER BY kind
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	kinds := []string{}
+	for rows.Next() {
+		var kind string
+		if err := rows.Scan(&kind); err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, rows.Err()
+}
+
+func (s *NotificationStore) SetMuted(ctx context.Context, userID string, kinds []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM notification_mutes WHERE user_id = $1
+	`, userID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO notification_mutes (user_id, kind)
+		SELECT $1, unnest($2::text[])
+	`, userID, pq.Array(kinds))
+	if isForeignKeyViolation(err) {
+		return store.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// New returns the PostgreSQL implementation of every store, backed by db.
+func New(db *sql.DB) store.Stores {
+	return store.Stores{
+		Articles:      &ArticleStore{db: db},
+		Comments:      &CommentStore{db: db},
+		Users:         &UserStore{db: db},
+		Favorites:     &FavoriteStore{db: db},
+		Followers:     &FollowerStore{db: db},
+		Likes:         &LikeStore{db: db},
+		Search:        &SearchStore{db: db},
+		Tags:          &TagStore{db: db},
+		Notifications: &NotificationStore{db: db},
+		Media:         &MediaStore{db: db},
+	}
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// authorColumns is selected from the users table aliased as "u".
+const authorColumns = `u.email, u.firstname, u.lastname, u.avatar_media_id, u.created_at`
+
+// authorDest returns scan destinations for authorColumns and a function that
+// copies the nullable names into the profile once the row has been scanned.
+func authorDest(author *models.Profile) ([]interface{}, func()) {
+	var firstName, lastName, avatar sql.NullString
+	dest := []interface{}{&author.Email, &firstName, &lastName, &avatar, &author.CreatedAt}
+	return dest, func() {
+		author.FirstName = firstName.String
+		author.LastName = lastName.String
+		author.AvatarMediaID = nullString(avatar)
+	}
+}
+
+// rowsAffected turns an UPDATE/DELETE result into store.ErrNotFound when no
+// row matched.
+func rowsAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// nullString converts a nullable column to an optional string.
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// keyset returns the WHERE condition, ORDER BY clause and arguments that
+// select one page of a list ordered by (createdCol, idCol). desc is the list's
+// natural order; backward requests scan the other way and NewPage flips the
+// rows back. Placeholders are numbered from argN.
+func keyset(page store.PageRequest, createdCol, idCol string, desc bool, argN int) (string, string, []interface{}) {
+	descending := desc != page.Backward()
+	direction, op := "ASC", ">"
+	if descending {
+		direction, op = "DESC", "<"
+	}
+	order := fmt.Sprintf("ORDER BY %s %s, %s %s LIMIT %d", createdCol, direction, idCol, direction, page.Limit+1)
+
+	cursor := page.Cursor()
+	if cursor == nil {
+		return "TRUE", order, nil
+	}
+	where := fmt.Sprintf("(%s, %s) %s ($%d::timestamptz, $%d::uuid)", createdCol, idCol, op, argN, argN+1)
+	return where, order, []interface{}{cursor.CreatedAt, cursor.ID}
+}
+
+// rankedKeyset is keyset for lists ordered by (scoreCol, createdCol, idCol),
+// highest score first, using the Score of the cursor.
+func rankedKeyset(page store.PageRequest, scoreCol, createdCol, idCol string, argN int) (string, string, []interface{}) {
+	direction, op := "DESC", "<"
+	if page.Backward() {
+		direction, op = "ASC", ">"
+	}
+	order := fmt.Sprintf("ORDER BY %s %s, %s %s, %s %s LIMIT %d",
+		scoreCol, direction, createdCol, direction, idCol, direction, page.Limit+1)
+
+	cursor := page.Cursor()
+	if cursor == nil {
+		return "TRUE", order, nil
+	}
+	where := fmt.Sprintf("(%s::float8, %s, %s) %s ($%d::float8, $%d::timestamptz, $%d::uuid)",
+		scoreCol, createdCol, idCol, op, argN, argN+1, argN+2)
+	return where, order, []interface{}{cursor.Score, cursor.CreatedAt, cursor.ID}
+}
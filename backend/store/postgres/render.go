@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"blog-api/markdown"
+	"context"
+	"database/sql"
+)
+
+// renderStale re-renders the content_html of up to limit rows of table
+// written by another markdown.Version. SKIP LOCKED lets several instances
+// share the work.
+func renderStale(ctx context.Context, db *sql.DB, table string, limit int) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, content FROM `+table+`
+		WHERE render_version <> $1
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, markdown.Version, limit)
+	if err != nil {
+		return 0, err
+	}
+	contents := map[string]string{}
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		contents[id] = content
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for id, content := range contents {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE `+table+` SET content_html = $1, render_version = $2 WHERE id = $3
+		`, markdown.Render(content), markdown.Version, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(contents), tx.Commit()
+}
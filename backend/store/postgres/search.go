@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type SearchStore struct {
+	db *sql.DB
+}
+
+// searchConfig is the text search configuration used by the search_vector
+// columns (see migration 0003).
+const searchConfig = "simple"
+
+// headlineOptions wraps matches in <mark> for ts_headline.
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+// tsQuery turns parsed terms into to_tsquery syntax: phrase words are joined
+// with <->, prefixes get :* and terms are ANDed.
+func tsQuery(terms []store.SearchTerm) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		part := strings.Join(term.Words, " <-> ")
+		if term.Prefix {
+			part += ":*"
+		}
+		if len(term.Words) > 1 {
+			part = "(" + part + ")"
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, " & ")
+}
+
+func (s *SearchStore) Search(ctx context.Context, query store.SearchQuery, page store.PageRequest) (store.Page[models.SearchResult], error) {
+	args := []interface{}{tsQuery(query.Terms)}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var branches []string
+	if query.Type == "" || query.Type == "article" {
+		branches = append(branches, `
+			SELECT 'article' AS type, a.id, a.id AS article_id, a.user_id, a.content, a.created_at,
+			       ts_rank_cd(a.search_vector, q)::float8 AS rank
+			FROM articles a, to_tsquery('`+searchConfig+`', $1) q
+			WHERE a.search_vector @@ q AND a.status = 'published' AND NOT a.hidden`)
+	}
+	if query.Type == "" || query.Type == "comment" {
+		branches = append(branches, `
+			SELECT 'comment' AS type, c.id, c.article_id, c.user_id, c.content, c.created_at,
+			       ts_rank_cd(c.search_vector, q)::float8 AS rank
+			FROM comments c
+			JOIN articles a ON a.id = c.article_id AND a.status = 'published' AND NOT a.hidden,
+			     to_tsquery('`+searchConfig+`', $1) q
+			WHERE c.search_vector @@ q`)
+	}
+
+	conditions := []string{"TRUE"}
+	if query.AuthorID != "" {
+		conditions = append(conditions, "r.user_id = "+arg(query.AuthorID)+"::uuid")
+	}
+	if query.From != nil {
+		conditions = append(conditions, "r.created_at >= "+arg(*query.From))
+	}
+	if query.To != nil {
+		conditions = append(conditions, "r.created_at < "+arg(*query.To))
+	}
+
+	direction, op := "DESC", "<"
+	if page.Backward() {
+		direction, op = "ASC", ">"
+	}
+	if cursor := page.Cursor(); cursor != nil {
+		conditions = append(conditions, fmt.Sprintf("(r.rank, r.created_at, r.id) %s (%s::float8, %s::timestamptz, %s::uuid)",
+			op, arg(cursor.Score), arg(cursor.CreatedAt), arg(cursor.ID)))
+	}
+
+	// Headlines are computed only for the rows of the page.
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.type, r.id, r.article_id, r.user_id, r.created_at, r.rank,
+		       ts_headline('`+searchConfig+`',
+		                   replace(replace(replace(r.content, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+		                   to_tsquery('`+searchConfig+`', $1), '`+headlineOptions+`'),
+		       `+authorColumns+`
+		FROM (
+			SELECT r.* FROM (`+strings.Join(branches, "\n\t\t\tUNION ALL")+`
+			) r
+			WHERE `+strings.Join(conditions, " AND ")+`
+			ORDER BY r.rank `+direction+`, r.created_at `+direction+`, r.id `+direction+`
+			LIMIT `+fmt.Sprint(page.Limit+1)+`
+		) r
+		LEFT JOIN users u ON r.user_id = u.id
+		ORDER BY r.rank `+direction+`, r.created_at `+direction+`, r.id `+direction, args...)
+	if err != nil {
+		return store.Page[models.SearchResult]{}, err
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var result models.SearchResult
+		var author models.Profile
+		dest, finish := authorDest(&author)
+
+		err := rows.Scan(append([]interface{}{
+			&result.Type,
+			&result.ID,
+			&result.ArticleID,
+			&result.UserID,
+			&result.CreatedAt,
+			&result.Rank,
+			&result.Snippet,
+		}, dest...)...)
+		if err != nil {
+			return store.Page[models.SearchResult]{}, err
+		}
+
+		finish()
+		result.Author = &author
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return store.Page[models.SearchResult]{}, err
+	}
+	return store.NewPage(results, page, searchCursor), nil
+}
+
+func searchCursor(r models.SearchResult) store.Cursor {
+	return store.Cursor{Score: r.Rank, CreatedAt: r.CreatedAt, ID: r.ID}
+}
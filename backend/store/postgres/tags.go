@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"blog-api/models"
+	"context"
+	"database/sql"
+)
+
+type TagStore struct {
+	db *sql.DB
+}
+
+func (s *TagStore) List(ctx context.Context) ([]models.Tag, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.name, COUNT(*)
+		FROM tags t
+		JOIN article_tags tg ON tg.tag_id = t.id
+		JOIN articles a ON a.id = tg.article_id
+		WHERE a.status = 'published' AND NOT a.hidden
+		GROUP BY t.name
+		ORDER BY COUNT(*) DESC, t.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.Name, &tag.Articles); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
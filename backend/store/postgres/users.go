@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"blog-api/models"
+	"blog-api/store"
+	"context"
+	"database/sql"
+)
+
+type UserStore struct {
+	db *sql.DB
+}
+
+func (s *UserStore) Create(ctx context.Context, user *models.User) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var existingID string
+	err = tx.QueryRowContext(ctx, "SELECT id FROM users WHERE email = $1", user.Email).Scan(&existingID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if existingID != "" {
+		return store.ErrConflict
+	}
+
+	if user.Role == "" {
+		user.Role = models.RoleAuthor
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO users (id, email, firstname, lastname, role)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`, user.ID, user.Email, user.FirstName, user.LastName, user.Role).Scan(&user.CreatedAt)
+	if isUniqueViolation(err) {
+		return store.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const userSelect = `
+	SELECT id, email, firstname, lastname, avatar_media_id, role, created_at
+	FROM users`
+
+func scanUser(row scanner) (*models.User, error) {
+	var user models.User
+	var firstName, lastName, avatar sql.NullString
+
+	err := row.Scan(&user.ID, &user.Email, &firstName, &lastName, &avatar, &user.Role, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	user.FirstName = firstName.String
+	user.LastName = lastName.String
+	user.AvatarMediaID = nullString(avatar)
+	return &user, nil
+}
+
+func (s *UserStore) Get(ctx context.Context, id string) (*models.User, error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx, userSelect+`
+		WHERE id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	return user, err
+}
+
+func (s *UserStore) List(ctx context.Context, role string, page store.PageRequest) (store.Page[models.User], error) {
+	where, order, args := keyset(page, "created_at", "id", true, 2)
+	rows, err := s.db.QueryContext(ctx, userSelect+`
+		WHERE ($1 = '' OR role = $1) AND `+where+`
+		`+order, append([]interface{}{role}, args...)...)
+	if err != nil {
+		return store.Page[models.User]{}, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return store.Page[models.User]{}, err
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return store.Page[models.User]{}, err
+	}
+	return store.NewPage(users, page, userCursor), nil
+}
+
+func userCursor(u models.User) store.Cursor {
+	return store.Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
+}
+
+func (s *UserStore) Update(ctx context.Context, user *models.User) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET firstname = $1, lastname = $2,
+		    avatar_media_id = CASE WHEN $3::text IS NULL THEN avatar_media_id ELSE NULLIF($3, '')::uuid END
+		WHERE id = $4
+	`, user.FirstName, user.LastName, user.AvatarMediaID, user.ID)
+	if err != nil {
+		return err
+	}
+	return rowsAffected(result)
+}
+
+func (s *UserStore) SetRole(ctx context.Context, id, role string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users SET role = $1 WHERE id = $2
+	`, role, id)
+	if err != nil {
+		return err
+	}
+	return rowsAffected(result)
+}
@@ -0,0 +1,31 @@
+package store
+
+import (
+	"math"
+	"time"
+)
+
+// Article orderings accepted by ArticleFilter.Sort.
+const (
+	SortNew = "new"
+	SortHot = "hot"
+	SortTop = "top"
+)
+
+// Weights of each interaction in an article's engagement.
+const (
+	LikeWeight     = 1
+	CommentWeight  = 2
+	FavoriteWeight = 3
+)
+
+// HotGravity sets how fast hot scores decay: an article's engagement is
+// divided by (age in hours + 2) raised to this power.
+const HotGravity = 1.8
+
+// HotScore decays engagement with the age of the article since it was
+// published. The PostgreSQL store computes the same formula in SQL.
+func HotScore(engagement int, age time.Duration) float64 {
+	hours := math.Max(age.Hours(), 0)
+	return float64(engagement+1) / math.Pow(hours+2, HotGravity)
+}
@@ -0,0 +1,28 @@
+package store
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHotScore(t *testing.T) {
+	if got, want := HotScore(3, 0), 4/math.Pow(2, HotGravity); got != want {
+		t.Fatalf("HotScore(3, 0) = %v, want %v", got, want)
+	}
+	// Articles published in the future score as if just published.
+	if HotScore(3, -time.Hour) != HotScore(3, 0) {
+		t.Fatal("a negative age changes the score")
+	}
+	// More engagement scores higher, and scores decay with age.
+	if HotScore(5, time.Hour) <= HotScore(4, time.Hour) {
+		t.Fatal("more engagement does not score higher")
+	}
+	if HotScore(5, 2*time.Hour) >= HotScore(5, time.Hour) {
+		t.Fatal("score does not decay with age")
+	}
+	// Without engagement, newer articles still come first.
+	if HotScore(0, 24*time.Hour) >= HotScore(0, time.Hour) {
+		t.Fatal("unengaged articles are not ordered by age")
+	}
+}
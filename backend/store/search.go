@@ -0,0 +1,56 @@
+package store
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SearchTerm is a single word, a quoted phrase of several words, or a word
+// ending in "*" that matches as a prefix.
+type SearchTerm struct {
+	Words  []string
+	Prefix bool
+}
+
+// SearchQuery is a parsed full-text query with its filters. All terms must
+// match.
+type SearchQuery struct {
+	Terms []SearchTerm
+	// Type restricts results to "article" or "comment"; empty means both.
+	Type     string
+	AuthorID string
+	From     *time.Time
+	To       *time.Time
+}
+
+// ParseSearchTerms splits user input into terms. Text in double quotes is a
+// phrase, a trailing "*" makes a prefix term, and punctuation separates
+// words, so the result is always safe to turn into a tsquery.
+func ParseSearchTerms(q string) []SearchTerm {
+	var terms []SearchTerm
+	for i, chunk := range strings.Split(q, `"`) {
+		if i%2 == 1 {
+			// Inside quotes: one phrase.
+			if words := SearchWords(chunk); len(words) > 0 {
+				terms = append(terms, SearchTerm{Words: words})
+			}
+			continue
+		}
+		for _, field := range strings.Fields(chunk) {
+			prefix := strings.HasSuffix(field, "*")
+			words := SearchWords(field)
+			for j, w := range words {
+				terms = append(terms, SearchTerm{Words: []string{w}, Prefix: prefix && j == len(words)-1})
+			}
+		}
+	}
+	return terms
+}
+
+// SearchWords lowercases text and splits it into runs of letters and digits.
+func SearchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
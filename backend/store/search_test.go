@@ -0,0 +1,31 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSearchTerms(t *testing.T) {
+	word := func(w string) SearchTerm { return SearchTerm{Words: []string{w}} }
+	tests := []struct {
+		q    string
+		want []SearchTerm
+	}{
+		{"", nil},
+		{"  Go  ", []SearchTerm{word("go")}},
+		{"go concurrency", []SearchTerm{word("go"), word("concurrency")}},
+		{`"Go Patterns" tips`, []SearchTerm{{Words: []string{"go", "patterns"}}, word("tips")}},
+		{"gorout*", []SearchTerm{{Words: []string{"gorout"}, Prefix: true}}},
+		// Punctuation only separates words, the prefix sticks to the last one.
+		{"e-mail*", []SearchTerm{word("e"), {Words: []string{"mail"}, Prefix: true}}},
+		{`x' | y & !z:*`, []SearchTerm{word("x"), word("y"), {Words: []string{"z"}, Prefix: true}}},
+		{`"unclosed phrase`, []SearchTerm{{Words: []string{"unclosed", "phrase"}}}},
+		{`"" * <>`, nil},
+		{"Straße 2024", []SearchTerm{word("straße"), word("2024")}},
+	}
+	for _, tt := range tests {
+		if got := ParseSearchTerms(tt.q); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSearchTerms(%q) = %+v, want %+v", tt.q, got, tt.want)
+		}
+	}
+}
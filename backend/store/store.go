@@ -0,0 +1,231 @@
+package store
+
+import (
+	"blog-api/models"
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when the requested row does not exist or does
+	// not belong to the acting user.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a unique constraint would be violated.
+	ErrConflict = errors.New("already exists")
+)
+
+// ArticleFilter narrows and orders an article listing. Empty fields match
+// everything.
+type ArticleFilter struct {
+	AuthorID string
+	// FeedOf keeps articles written by this user or by the users they follow.
+	FeedOf   string
+	Statuses []string
+	// Tags keeps articles with any of these tags, or all of them when
+	// AllTags is set.
+	Tags    []string
+	AllTags bool
+	// PublishedSince keeps articles published at or after this time.
+	PublishedSince *time.Time
+	// IncludeHidden keeps the articles hidden by moderators, which are left
+	// out by default.
+	IncludeHidden bool
+	// Sort is SortNew (the default, latest published first, see
+	// PublishedAt), SortHot (highest hot score first) or SortTop (highest
+	// engagement first).
+	Sort string
+}
+
+// PublishedAt is the time SortNew orders an article by: its publish_at, so
+// that a scheduled article comes out on top when it is published, or its
+// creation time when it has none, like a draft.
+func PublishedAt(a models.Article) time.Time {
+	if a.PublishAt != nil {
+		return *a.PublishAt
+	}
+	return a.CreatedAt
+}
+
+type ArticleStore interface {
+	// List pages through the articles matching filter in the order it
+	// asks for.
+	List(ctx context.Context, filter ArticleFilter, page PageRequest) (Page[models.Article], error)
+	Get(ctx context.Context, id string) (*models.Article, error)
+	// GetBySlug finds an article by its current slug or one it used to have.
+	// The returned article carries its current slug.
+	GetBySlug(ctx context.Context, slug string) (*models.Article, error)
+	// Create inserts an article with its tags and first revision. article.Slug is the
+	// base slug; a numeric suffix is added when it is taken and the final
+	// slug is written back. ErrConflict means concurrent writes kept taking
+	// the slug picked.
+	Create(ctx context.Context, article *models.Article) error
+	// Update changes the title, excerpt, content, status, publish date,
+	// tags (unless article.Tags is nil) and cover (unless
+	// article.CoverMediaID is nil; empty removes it) of the article
+	// article.ID owned by article.UserID. When the title
+	// changes the article gets a new unique slug derived from article.Slug
+	// and keeps the old one as a redirect. A new revision is recorded when
+	// the title or content changes. The stored article is written back into
+	// article. ErrConflict is as for Create.
+	Update(ctx context.Context, article *models.Article) error
+	// Restore is Update for a title and content copied from revision
+	// number; the new revision records where it was restored from.
+	Restore(ctx context.Context, article *models.Article, number int) error
+	// Revisions lists the revisions of an article, newest first.
+	Revisions(ctx context.Context, articleID string) ([]models.Revision, error)
+	// Revision returns one revision by number.
+	Revision(ctx context.Context, articleID string, number int) (*models.Revision, error)
+	// Delete removes an article owned by userID.
+	Delete(ctx context.Context, id, userID string) error
+	// SetHidden hides an article from everyone but its author and
+	// moderators, or shows it again.
+	SetHidden(ctx context.Context, id string, hidden bool) error
+	// PublishDue publishes the scheduled articles whose publish_at is not
+	// after now and returns them. Concurrent callers never publish the same
+	// article twice.
+	PublishDue(ctx context.Context, now time.Time) ([]models.Article, error)
+	// RefreshScores recomputes the engagement and hot score of published
+	// articles as of now and returns how many changed.
+	RefreshScores(ctx context.Context, now time.Time) (int, error)
+	// RenderStale renders again up to limit articles whose content_html
+	// comes from an older markdown.Version and returns how many it updated.
+	RenderStale(ctx context.Context, limit int) (int, error)
+}
+
+type CommentStore interface {
+	// ListByArticle pages through the comments of an article, oldest first,
+	// whatever their depth.
+	ListByArticle(ctx context.Context, articleID string, page PageRequest) (Page[models.Comment], error)
+	// Thread pages through the top-level comments of an article, oldest
+	// first, each with all of its replies nested in Replies.
+	Thread(ctx context.Context, articleID string, page PageRequest) (Page[models.Comment], error)
+	Get(ctx context.Context, id string) (*models.Comment, error)
+	// Create inserts a comment, or a reply when comment.ParentID is set.
+	// comment.Depth must already be the parent's depth plus one.
+	Create(ctx context.Context, comment *models.Comment) error
+	// Update changes the content of a comment owned by userID.
+	Update(ctx context.Context, id, userID, content string) error
+	// Delete removes a comment owned by userID. A comment with replies is
+	// only blanked out and marked deleted; deleted comments left without
+	// replies are then removed too.
+	Delete(ctx context.Context, id, userID string) error
+	// RenderStale is ArticleStore.RenderStale for comments.
+	RenderStale(ctx context.Context, limit int) (int, error)
+}
+
+type UserStore interface {
+	// Create inserts a user, returning ErrConflict if the email is taken.
+	// The user gets models.RoleAuthor when user.Role is empty.
+	Create(ctx context.Context, user *models.User) error
+	Get(ctx context.Context, id string) (*models.User, error)
+	// List pages through the users with the given role, or every user when
+	// role is empty, newest first.
+	List(ctx context.Context, role string, page PageRequest) (Page[models.User], error)
+	// Update changes the first and last name of a user, and the avatar
+	// unless AvatarMediaID is nil. An empty AvatarMediaID removes it.
+	Update(ctx context.Context, user *models.User) error
+	// SetRole changes the role of a user.
+	SetRole(ctx context.Context, id, role string) error
+}
+
+type MediaStore interface {
+	// Create records an uploaded image and its variants.
+	Create(ctx context.Context, media *models.Media) error
+	Get(ctx context.Context, id string) (*models.Media, error)
+	// Delete removes an image uploaded by userID. Articles and avatars
+	// using it lose their reference.
+	Delete(ctx context.Context, id, userID string) error
+}
+
+type FavoriteStore interface {
+	// ListByUser pages through a user's favorites, newest first, leaving out
+	// the articles that are not published or are hidden.
+	ListByUser(ctx context.Context, userID string, page PageRequest) (Page[models.Favorite], error)
+	Add(ctx context.Context, fav *models.Favorite) error
+	// Remove deletes a favorite owned by userID.
+	Remove(ctx context.Context, id, userID string) error
+}
+
+type FollowerStore interface {
+	// Followers pages through the users following userID, newest first.
+	Followers(ctx context.Context, userID string, page PageRequest) (Page[models.Follower], error)
+	// Following pages through the users userID follows, newest first.
+	Following(ctx context.Context, userID string, page PageRequest) (Page[models.Follower], error)
+	// Follow returns ErrConflict when the follow exists already, and
+	// ErrNotFound when either user does not.
+	Follow(ctx context.Context, follower *models.Follower) error
+	Unfollow(ctx context.Context, followerID, followingID string) error
+}
+
+type LikeStore interface {
+	Status(ctx context.Context, articleID, userID string) (bool, error)
+	Count(ctx context.Context, articleID string) (int, error)
+	// Add likes an article and returns the new like count, or ErrNotFound
+	// when the article does not exist.
+	Add(ctx context.Context, articleID, userID string) (int, error)
+	// Remove unlikes an article and returns the new like count.
+	Remove(ctx context.Context, articleID, userID string) (int, error)
+}
+
+type TagStore interface {
+	// List returns every tag used by a published article, most used first.
+	List(ctx context.Context) ([]models.Tag, error)
+}
+
+type NotificationStore interface {
+	// Add records that actorID caused notification n. It joins the unread
+	// notification of the same user, kind, article and comment when there is
+	// one, and otherwise creates it. The stored notification is written back
+	// into n, without its actors.
+	Add(ctx context.Context, n *models.Notification, actorID string) error
+	// List pages through a user's notifications, most recently active first,
+	// each with up to MaxNotificationActors actors.
+	List(ctx context.Context, userID string, unreadOnly bool, page PageRequest) (Page[models.Notification], error)
+	UnreadCount(ctx context.Context, userID string) (int, error)
+	// MarkRead marks one notification of userID as read.
+	MarkRead(ctx context.Context, id, userID string) error
+	// MarkAllRead marks every notification of userID as read and returns
+	// how many were unread.
+	MarkAllRead(ctx context.Context, userID string) (int, error)
+	// Muted returns the notification kinds userID does not receive.
+	Muted(ctx context.Context, userID string) ([]string, error)
+	// SetMuted replaces the muted kinds of userID.
+	SetMuted(ctx context.Context, userID string, kinds []string) error
+}
+
+// MaxNotificationActors is how many actors a listed notification carries.
+const MaxNotificationActors = 3
+
+type SearchStore interface {
+	// Search pages through articles and comments matching the query, best
+	// match first.
+	Search(ctx context.Context, query SearchQuery, page PageRequest) (Page[models.SearchResult], error)
+}
+
+// Stores groups every resource store the handlers depend on.
+type Stores struct {
+	Articles      ArticleStore
+	Comments      CommentStore
+	Users         UserStore
+	Favorites     FavoriteStore
+	Followers     FollowerStore
+	Likes         LikeStore
+	Search        SearchStore
+	Tags          TagStore
+	Notifications NotificationStore
+	Media         MediaStore
+}
+
+// NotificationGroup is the key under which unread notifications of a user
+// are merged: same kind, same article and same comment.
+func NotificationGroup(n *models.Notification) string {
+	key := n.Kind
+	for _, id := range []*string{n.ArticleID, n.CommentID} {
+		key += ":"
+		if id != nil {
+			key += *id
+		}
+	}
+	return key
+}
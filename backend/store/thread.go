@@ -0,0 +1,26 @@
+package store
+
+import "blog-api/models"
+
+// NestReplies fills the Replies of each root with its descendants, taken from
+// replies in the order they come, so chronological input gives
+// chronological threads.
+func NestReplies(roots, replies []models.Comment) {
+	children := make(map[string][]models.Comment)
+	for _, r := range replies {
+		if r.ParentID != nil {
+			children[*r.ParentID] = append(children[*r.ParentID], r)
+		}
+	}
+
+	var attach func(c *models.Comment)
+	attach = func(c *models.Comment) {
+		c.Replies = children[c.ID]
+		for i := range c.Replies {
+			attach(&c.Replies[i])
+		}
+	}
+	for i := range roots {
+		attach(&roots[i])
+	}
+}
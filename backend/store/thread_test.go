@@ -0,0 +1,30 @@
+package store
+
+import (
+	"blog-api/models"
+	"testing"
+)
+
+func TestNestReplies(t *testing.T) {
+	parent := func(id string) *string { return &id }
+	roots := []models.Comment{{ID: "a"}, {ID: "b"}}
+	replies := []models.Comment{
+		{ID: "a1", ParentID: parent("a")},
+		{ID: "a1x", ParentID: parent("a1")},
+		{ID: "a2", ParentID: parent("a")},
+		{ID: "orphan", ParentID: parent("gone")},
+		{ID: "c"},
+	}
+	NestReplies(roots, replies)
+
+	a := roots[0]
+	if len(a.Replies) != 2 || a.Replies[0].ID != "a1" || a.Replies[1].ID != "a2" {
+		t.Fatalf("replies of a = %+v, want a1 then a2", a.Replies)
+	}
+	if r := a.Replies[0].Replies; len(r) != 1 || r[0].ID != "a1x" {
+		t.Fatalf("replies of a1 = %+v, want a1x", r)
+	}
+	if roots[1].Replies != nil {
+		t.Fatalf("replies of b = %+v, want none", roots[1].Replies)
+	}
+}
@@ -0,0 +1,122 @@
+package syndication
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+var published = time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+
+func testFeed() Feed {
+	return Feed{
+		Title:       "Blog",
+		Description: "Latest articles",
+		Link:        "https://blog.example/",
+		FeedURL:     "https://blog.example/feeds/articles.rss",
+		Updated:     published,
+		Items: []Item{{
+			ID:        "0b9c7f3e-8f41-4c4a-9a55-4c1fe7a07f3b",
+			Title:     "Fish & <Chips>",
+			Link:      "https://blog.example/articles/fish-chips",
+			Author:    "Ann Lee",
+			Summary:   "Short",
+			Content:   "<p>Long</p>",
+			Tags:      []string{"food", "uk"},
+			Published: published,
+			Updated:   published,
+		}},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	body, err := testFeed().RSS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(body), xml.Header) {
+		t.Fatalf("RSS lacks the XML header: %s", body)
+	}
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Title      string   `xml:"title"`
+				GUID       string   `xml:"guid"`
+				PubDate    string   `xml:"pubDate"`
+				Creator    string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				Categories []string `xml:"category"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		t.Fatal(err)
+	}
+	item := doc.Channel.Items[0]
+	if doc.Version != "2.0" || doc.Channel.LastBuildDate != "Wed, 01 May 2024 10:00:00 +0000" {
+		t.Fatalf("channel = %+v", doc.Channel)
+	}
+	if item.Title != "Fish & <Chips>" || item.GUID != testFeed().Items[0].ID || item.Creator != "Ann Lee" || len(item.Categories) != 2 {
+		t.Fatalf("item = %+v", item)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	feed := testFeed()
+	feed.Items[0].HTML = true
+	body, err := feed.Atom()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Author  string `xml:"author>name"`
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("%v in %s", err, body)
+	}
+	if doc.ID != feed.FeedURL || doc.Updated != "2024-05-01T10:00:00Z" || len(doc.Entries) != 1 {
+		t.Fatalf("feed = %+v", doc)
+	}
+	entry := doc.Entries[0]
+	if entry.ID != "urn:uuid:"+feed.Items[0].ID || entry.Author != "Ann Lee" || entry.Content.Type != "html" || entry.Content.Value != "<p>Long</p>" {
+		t.Fatalf("entry = %+v", entry)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	body, err := testFeed().JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["version"] != "https://jsonfeed.org/version/1.1" || doc["home_page_url"] != "https://blog.example/" {
+		t.Fatalf("feed = %v", doc)
+	}
+	item := doc["items"].([]any)[0].(map[string]any)
+	if item["content_text"] != "<p>Long</p>" || item["content_html"] != nil || item["date_published"] != "2024-05-01T10:00:00Z" {
+		t.Fatalf("item = %v", item)
+	}
+
+	// An empty feed still has an items array.
+	body, _ = Feed{Title: "Empty"}.JSON()
+	if !strings.Contains(string(body), `"items": []`) {
+		t.Fatalf("empty feed = %s", body)
+	}
+}
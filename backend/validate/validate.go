@@ -0,0 +1,274 @@
+// Package validate checks request structs against the rules in their
+// `validate` struct tags and reports every invalid field at once.
+//
+// Rules are separated by commas:
+//
+//	required     the value is not empty (blank strings count as empty)
+//	min=N, max=N length of a string (in characters) or slice, or value of a number
+//	uuid         a UUID in canonical form
+//	email        a bare email address
+//	oneof=a b c  one of the listed values
+//
+// Rules other than required accept empty values, so optional fields only
+// need to be valid when they are set. uuid, email and oneof apply to each
+// element of a slice. Pointers are checked through, and fields of embedded
+// structs are checked as if they were declared inline. Rules spanning
+// several fields go in a Check method.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError describes one invalid field, named as in JSON.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors lists the invalid fields of a request.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Message
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+// Add records that field breaks rule.
+func (e *Errors) Add(field, rule, message string) {
+	*e = append(*e, FieldError{Field: field, Rule: rule, Message: message})
+}
+
+// Checker is implemented by requests with rules involving several fields.
+// Check runs after the tag rules and adds its own errors.
+type Checker interface {
+	Check(errs *Errors)
+}
+
+// Struct validates v, a pointer to a struct, and returns Errors when any
+// field is invalid.
+func Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	var errs Errors
+	for _, f := range fieldsOf(rv.Type()) {
+		for _, r := range f.rules {
+			if msg, ok := r.check(rv.FieldByIndex(f.index)); !ok {
+				errs.Add(f.name, r.name, f.name+" "+msg)
+				break
+			}
+		}
+	}
+	if checker, ok := v.(Checker); ok {
+		checker.Check(&errs)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Var validates a single value, such as a path parameter, against rules.
+func Var(name string, value any, rules string) error {
+	var errs Errors
+	rv := reflect.ValueOf(value)
+	for _, r := range parseRules(rules) {
+		if msg, ok := r.check(rv); !ok {
+			errs.Add(name, r.name, name+" "+msg)
+			break
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type field struct {
+	name  string
+	index []int
+	rules []rule
+}
+
+// fieldCache holds the parsed fields of each struct type.
+var fieldCache sync.Map
+
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+	var fields []field
+	for _, sf := range reflect.VisibleFields(t) {
+		tag := sf.Tag.Get("validate")
+		if tag == "" || !sf.IsExported() {
+			continue
+		}
+		fields = append(fields, field{name: jsonName(sf), index: sf.Index, rules: parseRules(tag)})
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
+
+type rule struct {
+	name  string
+	check func(v reflect.Value) (string, bool)
+}
+
+// parseRules panics on unknown rules: tags are fixed at compile time, so a
+// typo is a programming error.
+func parseRules(tag string) []rule {
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		var check func(v reflect.Value) (string, bool)
+		switch name {
+		case "required":
+			check = required
+		case "min", "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				panic(fmt.Sprintf("validate: invalid %s rule %q", name, part))
+			}
+			check = bound(name == "min", n)
+		case "uuid":
+			check = stringRule(isUUID, "must be a valid UUID")
+		case "email":
+			check = stringRule(isEmail, "must be a valid email address")
+		case "oneof":
+			allowed := strings.Fields(arg)
+			check = stringRule(func(s string) bool {
+				for _, a := range allowed {
+					if s == a {
+						return true
+					}
+				}
+				return false
+			}, "must be one of: "+strings.Join(allowed, ", "))
+		default:
+			panic(fmt.Sprintf("validate: unknown rule %q", part))
+		}
+		rules = append(rules, rule{name: name, check: check})
+	}
+	return rules
+}
+
+// indirect follows pointers and reports false for nil ones.
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, v.IsValid()
+}
+
+func isEmpty(v reflect.Value) bool {
+	v, ok := indirect(v)
+	if !ok {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+func required(v reflect.Value) (string, bool) {
+	return "is required", !isEmpty(v)
+}
+
+func bound(isMin bool, n int) func(reflect.Value) (string, bool) {
+	return func(v reflect.Value) (string, bool) {
+		if isEmpty(v) {
+			return "", true
+		}
+		v, _ = indirect(v)
+		var size int64
+		unit := ""
+		switch v.Kind() {
+		case reflect.String:
+			size, unit = int64(utf8.RuneCountInString(v.String())), " characters"
+		case reflect.Slice, reflect.Map:
+			size, unit = int64(v.Len()), " items"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			size = v.Int()
+		default:
+			panic("validate: min and max do not apply to " + v.Kind().String())
+		}
+		if isMin {
+			return fmt.Sprintf("must be at least %d%s", n, unit), size >= int64(n)
+		}
+		return fmt.Sprintf("must be at most %d%s", n, unit), size <= int64(n)
+	}
+}
+
+func stringRule(valid func(string) bool, message string) func(reflect.Value) (string, bool) {
+	return func(v reflect.Value) (string, bool) {
+		if isEmpty(v) {
+			return "", true
+		}
+		v, _ = indirect(v)
+		if v.Kind() == reflect.Slice {
+			for i := 0; i < v.Len(); i++ {
+				if !valid(v.Index(i).String()) {
+					return message, false
+				}
+			}
+			return "", true
+		}
+		return message, valid(v.String())
+	}
+}
+
+// isUUID accepts the canonical 8-4-4-4-12 hexadecimal form only.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if s[i] != '-' {
+				return false
+			}
+		case (s[i] >= '0' && s[i] <= '9') || (s[i] >= 'a' && s[i] <= 'f') || (s[i] >= 'A' && s[i] <= 'F'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isEmail accepts addresses without a display name, with a dotted domain.
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+	_, domain, _ := strings.Cut(s, "@")
+	return strings.Contains(domain, ".") && !strings.HasSuffix(domain, ".")
+}
@@ -0,0 +1,129 @@
+package validate
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type signup struct {
+	address
+	Email    string   `json:"email" validate:"required,email,max=254"`
+	Name     *string  `json:"name" validate:"min=2,max=5"`
+	Role     string   `json:"role" validate:"oneof=reader author"`
+	Friends  []string `json:"friends" validate:"max=2,uuid"`
+	Age      int      `validate:"min=18"`
+	Password string   `json:"password"`
+	Confirm  string   `json:"confirm"`
+}
+
+func (s *signup) Check(errs *Errors) {
+	if s.Password != s.Confirm {
+		errs.Add("confirm", "matches", "confirm must match password")
+	}
+}
+
+// fields returns field:rule for each error of err.
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("err = %v, want Errors", err)
+	}
+	var got []string
+	for _, fe := range errs {
+		if !strings.HasPrefix(fe.Message, fe.Field+" ") {
+			t.Errorf("message %q does not start with the field", fe.Message)
+		}
+		got = append(got, fe.Field+":"+fe.Rule)
+	}
+	return got
+}
+
+func TestStruct(t *testing.T) {
+	name := func(s string) *string { return &s }
+	const id = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	valid := func() signup {
+		return signup{address: address{City: "Paris"}, Email: "ann@example.com", Age: 30}
+	}
+	tests := []struct {
+		name   string
+		change func(s *signup)
+		want   []string
+	}{
+		{"valid", func(s *signup) {}, nil},
+		{"all optional fields set", func(s *signup) {
+			s.Name, s.Role, s.Friends = name("Ann"), "author", []string{id, strings.ToUpper(id)}
+		}, nil},
+		{"nil pointer is optional", func(s *signup) { s.Name = nil }, nil},
+		{"blank required string", func(s *signup) { s.Email = "  " }, []string{"email:required"}},
+		{"embedded field", func(s *signup) { s.City = "" }, []string{"city:required"}},
+		{"invalid email", func(s *signup) { s.Email = "Ann <ann@example.com>" }, []string{"email:email"}},
+		{"email without a dotted domain", func(s *signup) { s.Email = "ann@localhost" }, []string{"email:email"}},
+		{"too long email", func(s *signup) { s.Email = strings.Repeat("a", 250) + "@example.com" }, []string{"email:max"}},
+		{"short name", func(s *signup) { s.Name = name("A") }, []string{"name:min"}},
+		{"long name counts characters", func(s *signup) { s.Name = name("Zoë") }, nil},
+		{"long name", func(s *signup) { s.Name = name("Annabelle") }, []string{"name:max"}},
+		{"unknown role", func(s *signup) { s.Role = "admin" }, []string{"role:oneof"}},
+		{"too many friends", func(s *signup) { s.Friends = []string{id, id, id} }, []string{"friends:max"}},
+		{"invalid friend", func(s *signup) { s.Friends = []string{id, "nope"} }, []string{"friends:uuid"}},
+		{"number", func(s *signup) { s.Age = 12 }, []string{"Age:min"}},
+		{"several fields", func(s *signup) { s.Email, s.Role = "", "admin" }, []string{"email:required", "role:oneof"}},
+		{"check method", func(s *signup) { s.Password = "secret" }, []string{"confirm:matches"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid()
+			tt.change(&s)
+			if got := fields(t, Struct(&s)); !slices.Equal(got, tt.want) {
+				t.Fatalf("errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVar(t *testing.T) {
+	tests := []struct {
+		value any
+		rules string
+		want  []string
+	}{
+		{"7c9e6679-7425-40de-944b-e07fc1f90ae7", "required,uuid", nil},
+		{"7c9e6679742540de944be07fc1f90ae7", "required,uuid", []string{"id:uuid"}},
+		{"7c9e6679-7425-40de-944b-e07fc1f90ae", "uuid", []string{"id:uuid"}},
+		{"", "required,uuid", []string{"id:required"}},
+		{"", "uuid", nil},
+		{5, "max=3", []string{"id:max"}},
+	}
+	for _, tt := range tests {
+		if got := fields(t, Var("id", tt.value, tt.rules)); !slices.Equal(got, tt.want) {
+			t.Errorf("Var(%v, %q) = %v, want %v", tt.value, tt.rules, got, tt.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var errs Errors
+	errs.Add("email", "required", "email is required")
+	errs.Add("role", "oneof", "role must be one of: reader, author")
+	if got, want := errs.Error(), "invalid request: email is required; role must be one of: reader, author"; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestUnknownRulePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic for an unknown rule")
+		}
+	}()
+	Var("id", "x", "required,uuidv4")
+}
@@ -0,0 +1,103 @@
+// Package worker runs the server's periodic background jobs.
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// stalledAfter is how many intervals a job may go without finishing a run
+// before Check reports it.
+const stalledAfter = 3
+
+// Job is a task run every Interval until the runner stops.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Runner starts jobs in their own goroutines and stops them together.
+type Runner struct {
+	jobs   []Job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	running bool
+	// finished is when each job last finished a run, or when the runner
+	// started.
+	finished []time.Time
+}
+
+func NewRunner(jobs ...Job) *Runner {
+	return &Runner{jobs: jobs}
+}
+
+// Start runs every job once immediately, then on its interval.
+func (r *Runner) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.mu.Lock()
+	r.running = true
+	r.finished = make([]time.Time, len(r.jobs))
+	for i := range r.finished {
+		r.finished[i] = time.Now()
+	}
+	r.mu.Unlock()
+	for i, job := range r.jobs {
+		r.wg.Add(1)
+		go r.loop(ctx, i, job)
+	}
+}
+
+// Stop cancels the jobs and waits for the running ones to return.
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	r.running = false
+	r.mu.Unlock()
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+}
+
+// Check reports an error when the runner is not running, or when a job has
+// not finished a run for several intervals, which means it is stuck.
+func (r *Runner) Check(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.running {
+		return errors.New("not running")
+	}
+	for i, job := range r.jobs {
+		if since := time.Since(r.finished[i]); since > stalledAfter*job.Interval {
+			return fmt.Errorf("job %s has not finished a run for %s", job.Name, since.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+func (r *Runner) loop(ctx context.Context, i int, job Job) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("worker %s: %v", job.Name, err)
+		}
+		r.mu.Lock()
+		r.finished[i] = time.Now()
+		r.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunner(t *testing.T) {
+	var runs, failures atomic.Int32
+	first := make(chan struct{}, 1)
+	r := NewRunner(
+		Job{Name: "count", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+			if runs.Add(1) == 1 {
+				first <- struct{}{}
+			}
+			return nil
+		}},
+		// A failing job keeps running on its interval.
+		Job{Name: "fail", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+			failures.Add(1)
+			return errors.New("boom")
+		}},
+	)
+	r.Start(context.Background())
+
+	// Jobs run once right away, before the first tick.
+	select {
+	case <-first:
+	case <-time.After(time.Second):
+		t.Fatal("job did not run on start")
+	}
+	time.Sleep(50 * time.Millisecond)
+	r.Stop()
+
+	n := runs.Load()
+	if n < 3 || failures.Load() < 3 {
+		t.Fatalf("jobs ran %d and %d times, want at least 3", n, failures.Load())
+	}
+	time.Sleep(30 * time.Millisecond)
+	if runs.Load() != n {
+		t.Fatal("job ran after Stop")
+	}
+}
+
+func TestRunnerStopWithoutStart(t *testing.T) {
+	NewRunner().Stop()
+}
+
+func TestRunnerCheck(t *testing.T) {
+	release := make(chan struct{})
+	r := NewRunner(
+		Job{Name: "quick", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error { return nil }},
+		// A job stuck in its first run.
+		Job{Name: "stuck", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			return nil
+		}},
+	)
+	if err := r.Check(context.Background()); err == nil {
+		t.Fatal("no error before Start")
+	}
+	r.Start(context.Background())
+	if err := r.Check(context.Background()); err != nil {
+		t.Fatalf("check right after Start = %v", err)
+	}
+	time.Sleep(stalledAfter*10*time.Millisecond + 20*time.Millisecond)
+	if err := r.Check(context.Background()); err == nil || !strings.Contains(err.Error(), "stuck") {
+		t.Fatalf("check = %v, want the stuck job", err)
+	}
+	close(release)
+	time.Sleep(20 * time.Millisecond)
+	if err := r.Check(context.Background()); err != nil {
+		t.Fatalf("check once the job finished = %v", err)
+	}
+	r.Stop()
+	if err := r.Check(context.Background()); err == nil {
+		t.Fatal("no error after Stop")
+	}
+}